@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxAttachmentSize keeps an attached image well within the link message limit.
+const maxAttachmentSize = 20 << 20
+
+// readAttachment loads the image at path for the /attach command of the TUI.
+func readAttachment(path string) ([]byte, error) {
+	path = strings.Trim(strings.TrimSpace(path), `"'`)
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(homeDir, rest)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxAttachmentSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", filepath.Base(path), maxAttachmentSize>>20)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if mediaType := http.DetectContentType(data); !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("%s is not an image (%s)", filepath.Base(path), mediaType)
+	}
+	return data, nil
+}
@@ -125,7 +125,7 @@ func setupYafai(env string) (err error) {
 	err = godotenv.Load(fmt.Sprintf("%s/.env", yafaiRoot))
 
 	if err != nil {
-		fmt.Errorf("Error loading .env file : %s", err.Error())
+		slog.Error("Error loading .env file", "error", err)
 		log.Panic(err)
 	}
 
@@ -134,7 +134,7 @@ func setupYafai(env string) (err error) {
 	err = setupLogging(yafaiRoot)
 
 	if err != nil {
-		fmt.Errorf("Failed to create log file - %s", err.Error())
+		slog.Error("Failed to create log file", "error", err)
 		log.Panic(err)
 	}
 
@@ -149,7 +149,7 @@ func StartLink(ctx context.Context) (err error) {
 		return err
 	}
 
-	l := grpc.NewServer()
+	l := grpc.NewServer(grpc.MaxRecvMsgSize(link.MaxMessageSize))
 
 	linkServer, err := link.NewLinkServer("localhost:7002")
 	if err != nil {
@@ -183,7 +183,7 @@ func StartWsp(ctx context.Context, wspConfig *workspace.Workspace) (err error) {
 		return err
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(link.MaxMessageSize))
 
 	wspServer := &wsp.WorkspaceServer{
 		Wsp: wspConfig,
@@ -207,7 +207,22 @@ func StartWsp(ctx context.Context, wspConfig *workspace.Workspace) (err error) {
 	return nil
 }
 
-func RunClient(ctx context.Context, wsp *workspace.Workspace) error {
+// planAction splits the plan commands /plan, /refine, /confirm and /cancel into
+// their action and request. Anything else is a chat message.
+func planAction(text string) (string, string) {
+	for _, action := range []string{link.ActionPlan, link.ActionRefine, link.ActionConfirm, link.ActionCancel} {
+		command := "/" + action
+		if text == command {
+			return action, ""
+		}
+		if request, ok := strings.CutPrefix(text, command+" "); ok {
+			return action, strings.TrimSpace(request)
+		}
+	}
+	return "", text
+}
+
+func RunClient(ctx context.Context, wsp *workspace.Workspace, resumeID string) error {
 
 	app := tview.NewApplication()
 	title := fmt.Sprintf("[yellow::b] YAFAI - %s workspace", wsp.Name) // Assuming wsp is defined
@@ -279,7 +294,7 @@ func RunClient(ctx context.Context, wsp *workspace.Workspace) error {
 		AddItem(banner, 3, 0, false).
 		AddItem(mainFrame, 0, 1, true) // mainFrame takes remaining height
 	// gRPC connection
-	conn, err := grpc.NewClient("localhost:7001", grpc.WithInsecure())
+	conn, err := grpc.NewClient("localhost:7001", grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(link.MaxMessageSize)))
 	if err != nil {
 		slog.Error("Failed to connect to gRPC server", "error", err)
 		return err
@@ -296,18 +311,57 @@ func RunClient(ctx context.Context, wsp *workspace.Workspace) error {
 		return err
 	}
 
+	// Rejoin the session being resumed before the user's first message.
+	if resumeID != "" {
+		if err := stream.Send(&link.ChatRequest{SessionId: resumeID}); err != nil {
+			slog.Error("Failed to resume session", "session_id", resumeID, "error", err)
+		}
+	}
+
+	// Images queued with /attach go out with the next message; /detach drops them.
+	var attachments [][]byte
+
 	// Handle user input
 	inputField.SetDoneFunc(func(key tcell.Key) {
 		if key == tcell.KeyEnter {
 			text := inputField.GetText()
+			if path, ok := strings.CutPrefix(text, "/attach "); ok {
+				data, err := readAttachment(path)
+				if err != nil {
+					statusView.Write([]byte("\n[red]Attach failed: " + err.Error() + "\n"))
+				} else {
+					attachments = append(attachments, data)
+					statusView.Write([]byte(fmt.Sprintf("\n[white]Attached %s (%d KB), sent with your next message\n", strings.TrimSpace(path), len(data)>>10)))
+				}
+				inputField.SetText("")
+				return
+			}
+			if id, ok := strings.CutPrefix(text, "/resume "); ok {
+				if err := stream.Send(&link.ChatRequest{SessionId: strings.TrimSpace(id)}); err != nil {
+					slog.Error("Failed to resume session", "error", err)
+				}
+				inputField.SetText("")
+				return
+			}
+			if text == "/detach" {
+				attachments = nil
+				statusView.Write([]byte("\n[white]Attachments cleared\n"))
+				inputField.SetText("")
+				return
+			}
 			if text != "" {
+				action, request := planAction(text)
 				userMsg := "You: \n" + text
+				if len(attachments) > 0 {
+					userMsg += fmt.Sprintf("\n(%d image(s) attached)", len(attachments))
+				}
 				//alignedMsg := alignRight(userMsg, chatWidth)
 				chatView.Write([]byte("\n[blue]" + userMsg + "\n"))
 				chatView.Write([]byte("[white]----------------------------------------\n"))
-				if err := stream.Send(&link.ChatRequest{Request: text}); err != nil {
+				if err := stream.Send(&link.ChatRequest{Request: request, Stream: true, Images: attachments, Action: action}); err != nil {
 					slog.Error("Failed to send message", "error", err)
 				}
+				attachments = nil
 				inputField.SetText("")
 			}
 		}
@@ -315,6 +369,8 @@ func RunClient(ctx context.Context, wsp *workspace.Workspace) error {
 
 	// Handle server responses
 	go func() {
+		// Trace of the answer currently being streamed token by token, if any.
+		streamingTrace := ""
 		for {
 			resp, err := stream.Recv()
 			if err == io.EOF {
@@ -328,7 +384,34 @@ func RunClient(ctx context.Context, wsp *workspace.Workspace) error {
 
 			// 	continue
 			// }
-			if !strings.Contains(resp.Response, "STATUS:") {
+			if resp.Kind == link.KindPlan {
+				chatView.Write([]byte("\n[yellow]YAFAI: \n" + tview.Escape(resp.Response) + "\n"))
+				chatView.Write([]byte("[grey]/confirm runs it, /refine <changes> revises it, /cancel drops it\n"))
+				sideView.Write([]byte("[orange]" + resp.Trace + "\n"))
+				chatView.Write([]byte("[white]----------------------------------------\n"))
+			} else if resp.Kind == link.KindSession {
+				statusView.Write([]byte("\n[white]Session: " + resp.Response + "\n"))
+			} else if resp.Kind == link.KindTrace {
+				sideView.Write([]byte("[grey]" + tview.Escape(resp.Response) + "\n"))
+			} else if resp.Kind == link.KindDelta {
+				if streamingTrace != resp.Trace {
+					if streamingTrace != "" {
+						chatView.Write([]byte("\n\n[white]----------------------------------------\n"))
+					}
+					streamingTrace = resp.Trace
+					chatView.Write([]byte("\n[green]YAFAI: \n"))
+					sideView.Write([]byte("[orange]" + resp.Trace + "\n"))
+				}
+				chatView.Write([]byte(resp.Response))
+			} else if resp.Kind == link.KindEnd && streamingTrace != "" {
+				// The end message names the model that answered, which may be a fallback.
+				if resp.Trace != streamingTrace {
+					sideView.Write([]byte("[orange]" + resp.Trace + "\n"))
+				}
+				streamingTrace = ""
+				chatView.Write([]byte("\n\n"))
+				chatView.Write([]byte("[white]----------------------------------------\n"))
+			} else if !strings.Contains(resp.Response, "STATUS:") {
 				serverMsg := "YAFAI: \n" + resp.Response
 				chatView.Write([]byte("\n[green]" + serverMsg + "\n\n"))
 				sideView.Write([]byte("[orange]" + resp.Trace + "\n"))
@@ -359,7 +442,9 @@ func RunClient(ctx context.Context, wsp *workspace.Workspace) error {
 	return err
 }
 
-func StartYafai(env string, mode string, configsPath string) error {
+// StartYafai runs the workspace of the chosen config. A non-empty resumeID makes the
+// TUI continue that saved session.
+func StartYafai(env string, mode string, configsPath string, resumeID string) error {
 
 	err := setupYafai(env)
 	slog.Info(configsPath)
@@ -370,13 +455,13 @@ func StartYafai(env string, mode string, configsPath string) error {
 
 	//Set root path to env
 	rootPath := os.Getenv("YAFAI_ROOT")
-	slog.Info("Root set to : %s", rootPath)
+	slog.Info("Root set", "path", rootPath)
 
 	if configsPath != "default" {
-		slog.Info("Configs Path set to :%s", configsPath)
+		slog.Info("Configs path set", "path", configsPath)
 	} else {
 		configsPath = fmt.Sprintf("%s/configs", rootPath)
-		slog.Info("Configs Path set to :%s", configsPath)
+		slog.Info("Configs path set", "path", configsPath)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -423,8 +508,23 @@ func StartYafai(env string, mode string, configsPath string) error {
 
 	configPath = fmt.Sprintf("%s/%s", configsPath, selectedConfig)
 
-	wsp := config.ParseConfig(configPath)
-	slog.Info("Welcome to %s workspace", wsp.Name, nil)
+	wsp, err := config.ParseConfig(configPath)
+	if err != nil {
+		fmt.Printf("Failed to load workspace config: %v\n", err)
+		slog.Error("Failed to load workspace config", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Welcome to workspace", "name", wsp.Name)
+
+	sessions, err := sessionStore()
+	if err != nil {
+		slog.Error("Sessions will not be saved", "error", err)
+	} else {
+		wsp.Sessions = sessions
+	}
+
+	// Pull missing models in the background; progress shows in the TUI status pane.
+	go wsp.PrepareModels(ctx)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -454,7 +554,7 @@ func StartYafai(env string, mode string, configsPath string) error {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err := RunClient(ctx, wsp)
+			err := RunClient(ctx, wsp, resumeID)
 			if err != nil {
 				slog.Error("Error starting YAFAI client: %v", err.Error(), nil)
 				cancel()
@@ -514,7 +614,7 @@ var rootCmd = &cobra.Command{
 		env, _ := cmd.Flags().GetString("env")
 		mode, _ := cmd.Flags().GetString("mode")
 		configsPath, _ := cmd.Flags().GetString("configsPath")
-		StartYafai(env, mode, configsPath)
+		StartYafai(env, mode, configsPath, "")
 	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/session"
+
+	"github.com/spf13/cobra"
+)
+
+// sessionStore opens the session store under the YAFAI root, ~/.yafai by default.
+func sessionStore() (*session.FileStore, error) {
+	root := os.Getenv("YAFAI_ROOT")
+	if root == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		root = filepath.Join(homeDir, ".yafai")
+	}
+	return session.NewFileStore(filepath.Join(root, "sessions"))
+}
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage saved conversation sessions",
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List saved sessions, most recent first",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := sessionStore()
+		if err != nil {
+			return err
+		}
+		summaries, err := store.List()
+		if err != nil {
+			return err
+		}
+		if len(summaries) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No saved sessions.")
+			return nil
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tWORKSPACE\tUPDATED\tMESSAGES\tFIRST REQUEST")
+		for _, s := range summaries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", s.ID, s.Workspace, s.Updated.Format(time.DateTime), s.Messages, s.Title)
+		}
+		return w.Flush()
+	},
+}
+
+var sessionsShowCmd = &cobra.Command{
+	Use:          "show <id>",
+	Short:        "Print the conversation, plan and traces of a session",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := sessionStore()
+		if err != nil {
+			return err
+		}
+		s, err := store.Load(args[0])
+		if err != nil {
+			return err
+		}
+		printSession(cmd.OutOrStdout(), s)
+		return nil
+	},
+}
+
+var sessionsResumeCmd = &cobra.Command{
+	Use:          "resume <id>",
+	Short:        "Start the workspace and continue a saved session",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := sessionStore()
+		if err != nil {
+			return err
+		}
+		if _, err := store.Load(args[0]); err != nil {
+			return err
+		}
+		env, _ := cmd.Flags().GetString("env")
+		mode, _ := cmd.Flags().GetString("mode")
+		configsPath, _ := cmd.Flags().GetString("configsPath")
+		return StartYafai(env, mode, configsPath, args[0])
+	},
+}
+
+var sessionsDeleteCmd = &cobra.Command{
+	Use:          "delete <id>",
+	Short:        "Delete a saved session",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := sessionStore()
+		if err != nil {
+			return err
+		}
+		if err := store.Delete(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted session %s\n", args[0])
+		return nil
+	},
+}
+
+// printSession writes a readable transcript of s to w.
+func printSession(w io.Writer, s *session.Session) {
+	fmt.Fprintf(w, "Session:   %s\n", s.ID)
+	fmt.Fprintf(w, "Workspace: %s\n", s.Workspace)
+	fmt.Fprintf(w, "Created:   %s\n", s.Created.Format(time.DateTime))
+	fmt.Fprintf(w, "Updated:   %s\n", s.Updated.Format(time.DateTime))
+	fmt.Fprintf(w, "Usage:     %s\n", s.Usage)
+
+	fmt.Fprintln(w, "\nConversation:")
+	for _, record := range s.Orchestrator {
+		fmt.Fprintf(w, "  %s -> %s: %s\n", record.From, record.To, orchestratorMessage(record))
+	}
+
+	if s.Plan != nil && len(s.Plan.Response) > 0 {
+		status := "not confirmed"
+		if s.PlanConfirmed {
+			status = "confirmed"
+		}
+		fmt.Fprintf(w, "\nPlan (%s):\n", status)
+		for _, line := range strings.Split(strings.TrimRight(s.Plan.Describe(), "\n"), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	for name, history := range s.Agents {
+		fmt.Fprintf(w, "\nAgent %s: %d messages\n", name, len(history))
+	}
+
+	if len(s.Traces) > 0 {
+		fmt.Fprintln(w, "\nTraces:")
+		for _, trace := range s.Traces {
+			fmt.Fprintf(w, "  %s %s: %s\n", trace.Time.Format(time.TimeOnly), trace.Source, trace.Message)
+		}
+	}
+}
+
+// orchestratorMessage shows the action replies of the orchestrator as their text.
+func orchestratorMessage(record *executors.ChatRecord) string {
+	if record.From != "orchestrator" {
+		return record.Message
+	}
+	var action executors.OrchestratorAction
+	if err := json.Unmarshal([]byte(record.Message), &action); err != nil {
+		return record.Message
+	}
+	switch action.Action {
+	case executors.ActionAgentInvoke:
+		return fmt.Sprintf("invoke %s: %s", action.Name, action.Task)
+	case executors.ActionChat:
+		return action.Chat
+	case executors.ActionAnswer:
+		return action.Answer
+	}
+	return record.Message
+}
+
+func init() {
+	sessionsCmd.AddCommand(sessionsListCmd, sessionsShowCmd, sessionsResumeCmd, sessionsDeleteCmd)
+	rootCmd.AddCommand(sessionsCmd)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/session"
+)
+
+// sessionsRoot points YAFAI_ROOT at a temporary directory holding the given sessions.
+func sessionsRoot(t *testing.T, sessions ...*session.Session) *session.FileStore {
+	t.Helper()
+	t.Setenv("YAFAI_ROOT", t.TempDir())
+	store, err := sessionStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range sessions {
+		if err := store.Save(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+// runSessions runs the sessions command with args and returns what it printed.
+func runSessions(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs(append([]string{"sessions"}, args...))
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+	})
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func savedSession(id string, updated time.Time, request string) *session.Session {
+	return &session.Session{
+		ID:        id,
+		Workspace: "research",
+		Created:   updated,
+		Updated:   updated,
+		Orchestrator: []*executors.ChatRecord{
+			{From: "user", To: "orchestrator", Role: "user", Message: request},
+			{From: "orchestrator", To: "user", Role: "assistant", Message: `{"action":"agent_invoke","name":"looker","task":"look it up"}`},
+			{From: "orchestrator", To: "user", Role: "assistant", Message: `{"action":"answer","answer":"it is a cat"}`},
+		},
+		Agents:        map[string][]*executors.ChatRecord{"looker": {{From: "orchestrator", To: "agent", Role: "user", Message: "look it up"}}},
+		Plan:          &executors.PlannerResponse{Response: []*executors.PlannerTask{{ID: "t1", Task: "look it up", Agent: "looker"}}},
+		PlanConfirmed: true,
+		Traces:        []session.Trace{{Time: updated, Source: "looker", Message: "looking"}},
+	}
+}
+
+func TestSessionsList(t *testing.T) {
+	now := time.Now()
+	sessionsRoot(t, savedSession("older", now.Add(-time.Hour), "first question"), savedSession("newer", now, "second question"))
+
+	out, err := runSessions(t, "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") {
+		t.Fatalf("list printed %q, want a header and two sessions", out)
+	}
+	if !strings.HasPrefix(lines[1], "newer") || !strings.Contains(lines[1], "second question") || !strings.HasPrefix(lines[2], "older") {
+		t.Errorf("list printed %q, want the newer session first", out)
+	}
+}
+
+func TestSessionsListEmpty(t *testing.T) {
+	sessionsRoot(t)
+	out, err := runSessions(t, "list")
+	if err != nil || out != "No saved sessions.\n" {
+		t.Errorf("list printed %q, %v", out, err)
+	}
+}
+
+func TestSessionsShow(t *testing.T) {
+	sessionsRoot(t, savedSession("s1", time.Now(), "what is this?"))
+
+	out, err := runSessions(t, "show", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Session:   s1",
+		"Workspace: research",
+		"user -> orchestrator: what is this?",
+		"orchestrator -> user: invoke looker: look it up",
+		"orchestrator -> user: it is a cat",
+		"Plan (confirmed):",
+		"Agent looker: 1 messages",
+		"looker: looking",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("show printed %q, want it to contain %q", out, want)
+		}
+	}
+}
+
+func TestSessionsDelete(t *testing.T) {
+	store := sessionsRoot(t, savedSession("s1", time.Now(), "what is this?"))
+
+	out, err := runSessions(t, "delete", "s1")
+	if err != nil || out != "Deleted session s1\n" {
+		t.Errorf("delete printed %q, %v", out, err)
+	}
+	if _, err := store.Load("s1"); !errors.Is(err, session.ErrNotFound) {
+		t.Errorf("deleted session still loads: %v", err)
+	}
+}
+
+func TestSessionsMissing(t *testing.T) {
+	sessionsRoot(t)
+	for _, command := range []string{"show", "resume", "delete"} {
+		if _, err := runSessions(t, command, "missing"); !errors.Is(err, session.ErrNotFound) {
+			t.Errorf("%s error = %v, want not found", command, err)
+		}
+		if _, err := runSessions(t, command, "../outside"); err == nil {
+			t.Errorf("%s accepted an ID outside the store", command)
+		}
+	}
+}
+
+func TestSessionStoreUnderRoot(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("YAFAI_ROOT", root)
+	store, err := sessionStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Dir != filepath.Join(root, "sessions") {
+		t.Errorf("store in %s, want %s", store.Dir, filepath.Join(root, "sessions"))
+	}
+}
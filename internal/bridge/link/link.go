@@ -17,7 +17,7 @@ import (
 func NewLinkServer(workspaceServerAddress string) (*LinkServer, error) {
 	// Establish a single gRPC connection to the workspace server that will be shared by all clients.
 	// In production, use secure connections (e.g., grpc.WithTransportCredentials).
-	conn, err := grpc.Dial(workspaceServerAddress, grpc.WithInsecure()) // Use grpc.WithTransportCredentials in production
+	conn, err := grpc.Dial(workspaceServerAddress, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(MaxMessageSize))) // Use grpc.WithTransportCredentials in production
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial workspace server: %w", err)
 	}
@@ -93,6 +93,7 @@ func (l *LinkServer) ChatStream(stream ChatService_ChatStreamServer) (err error)
 			if err := stream.Send(&ChatResponse{
 				Response: resp.Response,
 				Trace:    resp.Trace,
+				Kind:     resp.Kind,
 			}); err != nil {
 				errChan <- fmt.Errorf("client send failed: %w", err)
 				return
@@ -121,7 +122,11 @@ func (l *LinkServer) ChatStream(stream ChatService_ChatStreamServer) (err error)
 			}
 
 			if err := wspStream.Send(&wsp.LinkRequest{
-				Request: packet.Request,
+				Request:   packet.Request,
+				Stream:    packet.Stream,
+				Images:    packet.Images,
+				SessionId: packet.SessionId,
+				Action:    packet.Action,
 			}); err != nil {
 				return fmt.Errorf("workspace send error: %w", err)
 			}
@@ -1,6 +1,8 @@
 package link
 
 import (
+	wsp "yafai/internal/bridge/wsp"
+
 	"google.golang.org/grpc"
 )
 
@@ -8,3 +10,25 @@ type LinkServer struct {
 	UnimplementedChatServiceServer
 	WspConn *grpc.ClientConn
 }
+
+// ChatResponse kinds, mirroring the workspace LinkResponse kinds.
+const (
+	KindDelta   = wsp.KindDelta
+	KindEnd     = wsp.KindEnd
+	KindTrace   = wsp.KindTrace
+	KindSession = wsp.KindSession
+	KindPlan    = wsp.KindPlan
+)
+
+// ChatRequest actions, mirroring the workspace LinkRequest actions.
+const (
+	ActionChat    = wsp.ActionChat
+	ActionPlan    = wsp.ActionPlan
+	ActionRefine  = wsp.ActionRefine
+	ActionConfirm = wsp.ActionConfirm
+	ActionCancel  = wsp.ActionCancel
+)
+
+// MaxMessageSize bounds the gRPC messages of the link and workspace services, which
+// are raised from the 4 MB default to make room for attached images.
+const MaxMessageSize = 32 << 20
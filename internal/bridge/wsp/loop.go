@@ -0,0 +1,126 @@
+package wsp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yafai/internal/nexus/executors"
+)
+
+// maxOutcomeLength bounds the result of an agent call quoted in a loop summary.
+const maxOutcomeLength = 200
+
+// agentAttempt is one agent call made while answering a request.
+type agentAttempt struct {
+	agent   string
+	task    string
+	outcome string
+	failed  bool
+}
+
+// loopGuard holds the orchestrator loop answering one request to the orchestrator's
+// limits, and remembers the agent calls to tell the user what was tried.
+type loopGuard struct {
+	maxIterations int
+	maxFailures   int
+	maxDuration   time.Duration
+
+	iterations int
+	failures   int
+	repeats    int
+	attempts   []agentAttempt
+}
+
+func newLoopGuard(orchestrator *executors.YafaiOrchestrator) *loopGuard {
+	iterations, failures, duration := orchestrator.Limits()
+	return &loopGuard{maxIterations: iterations, maxFailures: failures, maxDuration: duration}
+}
+
+// context bounds ctx by the time allowed per request, if any.
+func (g *loopGuard) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if g.maxDuration <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, g.maxDuration)
+}
+
+// expired returns why the loop must stop when ctx, from context, ran out of time.
+func (g *loopGuard) expired(ctx context.Context) string {
+	if ctx.Err() != context.DeadlineExceeded {
+		return ""
+	}
+	return fmt.Sprintf("it took longer than the limit of %s", g.maxDuration)
+}
+
+// invoke counts an agent call before it runs. A call repeating an earlier one returns
+// that attempt instead of running again; repeating a second time, or calling past the
+// iteration limit, returns why the loop must stop.
+func (g *loopGuard) invoke(agent string, task string) (*agentAttempt, string) {
+	if g.iterations >= g.maxIterations {
+		return nil, fmt.Sprintf("it reached the limit of %d agent calls", g.maxIterations)
+	}
+	g.iterations++
+	for i := range g.attempts {
+		previous := &g.attempts[i]
+		if previous.agent != agent || !strings.EqualFold(strings.TrimSpace(previous.task), strings.TrimSpace(task)) {
+			continue
+		}
+		g.repeats++
+		if g.repeats > 1 {
+			return nil, fmt.Sprintf("the orchestrator kept repeating the same call to agent %s", agent)
+		}
+		return previous, ""
+	}
+	return nil, ""
+}
+
+// record adds the outcome of an agent call and returns why the loop must stop when
+// agents failed too many times in a row.
+func (g *loopGuard) record(agent string, task string, output string, err error) string {
+	attempt := agentAttempt{agent: agent, task: task, outcome: output}
+	if err != nil {
+		attempt.outcome, attempt.failed = err.Error(), true
+		g.failures++
+	} else {
+		g.failures = 0
+	}
+	g.attempts = append(g.attempts, attempt)
+	if g.failures >= g.maxFailures {
+		return fmt.Sprintf("agents failed %d times in a row", g.failures)
+	}
+	return ""
+}
+
+// repeatRequest tells the orchestrator a call repeats an earlier one, with its result.
+func repeatRequest(previous *agentAttempt) string {
+	result := "Observation: " + previous.outcome
+	if previous.failed {
+		result = "It failed with error: " + previous.outcome
+	}
+	return fmt.Sprintf("You already invoked agent '%s' with this task. %s (from %s)\nDo not repeat the call: use this result, give a different task, or answer.", previous.agent, result, previous.agent)
+}
+
+// summary tells the user why the request stopped and what was tried.
+func (g *loopGuard) summary(reason string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "I could not complete this request: %s.", reason)
+	if len(g.attempts) == 0 {
+		b.WriteString("\nNo agent was called.")
+		return b.String()
+	}
+	b.WriteString("\nWhat was tried:")
+	for i, attempt := range g.attempts {
+		outcome := strings.Join(strings.Fields(attempt.outcome), " ")
+		if runes := []rune(outcome); len(runes) > maxOutcomeLength {
+			outcome = string(runes[:maxOutcomeLength]) + "..."
+		}
+		status := "returned"
+		if attempt.failed {
+			status = "failed"
+		}
+		fmt.Fprintf(&b, "\n%d. %s: %s (%s: %s)", i+1, attempt.agent, attempt.task, status, outcome)
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package wsp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoopGuardInvoke(t *testing.T) {
+	type call struct {
+		agent, task string
+		output      string
+		failed      bool
+	}
+	tests := []struct {
+		name          string
+		maxIterations int
+		calls         []call
+		next          call
+		wantPrevious  bool
+		wantReason    string
+	}{
+		{
+			name:          "new call",
+			maxIterations: 5,
+			calls:         []call{{agent: "a", task: "find", output: "found"}},
+			next:          call{agent: "b", task: "find"},
+		},
+		{
+			name:          "repeat returns the earlier attempt",
+			maxIterations: 5,
+			calls:         []call{{agent: "a", task: "find", output: "found"}},
+			next:          call{agent: "a", task: "  FIND "},
+			wantPrevious:  true,
+		},
+		{
+			name:          "same task for another agent is no repeat",
+			maxIterations: 5,
+			calls:         []call{{agent: "a", task: "find", output: "found"}},
+			next:          call{agent: "b", task: "find"},
+		},
+		{
+			name:          "iteration cap",
+			maxIterations: 2,
+			calls:         []call{{agent: "a", task: "one"}, {agent: "a", task: "two"}},
+			next:          call{agent: "a", task: "three"},
+			wantReason:    "it reached the limit of 2 agent calls",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &loopGuard{maxIterations: tt.maxIterations, maxFailures: 10}
+			for _, c := range tt.calls {
+				if previous, reason := g.invoke(c.agent, c.task); previous != nil || reason != "" {
+					t.Fatalf("invoke(%q, %q) = %v, %q", c.agent, c.task, previous, reason)
+				}
+				var err error
+				if c.failed {
+					err = errors.New(c.output)
+				}
+				g.record(c.agent, c.task, c.output, err)
+			}
+			previous, reason := g.invoke(tt.next.agent, tt.next.task)
+			if (previous != nil) != tt.wantPrevious {
+				t.Errorf("previous = %v, want one: %v", previous, tt.wantPrevious)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestLoopGuardSecondRepeatStops(t *testing.T) {
+	g := &loopGuard{maxIterations: 10, maxFailures: 10}
+	g.invoke("a", "find")
+	g.record("a", "find", "found", nil)
+	if previous, _ := g.invoke("a", "find"); previous == nil || previous.outcome != "found" {
+		t.Fatalf("first repeat = %v, want the earlier attempt", previous)
+	}
+	if _, reason := g.invoke("a", "find"); !strings.Contains(reason, "repeating the same call to agent a") {
+		t.Fatalf("second repeat reason = %q", reason)
+	}
+}
+
+func TestLoopGuardRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []bool // true for a failure
+		want    string
+	}{
+		{name: "one failure", results: []bool{true}},
+		{name: "failures in a row", results: []bool{true, true}, want: "agents failed 2 times in a row"},
+		{name: "success resets the count", results: []bool{true, false, true}},
+		{name: "failures after a success", results: []bool{true, false, true, true}, want: "agents failed 2 times in a row"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &loopGuard{maxIterations: 10, maxFailures: 2}
+			var reason string
+			for i, failed := range tt.results {
+				var err error
+				if failed {
+					err = errors.New("boom")
+				}
+				reason = g.record("a", string(rune('a'+i)), "ok", err)
+			}
+			if reason != tt.want {
+				t.Errorf("reason = %q, want %q", reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoopGuardExpired(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		wait     bool
+		cancel   bool
+		want     string
+	}{
+		{name: "no limit", duration: 0},
+		{name: "within the limit", duration: time.Hour},
+		{name: "past the limit", duration: time.Millisecond, wait: true, want: "it took longer than the limit of 1ms"},
+		{name: "cancelled is not expired", duration: time.Hour, cancel: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &loopGuard{maxDuration: tt.duration}
+			ctx, cancel := g.context(context.Background())
+			defer cancel()
+			if tt.wait {
+				<-ctx.Done()
+			}
+			if tt.cancel {
+				cancel()
+			}
+			if got := g.expired(ctx); got != tt.want {
+				t.Errorf("expired = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoopGuardSummary(t *testing.T) {
+	g := &loopGuard{maxIterations: 10, maxFailures: 10}
+	if got := g.summary("it stopped"); got != "I could not complete this request: it stopped.\nNo agent was called." {
+		t.Errorf("summary without calls = %q", got)
+	}
+	g.record("a", "find", "found\n  it", nil)
+	g.record("b", "fetch", "", errors.New("boom"))
+	g.record("c", "long", strings.Repeat("x", maxOutcomeLength+10), nil)
+	want := "I could not complete this request: it stopped.\nWhat was tried:\n" +
+		"1. a: find (returned: found it)\n" +
+		"2. b: fetch (failed: boom)\n" +
+		"3. c: long (returned: " + strings.Repeat("x", maxOutcomeLength) + "...)"
+	if got := g.summary("it stopped"); got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+}
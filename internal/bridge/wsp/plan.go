@@ -0,0 +1,146 @@
+package wsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/workspace"
+)
+
+// refinementRequest asks the planner to revise plan, given as JSON, as the user asked.
+func refinementRequest(plan string, refinement string, images []string) string {
+	request := fmt.Sprintf("Refine the following plan,\n %s \n based on the refinement request: %s. Stick to the formatting isntructions", plan, refinement)
+	return withImageNote(request, images)
+}
+
+// withImageNote tells the planner about the images attached to request. Like the
+// orchestrator it does not see them; they are passed to every task of the plan.
+func withImageNote(request string, images []string) string {
+	if len(images) == 0 {
+		return request
+	}
+	return request + fmt.Sprintf("\n\n[The user attached %d image(s); they are passed to every task of the plan.]", len(images))
+}
+
+// makePlan asks the planner for a plan of request, returning it with the model that
+// made it.
+func (s *WorkspaceServer) makePlan(ctx context.Context, request string) (*executors.PlannerResponse, string, error) {
+	if s.Wsp.Planner == nil || s.Wsp.Planner.Model == "" {
+		return nil, "", errors.New("this workspace has no planner configured")
+	}
+	resp, err := s.Wsp.Planner.Execute(ctx, &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: request}})
+	if err != nil {
+		return nil, "", err
+	}
+	steps, err := s.Wsp.Planner.Parse(resp)
+	if err != nil {
+		return nil, resp.Model, err
+	}
+	return &executors.PlannerResponse{Request: request, Response: steps}, resp.Model, nil
+}
+
+// planError tells the client why no plan came out, listing the rules an invalid plan
+// still breaks after the planner's revisions.
+func planError(err error) string {
+	var invalid *executors.PlanValidationError
+	if !errors.As(err, &invalid) {
+		return fmt.Sprintf("Planner Error: %s", providers.Describe(err))
+	}
+	var b strings.Builder
+	b.WriteString("The planner could not make a plan the team can run:")
+	for _, violation := range invalid.Violations {
+		fmt.Fprintf(&b, "\n- %s", violation)
+	}
+	return b.String()
+}
+
+// sendPlan shows the pending plan to the client.
+func sendPlan(stream WorkspaceService_LinkStreamServer, plan *executors.PlannerResponse, model string) {
+	text := fmt.Sprintf("Plan for: %s\n%s\nConfirm to run it, refine it with your changes, or cancel it.", plan.Request, plan.Describe())
+	stream.Send(&LinkResponse{Response: text, Trace: sourceTrace("Planner", model), Kind: KindPlan})
+}
+
+// handlePlanAction runs the plan, refine, confirm and cancel actions of a packet
+// against the connection's orchestrator.
+func (s *WorkspaceServer) handlePlanAction(ctx context.Context, stream WorkspaceService_LinkStreamServer, runtime *workspace.Runtime, packet *LinkRequest, images []string) {
+	orchestrator := runtime.Orchestrator
+	reply := func(message string) {
+		stream.Send(&LinkResponse{Response: message, Trace: "Source: Planner"})
+	}
+
+	switch packet.Action {
+	case ActionPlan:
+		plan, model, err := s.makePlan(ctx, withImageNote(packet.Request, images))
+		if err != nil {
+			slog.Error("Planning failed", "error", err)
+			reply(planError(err))
+			return
+		}
+		plan.Request = packet.Request
+		plan.Image = images
+		orchestrator.UpdatePlan(plan)
+		sendPlan(stream, plan, model)
+
+	case ActionRefine:
+		if orchestrator.Plan == nil {
+			reply("There is no plan to refine. Ask for a plan first.")
+			return
+		}
+		current, err := json.Marshal(orchestrator.Plan.Response)
+		if err != nil {
+			reply(fmt.Sprintf("Internal Error: %v", err))
+			return
+		}
+		plan, model, err := s.makePlan(ctx, refinementRequest(string(current), packet.Request, images))
+		if err != nil {
+			slog.Error("Plan refinement failed", "error", err)
+			reply(planError(err))
+			return
+		}
+		plan.Request = fmt.Sprintf("%s (refined: %s)", orchestrator.Plan.Request, packet.Request)
+		// The previous plan may still be shared, so its images are never appended in place
+		previous := orchestrator.Plan.Image
+		plan.Image = append(previous[:len(previous):len(previous)], images...)
+		orchestrator.UpdatePlan(plan)
+		sendPlan(stream, plan, model)
+
+	case ActionConfirm:
+		if orchestrator.Plan == nil {
+			reply("There is no plan to confirm. Ask for a plan first.")
+			return
+		}
+		orchestrator.UpdatePlanStatus(true)
+		fwd := newDeltaForwarder(packet, stream, "Source: Orchestrator")
+		req := &executors.YafaiRequest{
+			Request: &providers.RequestMessage{Role: "user", Image: images},
+			OnDelta: fwd.OnDelta(),
+			OnTrace: traceForwarder(stream, "Plan"),
+		}
+		res, err := orchestrator.ExecutePlan(ctx, req)
+		if err != nil {
+			// The plan stays pending so the user can run it again
+			slog.Error("Plan execution failed", "error", err)
+			orchestrator.UpdatePlanStatus(false)
+			stream.Send(&LinkResponse{Response: fmt.Sprintf("Plan Error: %s", providers.Describe(err)), Trace: "Source: Orchestrator"})
+			return
+		}
+		stream.Send(&LinkResponse{Response: res.Response.Content, Trace: sourceTrace("Orchestrator", res.Model), Kind: fwd.Kind()})
+
+	case ActionCancel:
+		if orchestrator.Plan == nil {
+			reply("There is no plan to cancel.")
+			return
+		}
+		orchestrator.UpdatePlan(nil)
+		reply("Plan cancelled.")
+
+	default:
+		stream.Send(&LinkResponse{Response: fmt.Sprintf("Unknown action %q (expected %s, %s, %s, %s or %s).", packet.Action, ActionChat, ActionPlan, ActionRefine, ActionConfirm, ActionCancel), Trace: "Source: Workspace"})
+	}
+}
@@ -0,0 +1,68 @@
+package wsp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/workspace"
+)
+
+// planServer is a workspace whose planner, backed by planner, plans for a team of "a".
+func planServer(planner *scriptedProvider) (*WorkspaceServer, *workspace.Runtime) {
+	team := []*executors.YafaiAgent{{Name: "a", Description: "looks at pictures"}}
+	server := &WorkspaceServer{Wsp: &workspace.Workspace{Name: "w", Planner: &executors.YafaiPlanner{Agents: team, Model: "m", GenAIProvider: planner}}}
+	return server, &workspace.Runtime{Workspace: "w", Orchestrator: &executors.YafaiOrchestrator{}}
+}
+
+// lastRequest is the user message of the last request the planner was sent.
+func (p *scriptedProvider) lastRequest() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	messages := p.requests[len(p.requests)-1].Messages
+	return messages[len(messages)-1].Content
+}
+
+func TestPlanActionsTellThePlannerAboutImages(t *testing.T) {
+	planner := &scriptedProvider{replies: []string{
+		`{"tasks":[{"id":"t1","task":"describe the picture","agent":"a"}]}`,
+		`{"tasks":[{"id":"t1","task":"compare the pictures","agent":"a"}]}`,
+	}}
+	server, runtime := planServer(planner)
+	stream := &fakeLinkStream{}
+
+	server.handlePlanAction(context.Background(), stream, runtime, &LinkRequest{Action: ActionPlan, Request: "describe it"}, []string{"first"})
+	if request := planner.lastRequest(); !strings.Contains(request, "describe it") || !strings.Contains(request, "attached 1 image(s)") {
+		t.Errorf("plan request = %q, want the attached image noted", request)
+	}
+	plan := runtime.Orchestrator.Plan
+	if plan == nil || plan.Request != "describe it" || fmt.Sprint(plan.Image) != "[first]" {
+		t.Fatalf("plan = %+v, want the request and its image", plan)
+	}
+
+	// Room past the images of the plan must not be written by the refinement
+	previous := make([]string, 1, 4)
+	previous[0] = "first"
+	plan.Image = previous
+
+	server.handlePlanAction(context.Background(), stream, runtime, &LinkRequest{Action: ActionRefine, Request: "compare with these"}, []string{"second", "third"})
+	request := planner.lastRequest()
+	if !strings.Contains(request, "describe the picture") || !strings.Contains(request, "compare with these") || !strings.Contains(request, "attached 2 image(s)") {
+		t.Errorf("refinement request = %q, want the plan, the refinement and its images noted", request)
+	}
+	refined := runtime.Orchestrator.Plan
+	if fmt.Sprint(refined.Image) != "[first second third]" || refined.Response[0].Task != "compare the pictures" {
+		t.Errorf("refined plan = %+v, want the images of both requests", refined)
+	}
+	if spare := previous[1:cap(previous)]; fmt.Sprint(spare) != "[  ]" {
+		t.Errorf("refinement wrote %q into the images of the previous plan", spare)
+	}
+}
+
+func TestRefinementRequestWithoutImages(t *testing.T) {
+	if request := refinementRequest(`[{"id":"t1"}]`, "shorter", nil); strings.Contains(request, "attached") {
+		t.Errorf("refinement request = %q, want no image note", request)
+	}
+}
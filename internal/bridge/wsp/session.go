@@ -0,0 +1,132 @@
+package wsp
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"yafai/internal/nexus/session"
+	"yafai/internal/nexus/workspace"
+)
+
+// sessionStream records the messages sent to the client as traces of the current
+// session. Streamed fragments and status updates are not recorded.
+type sessionStream struct {
+	WorkspaceService_LinkStreamServer
+	connID  string
+	mu      sync.Mutex
+	session *session.Session
+}
+
+func (s *sessionStream) Send(resp *LinkResponse) error {
+	if resp.Kind != KindDelta && resp.Kind != KindSession && !strings.HasPrefix(resp.Response, "STATUS: ") {
+		s.mu.Lock()
+		if s.session != nil {
+			s.session.AddTrace(strings.TrimPrefix(resp.Trace, "Source: "), resp.Kind, resp.Response)
+		}
+		s.mu.Unlock()
+	}
+	return s.WorkspaceService_LinkStreamServer.Send(resp)
+}
+
+// current returns the session the connection is in.
+func (s *sessionStream) current() *session.Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session
+}
+
+// record adds the user's request to the traces of the session.
+func (s *sessionStream) record(request string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.session != nil {
+		s.session.AddTrace("User", "", request)
+	}
+}
+
+// claim marks the session called id as open on connID. It fails while another
+// connection has it open.
+func (s *WorkspaceServer) claim(id string, connID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil {
+		s.active = map[string]string{}
+	}
+	if holder, open := s.active[id]; open && holder != connID {
+		return fmt.Errorf("session %s is open on another connection", id)
+	}
+	s.active[id] = connID
+	return nil
+}
+
+// release closes the session called id on connID.
+func (s *WorkspaceServer) release(id string, connID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[id] == connID {
+		delete(s.active, id)
+	}
+}
+
+// switchTo makes sess the session of the connection, restoring its histories into
+// the connection's runtime, and tells the client its ID.
+func (s *WorkspaceServer) switchTo(stream *sessionStream, runtime *workspace.Runtime, sess *session.Session) error {
+	if err := s.claim(sess.ID, stream.connID); err != nil {
+		return err
+	}
+	if err := runtime.Restore(sess); err != nil {
+		s.release(sess.ID, stream.connID)
+		return err
+	}
+	stream.mu.Lock()
+	previous := stream.session
+	stream.session = sess
+	stream.mu.Unlock()
+	if s.Wsp.Usage != nil {
+		s.Wsp.Usage.Bind(stream.connID, sess.ID, sess.Usage)
+	}
+	if previous != nil {
+		s.release(previous.ID, stream.connID)
+	}
+	return stream.Send(&LinkResponse{Response: sess.ID, Trace: "Source: Session", Kind: KindSession})
+}
+
+// resume loads the session called id and switches the connection to it.
+func (s *WorkspaceServer) resume(stream *sessionStream, runtime *workspace.Runtime, id string) error {
+	sess, err := s.Wsp.Sessions.Load(id)
+	if err != nil {
+		return err
+	}
+	if err := s.switchTo(stream, runtime, sess); err != nil {
+		return err
+	}
+	slog.Info("Resumed session", "connection_id", stream.connID, "session_id", id, "messages", len(sess.Orchestrator))
+	return stream.Send(&LinkResponse{Response: fmt.Sprintf("Resumed session %s with %d earlier messages.", id, len(sess.Orchestrator)), Trace: "Source: Session"})
+}
+
+// saveSession persists the runtime state into the connection's session.
+func (s *WorkspaceServer) saveSession(stream *sessionStream, runtime *workspace.Runtime) {
+	sess := stream.current()
+	if sess == nil {
+		return
+	}
+	stream.mu.Lock()
+	runtime.Snapshot(sess)
+	if s.Wsp.Usage != nil {
+		sess.Usage = s.Wsp.Usage.Session(sess.ID)
+	}
+	err := s.Wsp.Sessions.Save(sess)
+	stream.mu.Unlock()
+	if err != nil {
+		slog.Error("Failed to save session", "session_id", sess.ID, "error", err)
+	}
+}
+
+// closeSession releases the connection's session when the client goes away.
+func (s *WorkspaceServer) closeSession(stream *sessionStream) {
+	if sess := stream.current(); sess != nil {
+		s.release(sess.ID, stream.connID)
+	}
+}
@@ -2,11 +2,43 @@ package wsp
 
 import (
 	"context"
+	"sync"
 	"yafai/internal/nexus/workspace"
 )
 
+// WorkspaceServer serves a workspace. The workspace is shared read-only; each
+// connection converses through its own workspace.Runtime.
 type WorkspaceServer struct {
 	UnimplementedWorkspaceServiceServer
 	Wsp *workspace.Workspace
 	Ctx context.Context
+
+	// active maps the IDs of open sessions to the connection holding them, so a
+	// session is never resumed on two connections at once.
+	mu     sync.Mutex
+	active map[string]string
 }
+
+// LinkResponse kinds. Responses without a kind carry a complete message.
+const (
+	// KindDelta carries the next fragment of an answer that is still being generated.
+	KindDelta = "delta"
+	// KindEnd closes a streamed answer and carries its full text.
+	KindEnd = "end"
+	// KindTrace carries a step of an agent run for the system trace, not the chat.
+	KindTrace = "trace"
+	// KindSession carries the ID of the session the connection is in, sent when the
+	// connection opens and whenever it switches sessions.
+	KindSession = "session"
+	// KindPlan carries a plan waiting for the user to confirm, refine or cancel it.
+	KindPlan = "plan"
+)
+
+// LinkRequest actions. An empty action is a chat request.
+const (
+	ActionChat    = "chat"
+	ActionPlan    = "plan"
+	ActionRefine  = "refine"
+	ActionConfirm = "confirm"
+	ActionCancel  = "cancel"
+)
@@ -0,0 +1,62 @@
+package wsp
+
+import (
+	"context"
+	"sort"
+	"yafai/internal/nexus/usage"
+)
+
+// rpcConnection groups the usage of the unary Invoke* calls, which have no stream.
+const rpcConnection = "rpc"
+
+func (s *WorkspaceServer) GetUsage(ctx context.Context, req *UsageRequest) (*UsageResponse, error) {
+	if s.Wsp.Usage == nil {
+		return &UsageResponse{Total: &UsageTotals{}}, nil
+	}
+	report := s.Wsp.Usage.Report(req.ConnectionId)
+	return &UsageResponse{
+		Total:       toUsageTotals("total", report.Total),
+		Agents:      toUsageList(report.ByAgent),
+		Models:      toUsageList(report.ByModel),
+		Connections: toUsageList(report.Connections),
+	}, nil
+}
+
+func toUsageTotals(name string, totals usage.Totals) *UsageTotals {
+	return &UsageTotals{
+		Name:             name,
+		Calls:            int64(totals.Calls),
+		PromptTokens:     int64(totals.PromptTokens),
+		CompletionTokens: int64(totals.CompletionTokens),
+		TotalTokens:      int64(totals.TotalTokens),
+		Cost:             totals.Cost,
+	}
+}
+
+// toUsageList orders the entries by total tokens, heaviest first.
+func toUsageList(group map[string]usage.Totals) []*UsageTotals {
+	list := make([]*UsageTotals, 0, len(group))
+	for name, totals := range group {
+		list = append(list, toUsageTotals(name, totals))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TotalTokens != list[j].TotalTokens {
+			return list[i].TotalTokens > list[j].TotalTokens
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// usageStatus summarises the usage of a connection for the TUI status pane.
+func (s *WorkspaceServer) usageStatus(connID string) string {
+	if s.Wsp.Usage == nil {
+		return ""
+	}
+	report := s.Wsp.Usage.Report(connID)
+	status := "STATUS: Usage " + report.Total.String()
+	if agents := toUsageList(report.ByAgent); len(agents) > 0 {
+		status += "; top agent " + agents[0].Name
+	}
+	return status
+}
@@ -3,49 +3,147 @@ package wsp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"os/signal"
-	"regexp"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 	"yafai/internal/nexus/executors"
 	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/session"
+	"yafai/internal/nexus/usage"
+	"yafai/internal/nexus/workspace"
 )
 
-func stripJsonDelimiters(rawString string) string {
-	startDelimiter := "```json"
-	endDelimiter := "```"
+// deltaForwarder relays streamed answer fragments of one actor to the link client.
+type deltaForwarder struct {
+	stream WorkspaceService_LinkStreamServer
+	trace  string
+	sent   bool
+}
 
-	// 1. Trim leading/trailing whitespace from the input string
-	trimmed := strings.TrimSpace(rawString)
+// newDeltaForwarder returns nil when the client did not ask for streaming.
+func newDeltaForwarder(packet *LinkRequest, stream WorkspaceService_LinkStreamServer, trace string) *deltaForwarder {
+	if !packet.Stream {
+		return nil
+	}
+	return &deltaForwarder{stream: stream, trace: trace}
+}
 
-	// 2. Check if the trimmed string has the specified prefix and suffix
-	hasPrefix := strings.HasPrefix(trimmed, startDelimiter)
-	hasSuffix := strings.HasSuffix(trimmed, endDelimiter)
+func (f *deltaForwarder) OnDelta() func(string) {
+	if f == nil {
+		return nil
+	}
+	return func(delta string) {
+		f.sent = true
+		if err := f.stream.Send(&LinkResponse{Response: delta, Trace: f.trace, Kind: KindDelta}); err != nil {
+			slog.Error("Failed to send delta", "error", err)
+		}
+	}
+}
 
-	// 3. Ensure the string is long enough to contain more than just the delimiters
-	if hasPrefix && hasSuffix && len(trimmed) > len(startDelimiter)+len(endDelimiter) {
-		// Extract the content between the delimiters
-		content := trimmed[len(startDelimiter) : len(trimmed)-len(endDelimiter)]
+// Kind returns the kind for the complete message that follows the fragments.
+func (f *deltaForwarder) Kind() string {
+	if f == nil || !f.sent {
+		return ""
+	}
+	return KindEnd
+}
+
+// lockedStream serialises sends on a link stream, which gRPC does not allow from
+// several goroutines at once. Status updates are sent alongside the answers.
+type lockedStream struct {
+	WorkspaceService_LinkStreamServer
+	mu sync.Mutex
+}
 
-		// Trim whitespace from the extracted content itself
-		return strings.TrimSpace(content)
+func (s *lockedStream) Send(resp *LinkResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.WorkspaceService_LinkStreamServer.Send(resp)
+}
+
+// forwardStatus relays workspace status messages to the client until ctx ends.
+func (s *WorkspaceServer) forwardStatus(ctx context.Context, stream WorkspaceService_LinkStreamServer) {
+	if s.Wsp.Status == nil {
+		return
 	}
+	messages, unsubscribe := s.Wsp.Status.Subscribe()
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+	for message := range messages {
+		if err := stream.Send(&LinkResponse{Response: "STATUS: " + message, Trace: "Source: Status"}); err != nil {
+			slog.Error("Failed to send status", "error", err)
+			return
+		}
+	}
+}
+
+// traceForwarder relays the step traces of an agent or plan run to the client's
+// system trace.
+func traceForwarder(stream WorkspaceService_LinkStreamServer, source string) func(string) {
+	return func(trace string) {
+		if err := stream.Send(&LinkResponse{Response: trace, Trace: "Source: " + source, Kind: KindTrace}); err != nil {
+			slog.Error("Failed to send trace", "error", err)
+		}
+	}
+}
 
-	// 4. If delimiters weren't found correctly, return the trimmed original string
-	return trimmed
+// packetImages converts the images attached to a packet into data URLs.
+func packetImages(packet *LinkRequest) ([]string, error) {
+	var images []string
+	for i, data := range packet.Images {
+		image, err := providers.ImageDataURL(data)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// sourceTrace builds the trace of a message, naming the model that produced it.
+func sourceTrace(source string, model string) string {
+	if model == "" {
+		return "Source: " + source
+	}
+	return fmt.Sprintf("Source: %s (%s)", source, model)
 }
 
 func (s *WorkspaceServer) LinkStream(stream WorkspaceService_LinkStreamServer) (err error) { // Assume YourServiceServer and YourService_LinkServer types
 	connID := fmt.Sprintf("conn_%d", time.Now().UnixNano())
 	slog.Info("New client connected", "connection_id", connID)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// Derived from the stream so a client disconnect cancels in-flight model calls.
+	ctx, cancel := context.WithCancel(stream.Context())
 	defer cancel()
+	ctx = usage.WithLedger(ctx, s.Wsp.Usage, connID)
+	if s.Wsp.Usage != nil {
+		defer s.Wsp.Usage.Close(connID)
+	}
+
+	stream = &lockedStream{WorkspaceService_LinkStreamServer: stream}
+	go s.forwardStatus(ctx, stream)
+
+	// The connection converses through its own copy of the orchestrator and team.
+	// Each connection starts a new session unless the client resumes one. Without a
+	// session store the state is only kept in memory.
+	runtime := s.Wsp.NewRuntime()
+	sessions := &sessionStream{WorkspaceService_LinkStreamServer: stream, connID: connID}
+	if s.Wsp.Sessions != nil {
+		stream = sessions
+		defer s.closeSession(sessions)
+		if err := s.switchTo(sessions, runtime, session.New(s.Wsp.Name)); err != nil {
+			slog.Error("Failed to start session", "connection_id", connID, "error", err)
+		}
+	}
 
 	// Listen for Ctrl+C (SIGINT/SIGTERM)
 	sigChan := make(chan os.Signal, 1)
@@ -72,67 +170,160 @@ func (s *WorkspaceServer) LinkStream(stream WorkspaceService_LinkStreamServer) (
 			return err
 		}
 
-		// Append user message to orchestrator history
-		s.Wsp.Orchestrator.AppendChatRecord("user", "orchestrator", packet.Request)
+		if id := packet.SessionId; id != "" && s.Wsp.Sessions != nil {
+			if current := sessions.current(); current == nil || current.ID != id {
+				if err := s.resume(sessions, runtime, id); err != nil {
+					slog.Warn("Could not resume session", "connection_id", connID, "session_id", id, "error", err)
+					stream.Send(&LinkResponse{Response: fmt.Sprintf("Could not resume session %s: %v", id, err), Trace: "Source: Session"})
+					continue
+				}
+			}
+		}
+		if packet.Request == "" && len(packet.Images) == 0 && packet.Action == "" {
+			continue
+		}
+		if packet.Action != "" && packet.Action != ActionChat {
+			sessions.record(strings.TrimSpace("/" + packet.Action + " " + packet.Request))
+		} else {
+			sessions.record(packet.Request)
+		}
+
+		// Tokens spent answering this packet count against the per request budget
+		ctx := usage.WithRequest(ctx)
+		if err := usage.Check(ctx); err != nil {
+			slog.Warn("Request rejected by budget", "connection_id", connID, "error", err)
+			stream.Send(&LinkResponse{Response: err.Error(), Trace: "Source: Budget"})
+			continue
+		}
+
+		images, err := packetImages(packet)
+		if err != nil {
+			slog.Warn("Rejected attached image", "connection_id", connID, "error", err)
+			stream.Send(&LinkResponse{Response: fmt.Sprintf("Could not read the attached image: %v", err), Trace: "Source: Workspace"})
+			continue
+		}
+
+		// Plan actions go to the planner and the plan executor instead of the ReACT loop
+		if packet.Action != "" && packet.Action != ActionChat {
+			s.handlePlanAction(ctx, stream, runtime, packet, images)
+			s.finishPacket(connID, stream, sessions, runtime)
+			continue
+		}
+
+		// The orchestrator records each request and its reply in its own history. It is
+		// told about attached images, which go to the agents it invokes.
 		currentRequest := packet.Request
+		if len(images) > 0 {
+			currentRequest += fmt.Sprintf("\n\n[The user attached %d image(s); they are passed to the agent you invoke.]", len(images))
+		}
 
-		// ReACT loop for this packet
-		iterationCount := 0
+		// ReACT loop for this packet, held to the orchestrator's limits on agent calls,
+		// agent failures in a row and time. When one trips, the user is told what was
+		// tried. With a critic, agent outputs and answers are reviewed and the
+		// orchestrator revises answers it sends back, a few times at most.
+		guard := newLoopGuard(runtime.Orchestrator)
+		loopCtx, cancelLoop := guard.context(ctx)
+		stop := func(reason string) {
+			slog.Warn("Orchestrator loop stopped", "connection_id", connID, "reason", reason)
+			stream.Send(&LinkResponse{Response: guard.summary(reason), Trace: "Source: Orchestrator"})
+		}
+		critic := runtime.Orchestrator.Critic
+		criticTrace := traceForwarder(stream, "Critic")
+		revisions := 0
+		var agentLogs []executors.AgentLog
 
+	agentLoop:
 		for {
 			// Check for cancellation
 			select {
 			case <-ctx.Done():
 				slog.Error("Stream context cancelled", "connection_id", connID, "error", ctx.Err())
+				cancelLoop()
 				return ctx.Err()
 			default:
 			}
+			if reason := guard.expired(loopCtx); reason != "" {
+				stop(reason)
+				break
+			}
 
 			// 1. Plan/Invoke: ask orchestrator what to do
-			resp, err := s.InvokeOrchestrator(ctx, &OrchestratorRequest{Request: currentRequest})
-			if err != nil {
+			orchFwd := newDeltaForwarder(packet, stream, "Source: Orchestrator")
+			if critic.Enabled() {
+				// Answers are held back until the critic passes them
+				orchFwd = nil
+			}
+			resp, err := s.invokeOrchestrator(loopCtx, runtime.Orchestrator, currentRequest, orchFwd.OnDelta())
+			if reason := guard.expired(loopCtx); err != nil && reason != "" {
+				stop(reason)
+				break
+			} else if err != nil {
 				slog.Error("Error invoking orchestrator", "connection_id", connID, "error", err)
-				stream.Send(&LinkResponse{Response: fmt.Sprintf("Orchestrator Error: %v", err)})
+				stream.Send(&LinkResponse{Response: fmt.Sprintf("Orchestrator Error: %s", providers.Describe(err)), Trace: "Source: Orchestrator"})
 				break
 			}
 
 			// 2. Observe: parse orchestrator JSON
-			output := stripJsonDelimiters(resp.Response)
-			var j map[string]interface{}
-
-			if err := json.Unmarshal([]byte(output), &j); err != nil {
+			orchTrace := sourceTrace("Orchestrator", resp.Model)
+			var action executors.OrchestratorAction
+			if err := json.Unmarshal([]byte(resp.Response.Content), &action); err != nil {
 				slog.Error("Error parsing orchestrator response", "connection_id", connID, "error", err)
 				stream.Send(&LinkResponse{Response: fmt.Sprintf("Internal Error: %v", err)})
 				break
 			}
 
-			if msg, ok := j["chat"].(string); ok {
-				s.Wsp.Orchestrator.AppendChatRecord("orchestrator", "user", msg)
-				stream.Send(&LinkResponse{Response: msg, Trace: "Source: Orchestrator"})
+			if action.Action == executors.ActionChat {
+				msg := action.Chat
+				stream.Send(&LinkResponse{Response: msg, Trace: orchTrace, Kind: orchFwd.Kind()})
 				break
-			} else if ans, ok := j["answer"].(string); ok {
-				s.Wsp.Orchestrator.AppendChatRecord("orchestrator", "user", ans)
-				stream.Send(&LinkResponse{Response: ans, Trace: "Source: Orchestrator"})
+			} else if action.Action == executors.ActionAnswer {
+				ans := action.Answer
+				verdict, err := runtime.Orchestrator.Parse(loopCtx, packet.Request, ans, agentLogs)
+				if err != nil {
+					slog.Warn("Critic review failed, answering unreviewed", "connection_id", connID, "error", err)
+					criticTrace(fmt.Sprintf("Review failed, answer sent unreviewed: %s", providers.Describe(err)))
+				} else if verdict.Verdict == executors.VerdictRevise && revisions < critic.Revisions() {
+					revisions++
+					criticTrace(fmt.Sprintf("Revision %d/%d requested: %s", revisions, critic.Revisions(), verdict.Feedback))
+					currentRequest = executors.CriticFeedback(verdict, "your answer") + " Revise the answer and reply with it again."
+					continue
+				}
+				stream.Send(&LinkResponse{Response: ans, Trace: orchTrace, Kind: orchFwd.Kind()})
+				if err == nil && verdict.Verdict != executors.VerdictPass {
+					stream.Send(&LinkResponse{Response: fmt.Sprintf("Note: the reviewer found that this answer may not meet the goal: %s", verdict.Feedback), Trace: "Source: Critic"})
+				}
 				break
-			} else if name, ok := j["name"].(string); ok {
-				task, _ := j["task"].(string)
-				// Append orchestrator plan to history
-				s.Wsp.Orchestrator.AppendChatRecord("orchestrator", name, task)
+			} else if action.Action == executors.ActionAgentInvoke {
+				name, task := action.Name, action.Task
+				previous, reason := guard.invoke(name, task)
+				if reason != "" {
+					stop(reason)
+					break
+				}
+				if previous != nil {
+					slog.Warn("Orchestrator repeated an agent call", "connection_id", connID, "agent", name)
+					currentRequest = repeatRequest(previous)
+					continue
+				}
 
 				// Prepare agent request
-				agentReq := &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: task}}
+				agentFwd := newDeltaForwarder(packet, stream, fmt.Sprintf("Source: Agent %s", name))
+				agentReq := &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: task, Image: images}, OnDelta: agentFwd.OnDelta(), OnTrace: traceForwarder(stream, "Agent "+name)}
 
 				// Run agent execution in goroutine and wait
 				resultCh := make(chan *executors.YafaiResponse, 1)
 				errCh := make(chan error, 1)
 				go func() {
-					agentExec, exists := s.Wsp.Orchestrator.Team[name]
+					agentExec, exists := runtime.Orchestrator.Team[name]
 					if !exists {
 						errCh <- fmt.Errorf("agent '%s' not found", name)
 						return
 					}
-					res, err := agentExec.Execute(ctx, agentReq)
+					res, err := agentExec.Execute(loopCtx, agentReq)
 					if err != nil {
+						if res != nil && res.Model != "" {
+							err = fmt.Errorf("%s: %w", res.Model, err)
+						}
 						errCh <- err
 					} else {
 						resultCh <- res
@@ -143,45 +334,76 @@ func (s *WorkspaceServer) LinkStream(stream WorkspaceService_LinkStreamServer) (
 				select {
 				case err := <-errCh:
 					slog.Error("Agent execution failed", "agent", name, "error", err)
-					s.Wsp.Orchestrator.AppendChatRecord(name, "error", err.Error())
-					stream.Send(&LinkResponse{Response: fmt.Sprintf("Agent '%s' error: %v", name, err)})
+					stream.Send(&LinkResponse{Response: fmt.Sprintf("Agent '%s' error: %s", name, providers.Describe(err)), Trace: fmt.Sprintf("Source: Agent %s", name)})
+					reason := guard.record(name, task, "", err)
+					if expired := guard.expired(loopCtx); expired != "" {
+						reason = expired
+					}
+					if reason != "" {
+						stop(reason)
+						break agentLoop
+					}
 					currentRequest = fmt.Sprintf("Previous agent '%s' failed with error: %s. What's next?", name, err)
 					continue
 
 				case agentRes = <-resultCh:
-					// Append agent result to history
-					content := fmt.Sprintf("Observation: %s (from %s)", agentRes.Response.Content, name)
-					s.Wsp.Orchestrator.AppendChatRecord(name, "user", content)
-					currentRequest = content
+					slog.Info("Agent answered", "agent", name, "model", agentRes.Model)
+					if kind := agentFwd.Kind(); kind != "" {
+						stream.Send(&LinkResponse{Response: agentRes.Response.Content, Trace: sourceTrace("Agent "+name, agentRes.Model), Kind: kind})
+					}
+					// The agent result is the orchestrator's next request, with the critic's
+					// verdict when it is not a pass
+					currentRequest = fmt.Sprintf("Observation: %s (from %s)", agentRes.Response.Content, name)
+					guard.record(name, task, agentRes.Response.Content, nil)
+					agentLogs = append(agentLogs, executors.AgentLog{Name: name, Task: task, Response: agentRes.Response.Content})
+					verdict, err := runtime.Orchestrator.ReviewAgent(loopCtx, packet.Request, name, task, agentRes.Response.Content)
+					if err != nil {
+						slog.Warn("Critic review of agent output failed", "agent", name, "error", err)
+					} else if verdict.Verdict != executors.VerdictPass {
+						criticTrace(fmt.Sprintf("Agent %s output: %s: %s", name, verdict.Verdict, verdict.Feedback))
+						currentRequest += "\n" + executors.CriticFeedback(verdict, fmt.Sprintf("the output of %s", name))
+					}
 				}
 				// Next iteration of the ReACT loop uses updated currentRequest
-				iterationCount++
-
-				// If no new response or excessive iterations, terminate the loop
-				if iterationCount > 3 {
-					slog.Warn("Excessive iterations or no progress made, terminating the loop.")
-					stream.Send(&LinkResponse{Response: "Task could not be completed due to repeated failures or no progress."})
-					break
-				}
-
-				//lastResponse = currentRequest
 				continue
 			} else {
-				slog.Warn("Unexpected orchestrator response format", "response", output)
+				slog.Warn("Unexpected orchestrator response format", "response", resp.Response.Content)
 				stream.Send(&LinkResponse{Response: "Internal Error: Unexpected response format from orchestrator."})
 				break
 			}
 
 		}
-		// Inner loop ends; wait for next packet
+		// Inner loop ends; report usage so far, save the session and wait for next packet
+		cancelLoop()
+		s.finishPacket(connID, stream, sessions, runtime)
 	}
 
 	// End of outer receive packet loop
 }
 
+// finishPacket reports the usage so far and saves the session once a packet is answered.
+func (s *WorkspaceServer) finishPacket(connID string, stream WorkspaceService_LinkStreamServer, sessions *sessionStream, runtime *workspace.Runtime) {
+	if status := s.usageStatus(connID); status != "" {
+		stream.Send(&LinkResponse{Response: status, Trace: "Source: Usage"})
+	}
+	if s.Wsp.Sessions != nil {
+		s.saveSession(sessions, runtime)
+	}
+}
+
 func (s *WorkspaceServer) InvokeOrchestrator(ctx context.Context, req *OrchestratorRequest) (resp *OrchestratorResponse, err error) {
-	slog.Info("Orchestrator Request:", req.Request)
-	orch_resp, err := s.Wsp.Orchestrator.Execute(ctx, &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: req.Request}})
+	// Calls outside a link stream keep no conversation, so each gets fresh state.
+	orch_resp, err := s.invokeOrchestrator(usage.WithLedger(ctx, s.Wsp.Usage, rpcConnection), s.Wsp.NewRuntime().Orchestrator, req.Request, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &OrchestratorResponse{Response: orch_resp.Response.Content}, nil
+}
+
+// invokeOrchestrator runs orchestrator, streaming its answer through onDelta when set.
+func (s *WorkspaceServer) invokeOrchestrator(ctx context.Context, orchestrator *executors.YafaiOrchestrator, request string, onDelta func(string)) (resp *executors.YafaiResponse, err error) {
+	slog.Info("Orchestrator Request", "request", request)
+	orch_resp, err := orchestrator.Execute(ctx, &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: request}, OnDelta: onDelta})
 
 	// re := regexp.MustCompile(`<think>(.*?)</think>`)
 	// output := re.ReplaceAllString(planner_resp.Response.Content, "")
@@ -200,7 +422,8 @@ func (s *WorkspaceServer) InvokeOrchestrator(ctx context.Context, req *Orchestra
 	// //Extract the JSON array string
 	// planner_resp.Response.Content = output[start:]
 	if err != nil {
-		slog.Error(err.Error())
+		slog.Error("Orchestrator execution failed", "error", err)
+		return nil, err
 	}
 
 	// steps, err := s.Planner.Parse(planner_resp)
@@ -214,89 +437,56 @@ func (s *WorkspaceServer) InvokeOrchestrator(ctx context.Context, req *Orchestra
 	// for _, step := range steps {
 	// 	response = append(response, &PlannerStep{Task: step.Task, Agent: step.Agent, Thought: step.Thought})
 	// }
-	s.Wsp.Orchestrator.AppendChatRecord("orchestrator", "user", orch_resp.Response.Content)
 	slog.Info("Received orchestrator response",
 		"connection_id", "connID",
+		"model", orch_resp.Model,
 		"response", orch_resp.Response.Content)
-	return &OrchestratorResponse{Response: orch_resp.Response.Content}, nil
+	return orch_resp, nil
 }
 
-func (s *WorkspaceServer) InvokePlanner(ctx context.Context, req *PlannerRequest) (res *PlannerResponse, err error) {
-
-	planner_resp, err := s.Wsp.Planner.Execute(ctx, &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: req.Request}})
-
-	re := regexp.MustCompile(`<think>(.*?)</think>`)
-	output := re.ReplaceAllString(planner_resp.Response.Content, "")
-
-	// Remove any leading/trailing whitespace
-	output = strings.TrimSpace(output)
-
-	// Find the beginning of the json array
-	start := strings.Index(output, "[")
-
-	if start == -1 {
-		slog.Info("No JSON array found.")
-		return
+// plannerResponse converts parsed plan steps for the planner RPCs. The rules an
+// invalid plan breaks are returned in the response rather than as an error.
+func plannerResponse(steps []*executors.PlannerTask, err error) (*PlannerResponse, error) {
+	response := &PlannerResponse{}
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		response.Steps = append(response.Steps, &PlannerStep{Task: step.Task, Agent: step.Agent, Thought: step.Thought, Id: step.ID, DependsOn: step.DependsOn})
 	}
 
-	//Extract the JSON array string
-	planner_resp.Response.Content = output[start:]
-	if err != nil {
-		slog.Error(err.Error())
+	var invalid *executors.PlanValidationError
+	if errors.As(err, &invalid) {
+		for _, violation := range invalid.Violations {
+			response.Violations = append(response.Violations, &PlanViolation{Task: violation.Task, Rule: violation.Rule, Message: violation.Message})
+		}
+		return response, nil
 	}
-
-	steps, err := s.Wsp.Planner.Parse(planner_resp)
-	s.Wsp.Orchestrator.UpdatePlan(&executors.PlannerResponse{Response: steps})
 	if err != nil {
 		slog.Error(err.Error())
 	}
+	return response, err
+}
 
-	var response []*PlannerStep
+func (s *WorkspaceServer) InvokePlanner(ctx context.Context, req *PlannerRequest) (res *PlannerResponse, err error) {
 
-	for _, step := range steps {
-		response = append(response, &PlannerStep{Task: step.Task, Agent: step.Agent, Thought: step.Thought})
+	planner_resp, err := s.Wsp.Planner.Execute(usage.WithLedger(ctx, s.Wsp.Usage, rpcConnection), &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: req.Request}})
+	if err != nil {
+		slog.Error("Planner execution failed", "error", err)
+		return nil, err
 	}
 
-	return &PlannerResponse{Steps: response}, err
-
+	return plannerResponse(s.Wsp.Planner.Parse(planner_resp))
 }
 
 func (s *WorkspaceServer) InvokePlanRefine(ctx context.Context, req *PlannerRefineRequest) (res *PlannerResponse, err error) {
 
-	refinement_payload := fmt.Sprintf("Refine the following plan,\n %s \n based on the refinement request: %s. Stick to the formatting isntructions", req.Plan, req.Refinement)
-	planner_resp, err := s.Wsp.Planner.Execute(ctx, &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: refinement_payload}})
-
-	re := regexp.MustCompile(`<think>(.*?)</think>`)
-	output := re.ReplaceAllString(planner_resp.Response.Content, "")
-
-	// Remove any leading/trailing whitespace
-	output = strings.TrimSpace(output)
-
-	// Find the beginning of the json array
-	start := strings.Index(output, "[")
-
-	if start == -1 {
-		slog.Info("No JSON array found.")
-		return
-	}
-
-	//Extract the JSON array string
-	planner_resp.Response.Content = output[start:]
-	if err != nil {
-		slog.Error(err.Error())
-	}
-
-	steps, err := s.Wsp.Planner.Parse(planner_resp)
-	s.Wsp.Orchestrator.UpdatePlan(&executors.PlannerResponse{Response: steps})
+	refinement_payload := refinementRequest(req.Plan, req.Refinement, nil)
+	planner_resp, err := s.Wsp.Planner.Execute(usage.WithLedger(ctx, s.Wsp.Usage, rpcConnection), &executors.YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: refinement_payload}})
 	if err != nil {
-		slog.Error(err.Error())
-	}
-
-	var response []*PlannerStep
-
-	for _, step := range steps {
-		response = append(response, &PlannerStep{Task: step.Task, Agent: step.Agent, Thought: step.Thought})
+		slog.Error("Planner execution failed", "error", err)
+		return nil, err
 	}
 
-	return &PlannerResponse{Steps: response}, err
+	return plannerResponse(s.Wsp.Planner.Parse(planner_resp))
 }
@@ -24,6 +24,10 @@ const (
 type LinkRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Request       string                 `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Stream        bool                   `protobuf:"varint,2,opt,name=stream,proto3" json:"stream,omitempty"`
+	Images        [][]byte               `protobuf:"bytes,3,rep,name=images,proto3" json:"images,omitempty"`
+	SessionId     string                 `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Action        string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -65,10 +69,39 @@ func (x *LinkRequest) GetRequest() string {
 	return ""
 }
 
+func (x *LinkRequest) GetStream() bool {
+	if x != nil {
+		return x.Stream
+	}
+	return false
+}
+
+func (x *LinkRequest) GetImages() [][]byte {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+func (x *LinkRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *LinkRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
 type LinkResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Response      string                 `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
 	Trace         string                 `protobuf:"bytes,2,opt,name=trace,proto3" json:"trace,omitempty"`
+	Kind          string                 `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -117,6 +150,13 @@ func (x *LinkResponse) GetTrace() string {
 	return ""
 }
 
+func (x *LinkResponse) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
 type PlannerRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Request       string                 `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
@@ -218,6 +258,8 @@ type PlannerStep struct {
 	Task          string                 `protobuf:"bytes,1,opt,name=Task,proto3" json:"Task,omitempty"`
 	Agent         string                 `protobuf:"bytes,2,opt,name=Agent,proto3" json:"Agent,omitempty"`
 	Thought       string                 `protobuf:"bytes,3,opt,name=Thought,proto3" json:"Thought,omitempty"`
+	Id            string                 `protobuf:"bytes,4,opt,name=Id,proto3" json:"Id,omitempty"`
+	DependsOn     []string               `protobuf:"bytes,5,rep,name=DependsOn,proto3" json:"DependsOn,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -273,16 +315,91 @@ func (x *PlannerStep) GetThought() string {
 	return ""
 }
 
+func (x *PlannerStep) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlannerStep) GetDependsOn() []string {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+type PlanViolation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Task          string                 `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	Rule          string                 `protobuf:"bytes,2,opt,name=rule,proto3" json:"rule,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanViolation) Reset() {
+	*x = PlanViolation{}
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanViolation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanViolation) ProtoMessage() {}
+
+func (x *PlanViolation) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanViolation.ProtoReflect.Descriptor instead.
+func (*PlanViolation) Descriptor() ([]byte, []int) {
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PlanViolation) GetTask() string {
+	if x != nil {
+		return x.Task
+	}
+	return ""
+}
+
+func (x *PlanViolation) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *PlanViolation) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
 type PlannerResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Steps         []*PlannerStep         `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	Violations    []*PlanViolation       `protobuf:"bytes,2,rep,name=violations,proto3" json:"violations,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PlannerResponse) Reset() {
 	*x = PlannerResponse{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[5]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -294,7 +411,7 @@ func (x *PlannerResponse) String() string {
 func (*PlannerResponse) ProtoMessage() {}
 
 func (x *PlannerResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[5]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -307,7 +424,7 @@ func (x *PlannerResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlannerResponse.ProtoReflect.Descriptor instead.
 func (*PlannerResponse) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{5}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PlannerResponse) GetSteps() []*PlannerStep {
@@ -317,6 +434,13 @@ func (x *PlannerResponse) GetSteps() []*PlannerStep {
 	return nil
 }
 
+func (x *PlannerResponse) GetViolations() []*PlanViolation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
 type OrchestratorRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Request       string                 `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
@@ -326,7 +450,7 @@ type OrchestratorRequest struct {
 
 func (x *OrchestratorRequest) Reset() {
 	*x = OrchestratorRequest{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[6]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -338,7 +462,7 @@ func (x *OrchestratorRequest) String() string {
 func (*OrchestratorRequest) ProtoMessage() {}
 
 func (x *OrchestratorRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[6]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -351,7 +475,7 @@ func (x *OrchestratorRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrchestratorRequest.ProtoReflect.Descriptor instead.
 func (*OrchestratorRequest) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{6}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *OrchestratorRequest) GetRequest() string {
@@ -370,7 +494,7 @@ type OrchestratorResponse struct {
 
 func (x *OrchestratorResponse) Reset() {
 	*x = OrchestratorResponse{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[7]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -382,7 +506,7 @@ func (x *OrchestratorResponse) String() string {
 func (*OrchestratorResponse) ProtoMessage() {}
 
 func (x *OrchestratorResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[7]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -395,7 +519,7 @@ func (x *OrchestratorResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrchestratorResponse.ProtoReflect.Descriptor instead.
 func (*OrchestratorResponse) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{7}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *OrchestratorResponse) GetResponse() string {
@@ -414,7 +538,7 @@ type AgentRequest struct {
 
 func (x *AgentRequest) Reset() {
 	*x = AgentRequest{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[8]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -426,7 +550,7 @@ func (x *AgentRequest) String() string {
 func (*AgentRequest) ProtoMessage() {}
 
 func (x *AgentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[8]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -439,7 +563,7 @@ func (x *AgentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AgentRequest.ProtoReflect.Descriptor instead.
 func (*AgentRequest) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{8}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *AgentRequest) GetRequest() string {
@@ -458,7 +582,7 @@ type AgentResponse struct {
 
 func (x *AgentResponse) Reset() {
 	*x = AgentResponse{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[9]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -470,7 +594,7 @@ func (x *AgentResponse) String() string {
 func (*AgentResponse) ProtoMessage() {}
 
 func (x *AgentResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[9]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -483,7 +607,7 @@ func (x *AgentResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AgentResponse.ProtoReflect.Descriptor instead.
 func (*AgentResponse) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{9}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *AgentResponse) GetResponse() string {
@@ -502,7 +626,7 @@ type MonitorAgentRequest struct {
 
 func (x *MonitorAgentRequest) Reset() {
 	*x = MonitorAgentRequest{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[10]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -514,7 +638,7 @@ func (x *MonitorAgentRequest) String() string {
 func (*MonitorAgentRequest) ProtoMessage() {}
 
 func (x *MonitorAgentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[10]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -527,7 +651,7 @@ func (x *MonitorAgentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MonitorAgentRequest.ProtoReflect.Descriptor instead.
 func (*MonitorAgentRequest) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{10}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *MonitorAgentRequest) GetRequest() string {
@@ -546,7 +670,7 @@ type MonitorAgentResponse struct {
 
 func (x *MonitorAgentResponse) Reset() {
 	*x = MonitorAgentResponse{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[11]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -558,7 +682,7 @@ func (x *MonitorAgentResponse) String() string {
 func (*MonitorAgentResponse) ProtoMessage() {}
 
 func (x *MonitorAgentResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[11]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -571,7 +695,7 @@ func (x *MonitorAgentResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MonitorAgentResponse.ProtoReflect.Descriptor instead.
 func (*MonitorAgentResponse) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{11}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *MonitorAgentResponse) GetResponse() string {
@@ -590,7 +714,7 @@ type DiscoveryRequest struct {
 
 func (x *DiscoveryRequest) Reset() {
 	*x = DiscoveryRequest{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[12]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -602,7 +726,7 @@ func (x *DiscoveryRequest) String() string {
 func (*DiscoveryRequest) ProtoMessage() {}
 
 func (x *DiscoveryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[12]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -615,7 +739,7 @@ func (x *DiscoveryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DiscoveryRequest.ProtoReflect.Descriptor instead.
 func (*DiscoveryRequest) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{12}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *DiscoveryRequest) GetRequest() string {
@@ -634,7 +758,7 @@ type DiscoveryResponse struct {
 
 func (x *DiscoveryResponse) Reset() {
 	*x = DiscoveryResponse{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[13]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -646,7 +770,7 @@ func (x *DiscoveryResponse) String() string {
 func (*DiscoveryResponse) ProtoMessage() {}
 
 func (x *DiscoveryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[13]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -659,7 +783,7 @@ func (x *DiscoveryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DiscoveryResponse.ProtoReflect.Descriptor instead.
 func (*DiscoveryResponse) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{13}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *DiscoveryResponse) GetResponse() string {
@@ -678,7 +802,7 @@ type ToolExecuteRequest struct {
 
 func (x *ToolExecuteRequest) Reset() {
 	*x = ToolExecuteRequest{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[14]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -690,7 +814,7 @@ func (x *ToolExecuteRequest) String() string {
 func (*ToolExecuteRequest) ProtoMessage() {}
 
 func (x *ToolExecuteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[14]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -703,7 +827,7 @@ func (x *ToolExecuteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolExecuteRequest.ProtoReflect.Descriptor instead.
 func (*ToolExecuteRequest) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{14}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ToolExecuteRequest) GetName() string {
@@ -722,7 +846,7 @@ type ToolExecuteResponse struct {
 
 func (x *ToolExecuteResponse) Reset() {
 	*x = ToolExecuteResponse{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[15]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -734,7 +858,7 @@ func (x *ToolExecuteResponse) String() string {
 func (*ToolExecuteResponse) ProtoMessage() {}
 
 func (x *ToolExecuteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[15]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -747,7 +871,7 @@ func (x *ToolExecuteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolExecuteResponse.ProtoReflect.Descriptor instead.
 func (*ToolExecuteResponse) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{15}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *ToolExecuteResponse) GetName() string {
@@ -757,6 +881,202 @@ func (x *ToolExecuteResponse) GetName() string {
 	return ""
 }
 
+type UsageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConnectionId  string                 `protobuf:"bytes,1,opt,name=connection_id,json=connectionId,proto3" json:"connection_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageRequest) Reset() {
+	*x = UsageRequest{}
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageRequest) ProtoMessage() {}
+
+func (x *UsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageRequest.ProtoReflect.Descriptor instead.
+func (*UsageRequest) Descriptor() ([]byte, []int) {
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *UsageRequest) GetConnectionId() string {
+	if x != nil {
+		return x.ConnectionId
+	}
+	return ""
+}
+
+type UsageTotals struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Calls            int64                  `protobuf:"varint,2,opt,name=calls,proto3" json:"calls,omitempty"`
+	PromptTokens     int64                  `protobuf:"varint,3,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int64                  `protobuf:"varint,4,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	TotalTokens      int64                  `protobuf:"varint,5,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	Cost             float64                `protobuf:"fixed64,6,opt,name=cost,proto3" json:"cost,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UsageTotals) Reset() {
+	*x = UsageTotals{}
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageTotals) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageTotals) ProtoMessage() {}
+
+func (x *UsageTotals) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageTotals.ProtoReflect.Descriptor instead.
+func (*UsageTotals) Descriptor() ([]byte, []int) {
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *UsageTotals) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UsageTotals) GetCalls() int64 {
+	if x != nil {
+		return x.Calls
+	}
+	return 0
+}
+
+func (x *UsageTotals) GetPromptTokens() int64 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *UsageTotals) GetCompletionTokens() int64 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *UsageTotals) GetTotalTokens() int64 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+func (x *UsageTotals) GetCost() float64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+type UsageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         *UsageTotals           `protobuf:"bytes,1,opt,name=total,proto3" json:"total,omitempty"`
+	Agents        []*UsageTotals         `protobuf:"bytes,2,rep,name=agents,proto3" json:"agents,omitempty"`
+	Models        []*UsageTotals         `protobuf:"bytes,3,rep,name=models,proto3" json:"models,omitempty"`
+	Connections   []*UsageTotals         `protobuf:"bytes,4,rep,name=connections,proto3" json:"connections,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageResponse) Reset() {
+	*x = UsageResponse{}
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageResponse) ProtoMessage() {}
+
+func (x *UsageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageResponse.ProtoReflect.Descriptor instead.
+func (*UsageResponse) Descriptor() ([]byte, []int) {
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UsageResponse) GetTotal() *UsageTotals {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetAgents() []*UsageTotals {
+	if x != nil {
+		return x.Agents
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetModels() []*UsageTotals {
+	if x != nil {
+		return x.Models
+	}
+	return nil
+}
+
+func (x *UsageResponse) GetConnections() []*UsageTotals {
+	if x != nil {
+		return x.Connections
+	}
+	return nil
+}
+
 type HeartBeatRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Request       string                 `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
@@ -766,7 +1086,7 @@ type HeartBeatRequest struct {
 
 func (x *HeartBeatRequest) Reset() {
 	*x = HeartBeatRequest{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[16]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -778,7 +1098,7 @@ func (x *HeartBeatRequest) String() string {
 func (*HeartBeatRequest) ProtoMessage() {}
 
 func (x *HeartBeatRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[16]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -791,7 +1111,7 @@ func (x *HeartBeatRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HeartBeatRequest.ProtoReflect.Descriptor instead.
 func (*HeartBeatRequest) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{16}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *HeartBeatRequest) GetRequest() string {
@@ -810,7 +1130,7 @@ type HeartBeatResponse struct {
 
 func (x *HeartBeatResponse) Reset() {
 	*x = HeartBeatResponse{}
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[17]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -822,7 +1142,7 @@ func (x *HeartBeatResponse) String() string {
 func (*HeartBeatResponse) ProtoMessage() {}
 
 func (x *HeartBeatResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[17]
+	mi := &file_internal_bridge_wsp_wsp_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -835,7 +1155,7 @@ func (x *HeartBeatResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HeartBeatResponse.ProtoReflect.Descriptor instead.
 func (*HeartBeatResponse) Descriptor() ([]byte, []int) {
-	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{17}
+	return file_internal_bridge_wsp_wsp_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *HeartBeatResponse) GetResponse() string {
@@ -850,104 +1170,153 @@ var File_internal_bridge_wsp_wsp_proto protoreflect.FileDescriptor
 var file_internal_bridge_wsp_wsp_proto_rawDesc = string([]byte{
 	0x0a, 0x1d, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x62, 0x72, 0x69, 0x64, 0x67,
 	0x65, 0x2f, 0x77, 0x73, 0x70, 0x2f, 0x77, 0x73, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x03, 0x77, 0x73, 0x70, 0x22, 0x27, 0x0a, 0x0b, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x40, 0x0a,
-	0x0c, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a,
-	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x72, 0x61,
-	0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x72, 0x61, 0x63, 0x65, 0x22,
-	0x2a, 0x0a, 0x0e, 0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x4a, 0x0a, 0x14, 0x50,
-	0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6c, 0x61, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x04, 0x70, 0x6c, 0x61, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x66, 0x69, 0x6e,
-	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x66,
-	0x69, 0x6e, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0x51, 0x0a, 0x0b, 0x50, 0x6c, 0x61, 0x6e, 0x6e,
-	0x65, 0x72, 0x53, 0x74, 0x65, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x61, 0x73, 0x6b, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x41, 0x67,
-	0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x41, 0x67, 0x65, 0x6e, 0x74,
-	0x12, 0x18, 0x0a, 0x07, 0x54, 0x68, 0x6f, 0x75, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x54, 0x68, 0x6f, 0x75, 0x67, 0x68, 0x74, 0x22, 0x39, 0x0a, 0x0f, 0x50, 0x6c,
-	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a,
-	0x05, 0x73, 0x74, 0x65, 0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x77,
-	0x73, 0x70, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x53, 0x74, 0x65, 0x70, 0x52, 0x05,
-	0x73, 0x74, 0x65, 0x70, 0x73, 0x22, 0x2f, 0x0a, 0x13, 0x4f, 0x72, 0x63, 0x68, 0x65, 0x73, 0x74,
-	0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07,
-	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x32, 0x0a, 0x14, 0x4f, 0x72, 0x63, 0x68, 0x65, 0x73,
-	0x74, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a,
-	0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x28, 0x0a, 0x0c, 0x41, 0x67,
-	0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x22, 0x2b, 0x0a, 0x0d, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73,
+	0x03, 0x77, 0x73, 0x70, 0x22, 0x8e, 0x01, 0x0a, 0x0b, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06,
+	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x06, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x16, 0x0a,
+	0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x54, 0x0a, 0x0c, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x65, 0x73,
 	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
 	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x22, 0x2f, 0x0a, 0x13, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x41, 0x67, 0x65, 0x6e,
-	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x22, 0x32, 0x0a, 0x14, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x41, 0x67, 0x65,
-	0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x72, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x74, 0x72, 0x61, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x22, 0x2a, 0x0a, 0x0e, 0x50,
+	0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x4a, 0x0a, 0x14, 0x50, 0x6c, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x70, 0x6c, 0x61, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70,
+	0x6c, 0x61, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x22, 0x7f, 0x0a, 0x0b, 0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x53, 0x74,
+	0x65, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x61, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07,
+	0x54, 0x68, 0x6f, 0x75, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x54,
+	0x68, 0x6f, 0x75, 0x67, 0x68, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x44, 0x65, 0x70, 0x65, 0x6e, 0x64,
+	0x73, 0x4f, 0x6e, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x44, 0x65, 0x70, 0x65, 0x6e,
+	0x64, 0x73, 0x4f, 0x6e, 0x22, 0x51, 0x0a, 0x0d, 0x50, 0x6c, 0x61, 0x6e, 0x56, 0x69, 0x6f, 0x6c,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x73, 0x6b, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x61, 0x73, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x75, 0x6c,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x75, 0x6c, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x6d, 0x0a, 0x0f, 0x50, 0x6c, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x73, 0x74,
+	0x65, 0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x77, 0x73, 0x70, 0x2e,
+	0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x53, 0x74, 0x65, 0x70, 0x52, 0x05, 0x73, 0x74, 0x65,
+	0x70, 0x73, 0x12, 0x32, 0x0a, 0x0a, 0x76, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x50, 0x6c, 0x61,
+	0x6e, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x76, 0x69, 0x6f, 0x6c,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x2f, 0x0a, 0x13, 0x4f, 0x72, 0x63, 0x68, 0x65, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x32, 0x0a, 0x14, 0x4f, 0x72, 0x63, 0x68, 0x65,
+	0x73, 0x74, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x28, 0x0a, 0x0c, 0x41,
+	0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2b, 0x0a, 0x0d, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x2f, 0x0a, 0x13, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x41, 0x67, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x32, 0x0a, 0x14, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x41, 0x67,
+	0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x2c, 0x0a, 0x10, 0x44, 0x69, 0x73, 0x63, 0x6f,
+	0x76, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2f, 0x0a, 0x11, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65,
+	0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65,
 	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x2c, 0x0a, 0x10, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76,
-	0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x22, 0x2f, 0x0a, 0x11, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72,
-	0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x28, 0x0a, 0x12, 0x54, 0x6f, 0x6f, 0x6c, 0x45, 0x78, 0x65,
-	0x63, 0x75, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e,
-	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22,
-	0x29, 0x0a, 0x13, 0x54, 0x6f, 0x6f, 0x6c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x2c, 0x0a, 0x10, 0x48, 0x65,
-	0x61, 0x72, 0x74, 0x42, 0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18,
-	0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2f, 0x0a, 0x11, 0x48, 0x65, 0x61, 0x72,
-	0x74, 0x42, 0x65, 0x61, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a,
-	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xd4, 0x04, 0x0a, 0x10, 0x57, 0x6f,
-	0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x35,
-	0x0a, 0x0a, 0x4c, 0x69, 0x6e, 0x6b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x10, 0x2e, 0x77,
-	0x73, 0x70, 0x2e, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11,
-	0x2e, 0x77, 0x73, 0x70, 0x2e, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x28, 0x01, 0x30, 0x01, 0x12, 0x3a, 0x0a, 0x0d, 0x49, 0x6e, 0x76, 0x6f, 0x6b, 0x65, 0x50,
-	0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x50, 0x6c, 0x61,
-	0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x77, 0x73,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x28, 0x0a, 0x12, 0x54, 0x6f, 0x6f, 0x6c, 0x45, 0x78,
+	0x65, 0x63, 0x75, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x22, 0x29, 0x0a, 0x13, 0x54, 0x6f, 0x6f, 0x6c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x33, 0x0a, 0x0c, 0x55,
+	0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x63,
+	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0c, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x64,
+	0x22, 0xc0, 0x01, 0x0a, 0x0b, 0x55, 0x73, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x61, 0x6c, 0x6c, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x61, 0x6c, 0x6c, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x72,
+	0x6f, 0x6d, 0x70, 0x74, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x6d, 0x70, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x12,
+	0x2b, 0x0a, 0x11, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f,
+	0x6b, 0x65, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x63, 0x6f, 0x6d, 0x70,
+	0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x12,
+	0x12, 0x0a, 0x04, 0x63, 0x6f, 0x73, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x63,
+	0x6f, 0x73, 0x74, 0x22, 0xbf, 0x01, 0x0a, 0x0d, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65,
+	0x54, 0x6f, 0x74, 0x61, 0x6c, 0x73, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x28, 0x0a,
+	0x06, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e,
+	0x77, 0x73, 0x70, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x73, 0x52,
+	0x06, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x28, 0x0a, 0x06, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x55, 0x73,
+	0x61, 0x67, 0x65, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x73, 0x52, 0x06, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+	0x73, 0x12, 0x32, 0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x73, 0x52, 0x0b, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x2c, 0x0a, 0x10, 0x48, 0x65, 0x61, 0x72, 0x74, 0x42, 0x65,
+	0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x2f, 0x0a, 0x11, 0x48, 0x65, 0x61, 0x72, 0x74, 0x42, 0x65, 0x61, 0x74,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x32, 0x87, 0x05, 0x0a, 0x10, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x35, 0x0a, 0x0a, 0x4c, 0x69, 0x6e,
+	0x6b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x10, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x4c, 0x69,
+	0x6e, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x77, 0x73, 0x70, 0x2e,
+	0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01,
+	0x12, 0x3a, 0x0a, 0x0d, 0x49, 0x6e, 0x76, 0x6f, 0x6b, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x12, 0x13, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x50, 0x6c, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x10,
+	0x49, 0x6e, 0x76, 0x6f, 0x6b, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65,
+	0x12, 0x19, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65,
+	0x66, 0x69, 0x6e, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x77, 0x73,
 	0x70, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x43, 0x0a, 0x10, 0x49, 0x6e, 0x76, 0x6f, 0x6b, 0x65, 0x50, 0x6c, 0x61, 0x6e, 0x52,
-	0x65, 0x66, 0x69, 0x6e, 0x65, 0x12, 0x19, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x50, 0x6c, 0x61, 0x6e,
-	0x6e, 0x65, 0x72, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x14, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x50, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x12, 0x49, 0x6e, 0x76, 0x6f, 0x6b, 0x65,
-	0x4f, 0x72, 0x63, 0x68, 0x65, 0x73, 0x74, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x18, 0x2e, 0x77,
-	0x73, 0x70, 0x2e, 0x4f, 0x72, 0x63, 0x68, 0x65, 0x73, 0x74, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x4f, 0x72, 0x63,
-	0x68, 0x65, 0x73, 0x74, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x34, 0x0a, 0x0b, 0x49, 0x6e, 0x76, 0x6f, 0x6b, 0x65, 0x41, 0x67, 0x65, 0x6e, 0x74,
-	0x12, 0x11, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x15, 0x4d, 0x6f, 0x6e, 0x69, 0x74,
-	0x6f, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x18, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x41, 0x67,
-	0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x77, 0x73, 0x70,
-	0x2e, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x35, 0x0a, 0x0c, 0x45, 0x78, 0x65, 0x63, 0x75,
-	0x74, 0x65, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x12, 0x11, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x41, 0x67,
-	0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x77, 0x73, 0x70,
-	0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3e,
-	0x0a, 0x0d, 0x54, 0x6f, 0x6f, 0x6c, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x12,
-	0x15, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x44, 0x69, 0x73,
-	0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40,
-	0x0a, 0x0b, 0x54, 0x6f, 0x6f, 0x6c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x12, 0x17, 0x2e,
-	0x77, 0x73, 0x70, 0x2e, 0x54, 0x6f, 0x6f, 0x6c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x54, 0x6f, 0x6f,
-	0x6c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x42, 0x07, 0x5a, 0x05, 0x2e, 0x3b, 0x77, 0x73, 0x70, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x65, 0x12, 0x49, 0x0a, 0x12, 0x49, 0x6e, 0x76, 0x6f, 0x6b, 0x65, 0x4f, 0x72, 0x63, 0x68, 0x65,
+	0x73, 0x74, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x18, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x4f, 0x72,
+	0x63, 0x68, 0x65, 0x73, 0x74, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x19, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x4f, 0x72, 0x63, 0x68, 0x65, 0x73, 0x74, 0x72,
+	0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x34, 0x0a, 0x0b,
+	0x49, 0x6e, 0x76, 0x6f, 0x6b, 0x65, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x12, 0x11, 0x2e, 0x77, 0x73,
+	0x70, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12,
+	0x2e, 0x77, 0x73, 0x70, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x4e, 0x0a, 0x15, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x41, 0x67, 0x65,
+	0x6e, 0x74, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x2e, 0x77, 0x73,
+	0x70, 0x2e, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x4d, 0x6f, 0x6e, 0x69,
+	0x74, 0x6f, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x30, 0x01, 0x12, 0x35, 0x0a, 0x0c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x41, 0x67, 0x65,
+	0x6e, 0x74, 0x12, 0x11, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x41, 0x67, 0x65, 0x6e,
+	0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3e, 0x0a, 0x0d, 0x54, 0x6f, 0x6f,
+	0x6c, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x12, 0x15, 0x2e, 0x77, 0x73, 0x70,
+	0x2e, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x16, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72,
+	0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x0b, 0x54, 0x6f, 0x6f,
+	0x6c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x12, 0x17, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x54,
+	0x6f, 0x6f, 0x6c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x18, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x54, 0x6f, 0x6f, 0x6c, 0x45, 0x78, 0x65, 0x63,
+	0x75, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x08, 0x47,
+	0x65, 0x74, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x11, 0x2e, 0x77, 0x73, 0x70, 0x2e, 0x55, 0x73,
+	0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x77, 0x73, 0x70,
+	0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x07,
+	0x5a, 0x05, 0x2e, 0x3b, 0x77, 0x73, 0x70, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 })
 
 var (
@@ -962,52 +1331,63 @@ func file_internal_bridge_wsp_wsp_proto_rawDescGZIP() []byte {
 	return file_internal_bridge_wsp_wsp_proto_rawDescData
 }
 
-var file_internal_bridge_wsp_wsp_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_internal_bridge_wsp_wsp_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_internal_bridge_wsp_wsp_proto_goTypes = []any{
 	(*LinkRequest)(nil),          // 0: wsp.LinkRequest
 	(*LinkResponse)(nil),         // 1: wsp.LinkResponse
 	(*PlannerRequest)(nil),       // 2: wsp.PlannerRequest
 	(*PlannerRefineRequest)(nil), // 3: wsp.PlannerRefineRequest
 	(*PlannerStep)(nil),          // 4: wsp.PlannerStep
-	(*PlannerResponse)(nil),      // 5: wsp.PlannerResponse
-	(*OrchestratorRequest)(nil),  // 6: wsp.OrchestratorRequest
-	(*OrchestratorResponse)(nil), // 7: wsp.OrchestratorResponse
-	(*AgentRequest)(nil),         // 8: wsp.AgentRequest
-	(*AgentResponse)(nil),        // 9: wsp.AgentResponse
-	(*MonitorAgentRequest)(nil),  // 10: wsp.MonitorAgentRequest
-	(*MonitorAgentResponse)(nil), // 11: wsp.MonitorAgentResponse
-	(*DiscoveryRequest)(nil),     // 12: wsp.DiscoveryRequest
-	(*DiscoveryResponse)(nil),    // 13: wsp.DiscoveryResponse
-	(*ToolExecuteRequest)(nil),   // 14: wsp.ToolExecuteRequest
-	(*ToolExecuteResponse)(nil),  // 15: wsp.ToolExecuteResponse
-	(*HeartBeatRequest)(nil),     // 16: wsp.HeartBeatRequest
-	(*HeartBeatResponse)(nil),    // 17: wsp.HeartBeatResponse
+	(*PlanViolation)(nil),        // 5: wsp.PlanViolation
+	(*PlannerResponse)(nil),      // 6: wsp.PlannerResponse
+	(*OrchestratorRequest)(nil),  // 7: wsp.OrchestratorRequest
+	(*OrchestratorResponse)(nil), // 8: wsp.OrchestratorResponse
+	(*AgentRequest)(nil),         // 9: wsp.AgentRequest
+	(*AgentResponse)(nil),        // 10: wsp.AgentResponse
+	(*MonitorAgentRequest)(nil),  // 11: wsp.MonitorAgentRequest
+	(*MonitorAgentResponse)(nil), // 12: wsp.MonitorAgentResponse
+	(*DiscoveryRequest)(nil),     // 13: wsp.DiscoveryRequest
+	(*DiscoveryResponse)(nil),    // 14: wsp.DiscoveryResponse
+	(*ToolExecuteRequest)(nil),   // 15: wsp.ToolExecuteRequest
+	(*ToolExecuteResponse)(nil),  // 16: wsp.ToolExecuteResponse
+	(*UsageRequest)(nil),         // 17: wsp.UsageRequest
+	(*UsageTotals)(nil),          // 18: wsp.UsageTotals
+	(*UsageResponse)(nil),        // 19: wsp.UsageResponse
+	(*HeartBeatRequest)(nil),     // 20: wsp.HeartBeatRequest
+	(*HeartBeatResponse)(nil),    // 21: wsp.HeartBeatResponse
 }
 var file_internal_bridge_wsp_wsp_proto_depIdxs = []int32{
 	4,  // 0: wsp.PlannerResponse.steps:type_name -> wsp.PlannerStep
-	0,  // 1: wsp.WorkspaceService.LinkStream:input_type -> wsp.LinkRequest
-	2,  // 2: wsp.WorkspaceService.InvokePlanner:input_type -> wsp.PlannerRequest
-	3,  // 3: wsp.WorkspaceService.InvokePlanRefine:input_type -> wsp.PlannerRefineRequest
-	6,  // 4: wsp.WorkspaceService.InvokeOrchestrator:input_type -> wsp.OrchestratorRequest
-	8,  // 5: wsp.WorkspaceService.InvokeAgent:input_type -> wsp.AgentRequest
-	10, // 6: wsp.WorkspaceService.MonitorAgentExecution:input_type -> wsp.MonitorAgentRequest
-	8,  // 7: wsp.WorkspaceService.ExecuteAgent:input_type -> wsp.AgentRequest
-	12, // 8: wsp.WorkspaceService.ToolDiscovery:input_type -> wsp.DiscoveryRequest
-	14, // 9: wsp.WorkspaceService.ToolExecute:input_type -> wsp.ToolExecuteRequest
-	1,  // 10: wsp.WorkspaceService.LinkStream:output_type -> wsp.LinkResponse
-	5,  // 11: wsp.WorkspaceService.InvokePlanner:output_type -> wsp.PlannerResponse
-	5,  // 12: wsp.WorkspaceService.InvokePlanRefine:output_type -> wsp.PlannerResponse
-	7,  // 13: wsp.WorkspaceService.InvokeOrchestrator:output_type -> wsp.OrchestratorResponse
-	9,  // 14: wsp.WorkspaceService.InvokeAgent:output_type -> wsp.AgentResponse
-	11, // 15: wsp.WorkspaceService.MonitorAgentExecution:output_type -> wsp.MonitorAgentResponse
-	9,  // 16: wsp.WorkspaceService.ExecuteAgent:output_type -> wsp.AgentResponse
-	13, // 17: wsp.WorkspaceService.ToolDiscovery:output_type -> wsp.DiscoveryResponse
-	15, // 18: wsp.WorkspaceService.ToolExecute:output_type -> wsp.ToolExecuteResponse
-	10, // [10:19] is the sub-list for method output_type
-	1,  // [1:10] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	5,  // 1: wsp.PlannerResponse.violations:type_name -> wsp.PlanViolation
+	18, // 2: wsp.UsageResponse.total:type_name -> wsp.UsageTotals
+	18, // 3: wsp.UsageResponse.agents:type_name -> wsp.UsageTotals
+	18, // 4: wsp.UsageResponse.models:type_name -> wsp.UsageTotals
+	18, // 5: wsp.UsageResponse.connections:type_name -> wsp.UsageTotals
+	0,  // 6: wsp.WorkspaceService.LinkStream:input_type -> wsp.LinkRequest
+	2,  // 7: wsp.WorkspaceService.InvokePlanner:input_type -> wsp.PlannerRequest
+	3,  // 8: wsp.WorkspaceService.InvokePlanRefine:input_type -> wsp.PlannerRefineRequest
+	7,  // 9: wsp.WorkspaceService.InvokeOrchestrator:input_type -> wsp.OrchestratorRequest
+	9,  // 10: wsp.WorkspaceService.InvokeAgent:input_type -> wsp.AgentRequest
+	11, // 11: wsp.WorkspaceService.MonitorAgentExecution:input_type -> wsp.MonitorAgentRequest
+	9,  // 12: wsp.WorkspaceService.ExecuteAgent:input_type -> wsp.AgentRequest
+	13, // 13: wsp.WorkspaceService.ToolDiscovery:input_type -> wsp.DiscoveryRequest
+	15, // 14: wsp.WorkspaceService.ToolExecute:input_type -> wsp.ToolExecuteRequest
+	17, // 15: wsp.WorkspaceService.GetUsage:input_type -> wsp.UsageRequest
+	1,  // 16: wsp.WorkspaceService.LinkStream:output_type -> wsp.LinkResponse
+	6,  // 17: wsp.WorkspaceService.InvokePlanner:output_type -> wsp.PlannerResponse
+	6,  // 18: wsp.WorkspaceService.InvokePlanRefine:output_type -> wsp.PlannerResponse
+	8,  // 19: wsp.WorkspaceService.InvokeOrchestrator:output_type -> wsp.OrchestratorResponse
+	10, // 20: wsp.WorkspaceService.InvokeAgent:output_type -> wsp.AgentResponse
+	12, // 21: wsp.WorkspaceService.MonitorAgentExecution:output_type -> wsp.MonitorAgentResponse
+	10, // 22: wsp.WorkspaceService.ExecuteAgent:output_type -> wsp.AgentResponse
+	14, // 23: wsp.WorkspaceService.ToolDiscovery:output_type -> wsp.DiscoveryResponse
+	16, // 24: wsp.WorkspaceService.ToolExecute:output_type -> wsp.ToolExecuteResponse
+	19, // 25: wsp.WorkspaceService.GetUsage:output_type -> wsp.UsageResponse
+	16, // [16:26] is the sub-list for method output_type
+	6,  // [6:16] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_internal_bridge_wsp_wsp_proto_init() }
@@ -1021,7 +1401,7 @@ func file_internal_bridge_wsp_wsp_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_bridge_wsp_wsp_proto_rawDesc), len(file_internal_bridge_wsp_wsp_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   18,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -28,6 +28,7 @@ const (
 	WorkspaceService_ExecuteAgent_FullMethodName          = "/wsp.WorkspaceService/ExecuteAgent"
 	WorkspaceService_ToolDiscovery_FullMethodName         = "/wsp.WorkspaceService/ToolDiscovery"
 	WorkspaceService_ToolExecute_FullMethodName           = "/wsp.WorkspaceService/ToolExecute"
+	WorkspaceService_GetUsage_FullMethodName              = "/wsp.WorkspaceService/GetUsage"
 )
 
 // WorkspaceServiceClient is the client API for WorkspaceService service.
@@ -43,6 +44,7 @@ type WorkspaceServiceClient interface {
 	ExecuteAgent(ctx context.Context, in *AgentRequest, opts ...grpc.CallOption) (*AgentResponse, error)
 	ToolDiscovery(ctx context.Context, in *DiscoveryRequest, opts ...grpc.CallOption) (*DiscoveryResponse, error)
 	ToolExecute(ctx context.Context, in *ToolExecuteRequest, opts ...grpc.CallOption) (*ToolExecuteResponse, error)
+	GetUsage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error)
 }
 
 type workspaceServiceClient struct {
@@ -155,6 +157,16 @@ func (c *workspaceServiceClient) ToolExecute(ctx context.Context, in *ToolExecut
 	return out, nil
 }
 
+func (c *workspaceServiceClient) GetUsage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UsageResponse)
+	err := c.cc.Invoke(ctx, WorkspaceService_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // WorkspaceServiceServer is the server API for WorkspaceService service.
 // All implementations must embed UnimplementedWorkspaceServiceServer
 // for forward compatibility.
@@ -168,6 +180,7 @@ type WorkspaceServiceServer interface {
 	ExecuteAgent(context.Context, *AgentRequest) (*AgentResponse, error)
 	ToolDiscovery(context.Context, *DiscoveryRequest) (*DiscoveryResponse, error)
 	ToolExecute(context.Context, *ToolExecuteRequest) (*ToolExecuteResponse, error)
+	GetUsage(context.Context, *UsageRequest) (*UsageResponse, error)
 	mustEmbedUnimplementedWorkspaceServiceServer()
 }
 
@@ -205,6 +218,9 @@ func (UnimplementedWorkspaceServiceServer) ToolDiscovery(context.Context, *Disco
 func (UnimplementedWorkspaceServiceServer) ToolExecute(context.Context, *ToolExecuteRequest) (*ToolExecuteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ToolExecute not implemented")
 }
+func (UnimplementedWorkspaceServiceServer) GetUsage(context.Context, *UsageRequest) (*UsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
 func (UnimplementedWorkspaceServiceServer) mustEmbedUnimplementedWorkspaceServiceServer() {}
 func (UnimplementedWorkspaceServiceServer) testEmbeddedByValue()                          {}
 
@@ -370,6 +386,24 @@ func _WorkspaceService_ToolExecute_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WorkspaceService_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkspaceServiceServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WorkspaceService_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkspaceServiceServer).GetUsage(ctx, req.(*UsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // WorkspaceService_ServiceDesc is the grpc.ServiceDesc for WorkspaceService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -405,6 +439,10 @@ var WorkspaceService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ToolExecute",
 			Handler:    _WorkspaceService_ToolExecute_Handler,
 		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _WorkspaceService_GetUsage_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -0,0 +1,284 @@
+package wsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	skill "yafai/internal/bridge/skill"
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/workspace"
+)
+
+// scriptedProvider answers with replies in turn, repeating the last one, or fails
+// every call with err. With block set it waits for the request to be cancelled. The
+// requests it is sent are kept.
+type scriptedProvider struct {
+	mu       sync.Mutex
+	replies  []string
+	err      error
+	block    bool
+	calls    int
+	requests []providers.GenAIProviderRequest
+}
+
+func (p *scriptedProvider) Init() *http.Client { return nil }
+
+func (p *scriptedProvider) Generate(ctx context.Context, client *http.Client, req providers.GenAIProviderRequest) (*providers.GenAIProviderResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	p.requests = append(p.requests, req)
+	p.mu.Unlock()
+	if p.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reply := p.replies[0]
+	if len(p.replies) > 1 {
+		p.replies = p.replies[1:]
+	}
+	return &providers.GenAIProviderResponse{Choices: []providers.ResponseChoice{{Message: providers.ResponseMessage{Role: "assistant", Content: reply}}}}, nil
+}
+
+func (p *scriptedProvider) GenerateStream(ctx context.Context, client *http.Client, req providers.GenAIProviderRequest) (<-chan providers.StreamChunk, error) {
+	return nil, errors.New("streaming is not scripted")
+}
+
+func (p *scriptedProvider) Close(client *http.Client) {}
+
+func (p *scriptedProvider) Capabilities() providers.Capabilities { return providers.Capabilities{} }
+
+func (p *scriptedProvider) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// fakeLinkStream plays packets to LinkStream and collects what it sends back.
+type fakeLinkStream struct {
+	grpc.ServerStream
+	mu      sync.Mutex
+	packets []*LinkRequest
+	sent    []*LinkResponse
+}
+
+func (f *fakeLinkStream) Context() context.Context { return context.Background() }
+
+func (f *fakeLinkStream) Recv() (*LinkRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.packets) == 0 {
+		return nil, io.EOF
+	}
+	packet := f.packets[0]
+	f.packets = f.packets[1:]
+	return packet, nil
+}
+
+func (f *fakeLinkStream) Send(r *LinkResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, r)
+	return nil
+}
+
+// summaries returns the loop summaries sent.
+func (f *fakeLinkStream) summaries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []string
+	for _, r := range f.sent {
+		if strings.HasPrefix(r.Response, "I could not complete this request") {
+			found = append(found, r.Response)
+		}
+	}
+	return found
+}
+
+func invokeAction(agent string, task string) string {
+	return fmt.Sprintf(`{"action":"agent_invoke","name":%q,"task":%q,"chat":"","answer":""}`, agent, task)
+}
+
+// runLink answers one request with an orchestrator scripted by orchestrator and a
+// single agent "a" backed by agent.
+func runLink(t *testing.T, orchestrator *executors.YafaiOrchestrator, agent *scriptedProvider) *fakeLinkStream {
+	t.Helper()
+	orchestrator.Model, orchestrator.Goal, orchestrator.Scope = "m", "goal", "scope"
+	orchestrator.Team = map[string]*executors.YafaiAgent{"a": {
+		Name: "a", Model: "m", GenAIProvider: agent,
+		// A tool keeps the agent from discovering its skills over the socket
+		Tools:       []providers.LLMTool{{}},
+		SkillClient: skill.NewSkillServiceClient(nil),
+	}}
+	server := &WorkspaceServer{Wsp: &workspace.Workspace{Name: "w", Orchestrator: orchestrator}}
+	stream := &fakeLinkStream{packets: []*LinkRequest{{Request: "question"}}}
+	done := make(chan error, 1)
+	go func() { done <- server.LinkStream(stream) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("LinkStream: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("LinkStream did not return")
+	}
+	return stream
+}
+
+func TestLinkStreamStopsAfterAgentFailures(t *testing.T) {
+	orchestrator := &scriptedProvider{replies: []string{invokeAction("a", "one"), invokeAction("a", "two"), invokeAction("a", "three"), invokeAction("a", "four")}}
+	agent := &scriptedProvider{err: errors.New("agent is down")}
+	stream := runLink(t, &executors.YafaiOrchestrator{GenAIProvider: orchestrator, MaxIterations: 10, MaxFailures: 3}, agent)
+
+	summaries := stream.summaries()
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1: %q", len(summaries), summaries)
+	}
+	if !strings.Contains(summaries[0], "agents failed 3 times in a row") {
+		t.Errorf("summary = %q", summaries[0])
+	}
+	if got := orchestrator.count(); got != 3 {
+		t.Errorf("orchestrator called %d times, want 3", got)
+	}
+}
+
+func TestLinkStreamStopsAtIterationLimit(t *testing.T) {
+	orchestrator := &scriptedProvider{replies: []string{invokeAction("a", "one"), invokeAction("a", "two"), invokeAction("a", "three")}}
+	agent := &scriptedProvider{replies: []string{"Final Answer: done"}}
+	stream := runLink(t, &executors.YafaiOrchestrator{GenAIProvider: orchestrator, MaxIterations: 2}, agent)
+
+	summaries := stream.summaries()
+	if len(summaries) != 1 || !strings.Contains(summaries[0], "it reached the limit of 2 agent calls") {
+		t.Fatalf("summaries = %q", summaries)
+	}
+	if !strings.Contains(summaries[0], "1. a: one (returned: done)") || !strings.Contains(summaries[0], "2. a: two (returned: done)") {
+		t.Errorf("summary does not list the calls: %q", summaries[0])
+	}
+}
+
+func TestLinkStreamStopsRepeatedCalls(t *testing.T) {
+	orchestrator := &scriptedProvider{replies: []string{invokeAction("a", "same")}}
+	agent := &scriptedProvider{replies: []string{"Final Answer: done"}}
+	stream := runLink(t, &executors.YafaiOrchestrator{GenAIProvider: orchestrator, MaxIterations: 10}, agent)
+
+	summaries := stream.summaries()
+	if len(summaries) != 1 || !strings.Contains(summaries[0], "kept repeating the same call to agent a") {
+		t.Fatalf("summaries = %q", summaries)
+	}
+	if got := agent.count(); got != 1 {
+		t.Errorf("agent called %d times, want 1", got)
+	}
+}
+
+func TestLinkStreamStopsAtTimeLimit(t *testing.T) {
+	orchestrator := &scriptedProvider{replies: []string{invokeAction("a", "one")}}
+	agent := &scriptedProvider{block: true}
+	stream := runLink(t, &executors.YafaiOrchestrator{GenAIProvider: orchestrator, MaxFailures: 5, MaxDuration: 50 * time.Millisecond}, agent)
+
+	summaries := stream.summaries()
+	if len(summaries) != 1 || !strings.Contains(summaries[0], "it took longer than the limit of 50ms") {
+		t.Fatalf("summaries = %q", summaries)
+	}
+}
+
+func answerAction(answer string) string {
+	return fmt.Sprintf(`{"action":"answer","name":"","task":"","chat":"","answer":%q}`, answer)
+}
+
+// responses returns the responses sent, traces included.
+func (f *fakeLinkStream) responses() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []string
+	for _, r := range f.sent {
+		found = append(found, r.Response)
+	}
+	return found
+}
+
+func TestLinkStreamCriticVerdicts(t *testing.T) {
+	tests := []struct {
+		name         string
+		verdicts     []string
+		maxRevisions int
+		want         []string
+		wantAnswers  int
+	}{
+		{
+			name:        "approve",
+			verdicts:    []string{`{"verdict":"pass","feedback":""}`},
+			want:        []string{"draft"},
+			wantAnswers: 1,
+		},
+		{
+			name:        "revise",
+			verdicts:    []string{`{"verdict":"revise","feedback":"name the breed"}`, `{"verdict":"pass","feedback":""}`},
+			want:        []string{"Revision 1/2 requested: name the breed", "final"},
+			wantAnswers: 2,
+		},
+		{
+			name:        "reject",
+			verdicts:    []string{`{"verdict":"fail","feedback":"there is no picture"}`},
+			want:        []string{"draft", "Note: the reviewer found that this answer may not meet the goal: there is no picture"},
+			wantAnswers: 1,
+		},
+		{
+			name:         "revisions used up",
+			verdicts:     []string{`{"verdict":"revise","feedback":"shorter"}`},
+			maxRevisions: 1,
+			want:         []string{"Revision 1/1 requested: shorter", "final", "Note: the reviewer found that this answer may not meet the goal: shorter"},
+			wantAnswers:  2,
+		},
+		{
+			name:        "malformed verdict",
+			verdicts:    []string{`{"verdict":"maybe"}`},
+			want:        []string{"draft"},
+			wantAnswers: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orchestrator := &scriptedProvider{replies: []string{answerAction("draft"), answerAction("final")}}
+			critic := &scriptedProvider{replies: tt.verdicts}
+			stream := runLink(t, &executors.YafaiOrchestrator{
+				GenAIProvider: orchestrator,
+				Critic:        &executors.YafaiCritic{Model: "judge", GenAIProvider: critic, MaxRevisions: tt.maxRevisions},
+			}, &scriptedProvider{replies: []string{"Final Answer: unused"}})
+
+			responses := stream.responses()
+			var got []string
+			for _, response := range responses {
+				for _, want := range tt.want {
+					if response == want || strings.HasSuffix(response, want) {
+						got = append(got, want)
+					}
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("responses = %q, want %q in order", responses, tt.want)
+			}
+			if orchestrator.count() != tt.wantAnswers {
+				t.Errorf("orchestrator answered %d times, want %d", orchestrator.count(), tt.wantAnswers)
+			}
+			for _, response := range responses {
+				if tt.wantAnswers > 1 && response == "draft" {
+					t.Error("the revised draft reached the user")
+				}
+			}
+		})
+	}
+}
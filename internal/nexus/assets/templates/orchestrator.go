@@ -30,24 +30,25 @@ Repeat the Thought → Plan → Action → Observation cycle until no more agent
 Chat Reply: when engaging in general conversation or clarifications, return JSON:
 
 '''json
-{"chat":"your response to user for greetings, general chat and conversations"}
+{"action":"chat","chat":"your response to user for greetings, general chat and conversations"}
 '''
 
 Final Answer: when you have a final answer and the orchestration is done or the process failed, return this JSON:
-{"answer":"Your final response here."}
+{"action":"answer","answer":"Your final response here."}
 
-Note: Never return 'chat' and 'answer' together. Only one key per response.
+Note: Never return 'chat' and 'answer' together. Only fill the fields of the chosen action.
 IMPORTANT : If user asks about options available for a parameter needed by an agent, invoke the agent to get the parameter options, strictyl reply only with those parameters.
 Do Not assume, guess or hallucinate the options.
 
 Begin!
 
 IMPORTANT : Never ask user to wait as you are not running any processes without user consent.
-
-Chat History:
-{{.ChatRecords}}
-
-Ensure you review the entire chat history at each Thought, Plan, Action, and Observation step.
+{{if .Plan}}
+Current plan ({{.Confirmation}}):
+{{.Plan}}
+A plan runs only once the user confirms it; until then, help the user review or refine it.
+{{end}}
+Ensure you review the entire conversation at each Thought, Plan, Action, and Observation step.
 
 
 `
@@ -60,6 +61,7 @@ message : {{.Message}}
 
 var AgentLogRecord = `
 agent: {{.Name}}
+task: {{.Task}}
 output: {{.Response}}
 `
 
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/usage"
 	"yafai/internal/nexus/workspace"
 
 	"gopkg.in/yaml.v3"
@@ -25,31 +30,58 @@ func GetAvailableConfigs(path string) ([]string, error) {
 	return configs, nil
 }
 
-func NewWorkspace(path string) *workspace.Workspace {
-	wsp := ParseConfig(path)
-	return wsp
+func NewWorkspace(path string) (*workspace.Workspace, error) {
+	return ParseConfig(path)
 }
 
-func ParseConfig(path string) *workspace.Workspace {
+func ParseConfig(path string) (*workspace.Workspace, error) {
 
 	data, err := os.ReadFile(path)
-
 	if err != nil {
-		slog.Error("here!!!", err.Error(), nil)
+		slog.Error("Failed to read config", "path", path, "error", err)
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
 	}
 
 	var config WorkspaceConfig
 	err = yaml.Unmarshal(data, &config)
-
 	if err != nil {
-		slog.Info("YAML parsing error!!!!!!!!!!!!!!!")
-		slog.Error(err.Error())
+		slog.Error("YAML parsing error", "path", path, "error", err)
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
 	}
 
 	for name, member := range config.Orchestrator.Team {
 		member.Name = name
 		config.Planner.Agents = append(config.Planner.Agents, member)
 	}
+
+	if err := attachProviders(&config); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := validateGeneration(&config); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := validateContext(&config); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if config.Orchestrator.Parallelism < 0 {
+		return nil, fmt.Errorf("config %s: orchestrator parallelism must not be negative, got %d", path, config.Orchestrator.Parallelism)
+	}
+	if config.Orchestrator.MaxIterations < 0 || config.Orchestrator.MaxFailures < 0 {
+		return nil, fmt.Errorf("config %s: orchestrator max_iterations and max_failures must not be negative", path)
+	}
+	if config.Orchestrator.MaxDuration < 0 {
+		return nil, fmt.Errorf("config %s: orchestrator max_duration must not be negative, got %s", path, config.Orchestrator.MaxDuration)
+	}
+	if config.Planner.MaxRevisions < 0 {
+		return nil, fmt.Errorf("config %s: planner max_revisions must not be negative, got %d", path, config.Planner.MaxRevisions)
+	}
+	if config.Critic.MaxRevisions < 0 {
+		return nil, fmt.Errorf("config %s: critic max_revisions must not be negative, got %d", path, config.Critic.MaxRevisions)
+	}
+	if config.Critic.Enabled() {
+		config.Orchestrator.Critic = &config.Critic
+	}
+
 	// planner := &executors.YafaiPlanner{Agents: config.Team, Model: config.Planner.Model }
 	slog.Info("Parsed config", "config", config)
 	workspace := workspace.Workspace{
@@ -57,10 +89,108 @@ func ParseConfig(path string) *workspace.Workspace {
 		Scope:        config.Scope,
 		Planner:      &config.Planner,
 		Orchestrator: &config.Orchestrator,
+		Providers:    config.Providers,
+		Pricing:      config.Pricing,
+		Budget:       config.Budget,
+		Usage:        usage.NewLedger(config.Pricing, config.Budget),
+		Status:       workspace.NewStatusHub(),
 		Integrations: config.Integrations,
 		VectorStore:  config.VectorStore,
 		Bridge:       config.Bridge,
 	}
 
-	return &workspace
+	return &workspace, nil
+}
+
+// attachProviders validates the providers section and resolves the provider of every
+// actor in the workspace, so unknown provider names fail at load time.
+func attachProviders(config *WorkspaceConfig) error {
+	var errs []error
+
+	for name := range config.Providers {
+		if _, _, err := providers.ResolveConfig(name, config.Providers); err != nil {
+			errs = append(errs, fmt.Errorf("providers: %w", err))
+		}
+	}
+
+	// Actors of one provider share it, and with it its HTTP connections
+	built := make(map[string]providers.GenAIProvider)
+	resolve := func(actor string, name string) providers.GenAIProvider {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("%s: no provider set", actor))
+			return nil
+		}
+		if provider, ok := built[name]; ok {
+			return provider
+		}
+		provider, err := providers.NewProvider(name, config.Providers)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", actor, err))
+			return nil
+		}
+		built[name] = provider
+		return provider
+	}
+	// Fallbacks without a provider use the actor's own provider.
+	resolveFallbacks := func(actor string, primary string, fallbacks []*executors.ModelRef) {
+		for i, fallback := range fallbacks {
+			if fallback == nil || fallback.Model == "" {
+				errs = append(errs, fmt.Errorf("%s: fallback %d has no model", actor, i+1))
+				continue
+			}
+			if fallback.Provider == "" {
+				fallback.Provider = primary
+			}
+			fallback.GenAIProvider = resolve(fmt.Sprintf("%s fallback %d", actor, i+1), fallback.Provider)
+		}
+	}
+
+	if config.Planner.Model != "" || config.Planner.Provider != "" {
+		config.Planner.GenAIProvider = resolve("planner", config.Planner.Provider)
+		resolveFallbacks("planner", config.Planner.Provider, config.Planner.Fallbacks)
+	}
+	if config.Critic.Model != "" || config.Critic.Provider != "" {
+		config.Critic.GenAIProvider = resolve("critic", config.Critic.Provider)
+		resolveFallbacks("critic", config.Critic.Provider, config.Critic.Fallbacks)
+	}
+	config.Orchestrator.GenAIProvider = resolve("orchestrator", config.Orchestrator.Provider)
+	resolveFallbacks("orchestrator", config.Orchestrator.Provider, config.Orchestrator.Fallbacks)
+	for name, member := range config.Orchestrator.Team {
+		member.GenAIProvider = resolve(fmt.Sprintf("agent %s", name), member.Provider)
+		resolveFallbacks(fmt.Sprintf("agent %s", name), member.Provider, member.Fallbacks)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateGeneration checks the sampling parameters of every actor in the workspace.
+func validateGeneration(config *WorkspaceConfig) error {
+	var errs []error
+	check := func(actor string, generation providers.GenerationConfig) {
+		if err := generation.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s generation: %w", actor, err))
+		}
+	}
+
+	check("planner", config.Planner.Generation)
+	check("orchestrator", config.Orchestrator.Generation)
+	check("critic", config.Critic.Generation)
+	for name, member := range config.Orchestrator.Team {
+		check(fmt.Sprintf("agent %s", name), member.Generation)
+	}
+	return errors.Join(errs...)
+}
+
+// validateContext checks the context window settings of the actors keeping a history.
+func validateContext(config *WorkspaceConfig) error {
+	var errs []error
+	if err := config.Orchestrator.Context.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("orchestrator context: %w", err))
+	}
+	for name, member := range config.Orchestrator.Team {
+		if err := member.Context.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("agent %s context: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
 }
@@ -2,14 +2,20 @@ package config
 
 import (
 	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/usage"
 )
 
 type WorkspaceConfig struct {
-	Name         string                      `yaml:"name"`
-	Scope        string                      `yaml:"scope"`
-	Planner      executors.YafaiPlanner      `yaml:"planner,omitempty"`
-	Orchestrator executors.YafaiOrchestrator `yaml:"orchestrator,omitempty"`
-	Integrations []string                    `yaml:"integrations,omitempty"`
-	VectorStore  string                      `yaml:"vector_store,omitempty"`
-	Bridge       string                      `yaml:"bridge"`
+	Name         string                              `yaml:"name"`
+	Scope        string                              `yaml:"scope"`
+	Planner      executors.YafaiPlanner              `yaml:"planner,omitempty"`
+	Orchestrator executors.YafaiOrchestrator         `yaml:"orchestrator,omitempty"`
+	Critic       executors.YafaiCritic               `yaml:"critic,omitempty"`
+	Providers    map[string]providers.ProviderConfig `yaml:"providers,omitempty"`
+	Pricing      usage.PriceTable                    `yaml:"pricing,omitempty"`
+	Budget       usage.Budget                        `yaml:"budget,omitempty"`
+	Integrations []string                            `yaml:"integrations,omitempty"`
+	VectorStore  string                              `yaml:"vector_store,omitempty"`
+	Bridge       string                              `yaml:"bridge"`
 }
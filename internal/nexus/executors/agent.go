@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
@@ -16,6 +17,7 @@ import (
 	skill "yafai/internal/bridge/skill"
 	"yafai/internal/nexus/assets/templates"
 	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/usage"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -125,7 +127,7 @@ func (a *YafaiAgent) SetupPrompt() (prompt string, err error) {
 
 	//history, err := a.getChatHistory()
 	if err != nil {
-		slog.Error("Parsing chat history failed %s", err.Error())
+		slog.Error("Parsing chat history failed", "error", err)
 	}
 	system_tmpl, err := template.New("AgentSystem").Parse(templates.AgentTemplate)
 	if err != nil {
@@ -147,9 +149,29 @@ func (a *YafaiAgent) SetupPrompt() (prompt string, err error) {
 	return system_prompt_string.String(), err
 }
 
+// Clone returns a copy of the agent without conversation state. The discovered
+// tools are copied as each Execute replaces them.
+func (a *YafaiAgent) Clone() *YafaiAgent {
+	clone := *a
+	clone.History = nil
+	clone.current = 0
+	clone.Tools = append([]providers.LLMTool(nil), a.Tools...)
+	clone.Actions = append([]*skill.Action(nil), a.Actions...)
+	return &clone
+}
+
+// UpdatePrompt fits the history to the context of the agent's models before a model
+// call, as set by its context config.
+func (a *YafaiAgent) UpdatePrompt(ctx context.Context, system string) error {
+	chain := modelChain(ModelRef{Provider: a.Provider, Model: a.Model, GenAIProvider: a.GenAIProvider}, a.Fallbacks)
+	window := newContextWindow(a.Name, chain, a.Context, a.Generation, system, a.Tools)
+	a.History, a.current = window.fit(ctx, a.History, a.current)
+	return nil
+}
+
 func (a *YafaiAgent) AppendChatRecord(From string, To string, Message string) error {
 	// Implement the logicto append a new chat record to the conversation history
-	record := &ChatRecord{From: From, To: To, Message: Message}
+	record := &ChatRecord{From: From, To: To, Message: Message, Role: chatRole(From, "agent", "assistant")}
 	a.History = append(a.History, record)
 	return nil
 }
@@ -285,7 +307,7 @@ func (a *YafaiAgent) DiscoverTools() (err error) {
 	a.Tools = tools
 	a.Actions = res.Actions
 	slog.Info("----------------------------------------------")
-	slog.Info("Tools discovered: ", a.Tools)
+	slog.Info("Tools discovered", "tools", a.Tools)
 	slog.Info("----------------------------------------------")
 	return err
 }
@@ -326,7 +348,9 @@ func toStructPB(value interface{}) (*structpb.Value, error) {
 	}
 }
 
-func (a *YafaiAgent) ExecuteTool(req ToolExecutionInput) (*skill.ExecuteActionResponse, error) {
+// ExecuteTool runs a skill action through the skill socket. The call is bounded by
+// ctx, so it stops when the request is cancelled or the agent times out.
+func (a *YafaiAgent) ExecuteTool(ctx context.Context, req ToolExecutionInput) (*skill.ExecuteActionResponse, error) {
 	// Helper: Convert any interface{} to *structpb.Value for protobuf
 	toStructPB := func(value interface{}) (*structpb.Value, error) {
 		switch v := value.(type) {
@@ -397,7 +421,7 @@ func (a *YafaiAgent) ExecuteTool(req ToolExecutionInput) (*skill.ExecuteActionRe
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
 	}
 	skillSocket := fmt.Sprintf("%s/.yafai/plugins/skill.sock", homeDir)
-	slog.Info("Connecting to socket:", skillSocket)
+	slog.Info("Connecting to socket", "path", skillSocket)
 
 	// gRPC connection
 	conn, err := grpc.Dial(fmt.Sprintf("unix:%s", skillSocket), grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -407,7 +431,7 @@ func (a *YafaiAgent) ExecuteTool(req ToolExecutionInput) (*skill.ExecuteActionRe
 	defer conn.Close()
 
 	client := skill.NewSkillServiceClient(conn)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// Build request
@@ -421,7 +445,7 @@ func (a *YafaiAgent) ExecuteTool(req ToolExecutionInput) (*skill.ExecuteActionRe
 	// Execute action
 	response, err := client.ExecuteAction(ctx, reqStruct)
 	if err != nil {
-		slog.Error("ExecuteAction failed:", err)
+		slog.Error("ExecuteAction failed", "error", err)
 		return nil, err
 	}
 
@@ -437,9 +461,12 @@ func extractAfter(input, key string) string {
 }
 
 func (a *YafaiAgent) Execute(ctx context.Context, req *YafaiRequest) (*YafaiResponse, error) {
+	ctx, cancel := withActorTimeout(ctx, a.Timeout)
+	defer cancel()
+
 	// Discover tools
 	if err := a.DiscoverTools(); err != nil {
-		slog.Error("Tool discovery failed: %v", err)
+		slog.Error("Tool discovery failed", "error", err)
 		return &YafaiResponse{Response: &providers.ResponseMessage{
 			Role:    "assistant",
 			Content: fmt.Sprintf("Internal error: could not load tools: %v", err),
@@ -447,141 +474,166 @@ func (a *YafaiAgent) Execute(ctx context.Context, req *YafaiRequest) (*YafaiResp
 	}
 
 	// Initialize history if needed
+	task := newChatRecord("orchestrator", "agent", providers.RequestMessage{Role: "user", Content: req.Request.Content, Image: req.Request.Image})
 	if req.Source == "orchestrator" {
-		a.History = []*ChatRecord{
-			{From: "orchestrator", To: "agent", Message: req.Request.Content},
-		}
+		a.History = []*ChatRecord{task}
 	} else {
-		a.History = append(a.History, &ChatRecord{
-			From: "orchestrator", To: "agent", Message: req.Request.Content,
-		})
+		a.History = append(a.History, task)
+	}
+	a.current = len(a.History) - 1
+
+	chain := modelChain(ModelRef{Provider: a.Provider, Model: a.Model, GenAIProvider: a.GenAIProvider}, a.Fallbacks)
+	maxSteps, maxToolCalls := a.limits()
+	trace := func(step int, format string, args ...interface{}) {
+		message := fmt.Sprintf("Agent %s step %d: %s", a.Name, step, fmt.Sprintf(format, args...))
+		slog.Info("Agent step", "agent", a.Name, "step", step, "trace", message)
+		if req.OnTrace != nil {
+			req.OnTrace(message)
+		}
 	}
 
-	provider := providers.GetProvider(a.Provider)
-	client := provider.Init()
+	var lastObservation string
+	toolCalls := 0
+	var model ModelRef
 
-	// Set retry parameters
-	const maxRetries = 5
-	for attempt := 1; attempt <= maxRetries; attempt++ {
+	// ReAct loop: reason, act with tools, observe, until an answer or a limit
+	for step := 1; step <= maxSteps; step++ {
 		// Build the system prompt: only relevant instructions for the agent
 		sysPrompt, err := a.SetupPrompt()
 		if err != nil {
-			slog.Error("Failed to set up system prompt: %v", err)
+			slog.Error("Failed to set up system prompt", "error", err)
 			return &YafaiResponse{Response: &providers.ResponseMessage{
 				Role:    "assistant",
 				Content: fmt.Sprintf("Error setting up system prompt: %v", err),
 			}}, err
 		}
 
-		// Include the message history in the conversation (not in the system prompt)
-		conversationHistory := a.BuildConversationHistory()
-
-		providerReq := []providers.RequestMessage{
-			{Role: "system", Content: sysPrompt},
-			{Role: "user", Content: fmt.Sprintf("Here's the context so far: %s", conversationHistory)},
+		// Fit the history to the model context, then send it as role tagged messages,
+		// including the tool calls and results of the earlier steps
+		if err := a.UpdatePrompt(ctx, sysPrompt); err != nil {
+			slog.Error("Failed to fit history to the model context", "agent", a.Name, "error", err)
 		}
+		providerRequest := providers.GenAIProviderRequest{
+			Model:            a.Model,
+			Messages:         historyMessages(sysPrompt, a.History),
+			Stream:           false,
+			Tools:            a.Tools,
+			GenerationConfig: a.Generation,
+		}
+		var onContent func(string)
+		if req.OnDelta != nil {
+			extractor := &ReactAnswerExtractor{}
+			onContent = func(fragment string) {
+				if delta := extractor.Write(fragment); delta != "" {
+					req.OnDelta(delta)
+				}
+			}
+		}
+		resp, answered, err := generate(ctx, a.Name, chain, providerRequest, onContent)
+		model = answered
 
-		// Generate response from the model
-		resp, err := provider.Generate(ctx, client, providers.GenAIProviderRequest{
-			Model:    a.Model,
-			Messages: providerReq,
-			Stream:   false,
-			Tools:    a.Tools,
-		})
+		// Hand the budget message back as the agent's answer so the orchestrator can wrap up
+		if errors.Is(err, usage.ErrBudgetExhausted) {
+			return &YafaiResponse{Response: &providers.ResponseMessage{
+				Role:    "assistant",
+				Content: err.Error(),
+			}}, nil
+		}
 
 		// Handle model errors
 		if err != nil {
-			slog.Error("Model error: %v", err)
+			slog.Error("Model error", "agent", a.Name, "model", model.String(), "error", err)
 			return &YafaiResponse{Response: &providers.ResponseMessage{
 				Role:    "assistant",
-				Content: fmt.Sprintf("Error with the model: %v", err),
-			}}, err
-		}
-
-		// Ensure the model response is valid
-		if resp == nil || len(resp.Choices) == 0 {
-			slog.Error("Empty response from the model")
-			break
+				Content: fmt.Sprintf("Error with the model: %s", providers.Describe(err)),
+			}, Model: model.String()}, err
 		}
 
 		// Extract message content
 		msg := resp.Choices[0].Message
 		content := strings.TrimSpace(msg.Content)
-		a.AppendChatRecord("agent", "user", content)
+
+		// Log the response
+		slog.Info("LLM Response", "message", msg)
 
 		// Handle clarification and final answer cases
 		if strings.Contains(content, "Query:") {
+			a.AppendChatRecord("agent", "user", content)
 			query := strings.TrimSpace(extractAfter(content, "Query:"))
+			trace(step, "asks for clarification")
 			return &YafaiResponse{Response: &providers.ResponseMessage{
 				Role:    "assistant",
 				Content: query,
-			}}, nil
+			}, Model: model.String()}, nil
 		}
 
 		if strings.Contains(content, "Final Answer:") {
+			a.AppendChatRecord("agent", "user", content)
 			answer := strings.TrimSpace(extractAfter(content, "Final Answer:"))
+			trace(step, "final answer")
 			return &YafaiResponse{Response: &providers.ResponseMessage{
 				Role:    "assistant",
 				Content: answer,
-			}}, nil
+			}, Model: model.String()}, nil
 		}
 
-		// Log the response
-		slog.Info("LLM Response: %+v", msg)
+		if thought := extractThought(content); thought != "" {
+			trace(step, "thought: %s", thought)
+		}
 
-		// Handle tool invocation
+		// Handle tool invocation: run the calls of the step and send the results back
 		if len(msg.ToolCalls) > 0 {
-			call := msg.ToolCalls[0]
-			thought := fmt.Sprintf("Thought: I need to use tool: %s with input: %s", call.Function.Name, call.Function.Arguments)
-			slog.Info("Tool call: %s", thought)
-			a.AppendChatRecord("assistant", "log", thought)
-
-			action := fmt.Sprintf("Action: %s\nInput: %s", call.Function.Name, call.Function.Arguments)
-			a.AppendChatRecord("assistant", "tool", action)
+			if toolCalls >= maxToolCalls {
+				trace(step, "tool call limit of %d reached", maxToolCalls)
+				return a.stopped(fmt.Sprintf("the limit of %d tool calls", maxToolCalls), lastObservation, model), nil
+			}
 
-			// Prepare and validate input for the tool
-			input, err := a.ConvertToolCallToExecutionInput(call)
-			if err != nil {
-				return &YafaiResponse{Response: &providers.ResponseMessage{
-					Role:    "assistant",
-					Content: fmt.Sprintf("Error converting tool input: %v", err),
-				}}, err
+			calls := withToolCallIDs(msg.ToolCalls, step)
+			allowed := len(calls)
+			if remaining := maxToolCalls - toolCalls; allowed > remaining {
+				allowed = remaining
+			}
+			for _, call := range calls[:allowed] {
+				trace(step, "calling %s %s", call.Function.Name, call.Function.Arguments)
 			}
 
-			// Execute the tool and capture the observation
-			result, err := a.ExecuteTool(input)
-			if err != nil {
-				slog.Error("Tool execution failed: %v", err)
+			results := a.runToolCalls(ctx, calls[:allowed])
+			if ctx.Err() != nil {
 				return &YafaiResponse{Response: &providers.ResponseMessage{
 					Role:    "assistant",
-					Content: fmt.Sprintf("Error executing tool: %v", err),
-				}}, err
+					Content: fmt.Sprintf("Error executing tools: %s", providers.Describe(ctx.Err())),
+				}, Model: model.String()}, ctx.Err()
 			}
-
-			observation := strings.TrimSpace(result.Response)
-			a.AppendChatRecord("tool", "assistant", observation)
-
-			// Return the tool observation
-			return &YafaiResponse{Response: &providers.ResponseMessage{
-				Role:    "tool",
-				Content: observation,
-			}}, nil
+			// Every call needs a result, so the ones over the limit are answered with an error
+			for _, call := range calls[allowed:] {
+				results = append(results, ToolResult{Call: call, Err: fmt.Errorf("tool call limit of %d reached", maxToolCalls)})
+			}
+			toolCalls += allowed
+
+			// The calls and their results join the history together, so it never holds
+			// a call without a result
+			a.History = append(a.History, newChatRecord("agent", "tool", providers.RequestMessage{Role: "assistant", Content: msg.Content, ToolCalls: calls}))
+			for _, result := range results {
+				if result.Err != nil {
+					slog.Error("Tool execution failed", "agent", a.Name, "tool", result.Call.Function.Name, "id", result.Call.ID, "error", result.Err)
+					trace(step, "%s failed: %v", result.Call.Function.Name, result.Err)
+				} else {
+					trace(step, "%s returned %d characters", result.Call.Function.Name, len(result.Output))
+					lastObservation = result.Output
+				}
+				a.History = append(a.History, newChatRecord("tool", "agent", result.Message()))
+			}
+			continue
 		}
 
-		// If no tool was invoked, ask for clarification or stop
-		if attempt == maxRetries {
-			return &YafaiResponse{Response: &providers.ResponseMessage{
-				Role:    "assistant",
-				Content: "I’ve tried several times but still need more details. Could you clarify or provide additional information?",
-			}}, nil
-		}
+		// Neither a tool call nor an answer: keep the reasoning and ask the model to go on
+		trace(step, "no action or answer, asking the model to continue")
+		a.AppendChatRecord("agent", "user", content)
+		a.AppendChatRecord("workspace", "agent", "Continue: call a tool, ask a Query: or reply with Final Answer: followed by your answer.")
 	}
 
-	// Fallback message if all retries failed
-	return &YafaiResponse{Response: &providers.ResponseMessage{
-		Role:    "assistant",
-		Content: "I’m unable to complete the task. Please provide more details.",
-	}}, nil
+	trace(maxSteps, "step limit of %d reached", maxSteps)
+	return a.stopped(fmt.Sprintf("the limit of %d steps", maxSteps), lastObservation, model), nil
 }
 
 // BuildSystemPrompt constructs the system prompt with agent instructions (no history)
@@ -589,18 +641,3 @@ func (a *YafaiAgent) BuildSystemPrompt() string {
 	// Static instructions for the system prompt (no history here)
 	return "You are a helpful assistant. Please assist the user in completing the requested task. Ask for clarification when needed."
 }
-
-// BuildConversationHistory concatenates chat history for use as context
-func (a *YafaiAgent) BuildConversationHistory() string {
-	var historyBuilder strings.Builder
-	for _, record := range a.History {
-		if record.From == "orchestrator" {
-			historyBuilder.WriteString(fmt.Sprintf("User: %s\n", record.Message))
-		} else if record.From == "agent" {
-			historyBuilder.WriteString(fmt.Sprintf("Assistant: %s\n", record.Message))
-		} else if record.From == "tool" {
-			historyBuilder.WriteString(fmt.Sprintf("Tool: %s\n", record.Message))
-		}
-	}
-	return historyBuilder.String()
-}
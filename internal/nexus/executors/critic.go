@@ -1 +1,125 @@
 package executors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+
+	"yafai/internal/nexus/assets/templates"
+	"yafai/internal/nexus/providers"
+)
+
+// defaultCriticRevisions caps the answer revisions asked by critics that do not set
+// max_revisions.
+const defaultCriticRevisions = 2
+
+// passVerdict is returned when there is nothing to review with.
+var passVerdict = &CriticVerdict{Verdict: VerdictPass}
+
+func (v *CriticVerdict) validate() error {
+	switch v.Verdict {
+	case VerdictPass:
+	case VerdictRevise, VerdictFail:
+		if strings.TrimSpace(v.Feedback) == "" {
+			return fmt.Errorf("a %s verdict needs feedback", v.Verdict)
+		}
+	default:
+		return fmt.Errorf("unknown verdict %q, expected pass, revise or fail", v.Verdict)
+	}
+	return nil
+}
+
+// Enabled reports whether a critic is configured.
+func (c *YafaiCritic) Enabled() bool {
+	return c != nil && c.Model != ""
+}
+
+// Revisions is the number of answer revisions the critic may ask for per request.
+func (c *YafaiCritic) Revisions() int {
+	if c.MaxRevisions <= 0 {
+		return defaultCriticRevisions
+	}
+	return c.MaxRevisions
+}
+
+func (c *YafaiCritic) SetupPrompt(goal string, scope string) (string, error) {
+	if c.SysPrompt != "" {
+		return c.SysPrompt, nil
+	}
+	tmpl, err := template.New("critic").Parse(templates.CriticPrompt)
+	if err != nil {
+		return "", err
+	}
+	var prompt bytes.Buffer
+	if err := tmpl.Execute(&prompt, CriticPromptStruct{Goal: goal, Scope: scope, Rubric: c.Rubric}); err != nil {
+		return "", err
+	}
+	return prompt.String(), nil
+}
+
+// Review asks the critic for a verdict on subject, the response under review.
+func (c *YafaiCritic) Review(ctx context.Context, goal string, scope string, request string, subject string) (*CriticVerdict, error) {
+	ctx, cancel := withActorTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	prompt, err := c.SetupPrompt(goal, scope)
+	if err != nil {
+		return nil, err
+	}
+	req := providers.GenAIProviderRequest{
+		Model: c.Model,
+		Messages: []providers.RequestMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: fmt.Sprintf("User request:\n%s\n\n%s", request, subject)},
+		},
+		GenerationConfig: c.Generation,
+	}
+	chain := modelChain(ModelRef{Provider: c.Provider, Model: c.Model, GenAIProvider: c.GenAIProvider}, c.Fallbacks)
+	var verdict CriticVerdict
+	_, model, err := generateStructured(ctx, "critic", chain, req, "critic_verdict", &verdict, nil)
+	if err != nil {
+		slog.Error("Critic generation failed", "model", model.String(), "error", err)
+		return nil, err
+	}
+	slog.Info("Critic verdict", "model", model.String(), "verdict", verdict.Verdict, "feedback", verdict.Feedback)
+	return &verdict, nil
+}
+
+// ReviewAgent has the critic judge the output of an agent for task. Without a critic,
+// or when it skips agents, every output passes.
+func (o *YafaiOrchestrator) ReviewAgent(ctx context.Context, request string, agent string, task string, output string) (*CriticVerdict, error) {
+	if !o.Critic.Enabled() || o.Critic.SkipAgents {
+		return passVerdict, nil
+	}
+	subject := fmt.Sprintf("Output of agent %s for the task: %s\n\n%s", agent, task, output)
+	return o.Critic.Review(ctx, o.Goal, o.Scope, request, subject)
+}
+
+// Parse has the critic decide whether answer achieves the goal for request, given
+// the agent outputs it draws on. Without a critic every answer passes.
+func (o *YafaiOrchestrator) Parse(ctx context.Context, request string, answer string, agentLogs []AgentLog) (*CriticVerdict, error) {
+	if !o.Critic.Enabled() {
+		return passVerdict, nil
+	}
+	var subject strings.Builder
+	fmt.Fprintf(&subject, "Final answer to the user:\n%s", answer)
+	if len(agentLogs) > 0 {
+		subject.WriteString("\n\nAgent outputs the answer is based on:")
+		for _, log := range agentLogs {
+			fmt.Fprintf(&subject, "\n\nagent: %s\ntask: %s\noutput: %s", log.Name, log.Task, log.Response)
+		}
+	}
+	return o.Critic.Review(ctx, o.Goal, o.Scope, request, subject.String())
+}
+
+// CriticFeedback is the request that hands a verdict other than pass back to the
+// orchestrator.
+func CriticFeedback(verdict *CriticVerdict, subject string) string {
+	if verdict.Verdict == VerdictFail {
+		return fmt.Sprintf("Critic: %s cannot meet the goal: %s", subject, verdict.Feedback)
+	}
+	return fmt.Sprintf("Critic: %s needs revision: %s", subject, verdict.Feedback)
+}
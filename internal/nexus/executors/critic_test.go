@@ -0,0 +1,173 @@
+package executors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"yafai/internal/nexus/providers"
+)
+
+// criticTeam is an orchestrator whose critic, backed by provider, reviews answers
+// and agent outputs.
+func criticTeam(provider *scriptedProvider) *YafaiOrchestrator {
+	return &YafaiOrchestrator{Goal: "answer questions about pictures", Scope: "pictures only", Critic: &YafaiCritic{Model: "judge", GenAIProvider: provider, Rubric: "Name the animal."}}
+}
+
+func TestCriticVerdicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  CriticVerdict
+	}{
+		{"approve", `{"verdict":"pass","feedback":""}`, CriticVerdict{Verdict: VerdictPass}},
+		{"revise", `{"verdict":"revise","feedback":"name the breed"}`, CriticVerdict{Verdict: VerdictRevise, Feedback: "name the breed"}},
+		{"reject", `{"verdict":"fail","feedback":"there is no picture"}`, CriticVerdict{Verdict: VerdictFail, Feedback: "there is no picture"}},
+		{"fenced", "```json\n{\"verdict\":\"pass\"}\n```", CriticVerdict{Verdict: VerdictPass}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{replies: []string{tt.reply}, schema: true}
+			o := criticTeam(provider)
+
+			verdict, err := o.Parse(context.Background(), "what is this?", "a cat", []AgentLog{{Name: "looker", Task: "look", Response: "a tabby cat"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *verdict != tt.want {
+				t.Errorf("verdict = %+v, want %+v", *verdict, tt.want)
+			}
+			if provider.calls() != 1 {
+				t.Errorf("critic called %d times, want 1", provider.calls())
+			}
+
+			req := provider.requests[0]
+			if format, ok := req.ResponseFormat.(*providers.ResponseFormat); !ok || format.JSONSchema == nil || format.JSONSchema.Name != "critic_verdict" {
+				t.Errorf("response format = %+v, want the verdict schema", req.ResponseFormat)
+			}
+			if system := req.Messages[0].Content; !strings.Contains(system, "answer questions about pictures") || !strings.Contains(system, "Name the animal.") {
+				t.Errorf("system prompt = %q, want the goal and rubric", system)
+			}
+			subject := req.Messages[1].Content
+			for _, want := range []string{"User request:\nwhat is this?", "Final answer to the user:\na cat", "agent: looker\ntask: look\noutput: a tabby cat"} {
+				if !strings.Contains(subject, want) {
+					t.Errorf("review request = %q, want it to contain %q", subject, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCriticRepairsMalformedVerdicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		invalid string
+		problem string
+	}{
+		{"not JSON", "Looks good to me.", "JSON"},
+		{"unknown verdict", `{"verdict":"maybe","feedback":""}`, `unknown verdict "maybe"`},
+		{"revise without feedback", `{"verdict":"revise","feedback":" "}`, "a revise verdict needs feedback"},
+		{"fail without feedback", `{"verdict":"fail"}`, "a fail verdict needs feedback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{replies: []string{tt.invalid, `{"verdict":"revise","feedback":"be brief"}`}}
+			o := criticTeam(provider)
+
+			verdict, err := o.Parse(context.Background(), "what is this?", "a cat", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verdict.Verdict != VerdictRevise || verdict.Feedback != "be brief" {
+				t.Errorf("verdict = %+v, want the repaired one", verdict)
+			}
+			if provider.calls() != 2 {
+				t.Fatalf("critic called %d times, want 2", provider.calls())
+			}
+			repair := provider.requests[1].Messages
+			if last := repair[len(repair)-1]; last.Role != "user" || !strings.Contains(last.Content, tt.problem) {
+				t.Errorf("repair request = %q, want it to name %q", last.Content, tt.problem)
+			}
+		})
+	}
+}
+
+func TestCriticGivesUpOnMalformedVerdicts(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{`{"verdict":"maybe"}`}}
+	o := criticTeam(provider)
+
+	verdict, err := o.ReviewAgent(context.Background(), "what is this?", "looker", "look", "a cat")
+	if err == nil {
+		t.Fatalf("verdict = %+v, want an error", verdict)
+	}
+	if provider.calls() != maxRepairs+1 {
+		t.Errorf("critic called %d times, want %d", provider.calls(), maxRepairs+1)
+	}
+}
+
+func TestCriticReviewsAgentOutputs(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{`{"verdict":"revise","feedback":"look closer"}`}}
+	o := criticTeam(provider)
+
+	verdict, err := o.ReviewAgent(context.Background(), "what is this?", "looker", "look at the picture", "an animal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict.Verdict != VerdictRevise {
+		t.Errorf("verdict = %+v, want revise", verdict)
+	}
+	if subject := provider.requests[0].Messages[1].Content; !strings.Contains(subject, "Output of agent looker for the task: look at the picture\n\nan animal") {
+		t.Errorf("review request = %q, want the agent output", subject)
+	}
+	if feedback := CriticFeedback(verdict, "the output of looker"); feedback != "Critic: the output of looker needs revision: look closer" {
+		t.Errorf("feedback = %q", feedback)
+	}
+}
+
+func TestCriticOff(t *testing.T) {
+	tests := []struct {
+		name   string
+		critic *YafaiCritic
+		answer bool
+		agent  bool
+	}{
+		{"no critic", nil, false, false},
+		{"no model", &YafaiCritic{}, false, false},
+		{"skip agents", &YafaiCritic{Model: "judge", SkipAgents: true}, true, false},
+		{"on", &YafaiCritic{Model: "judge"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{replies: []string{`{"verdict":"fail","feedback":"no"}`}}
+			if tt.critic != nil {
+				tt.critic.GenAIProvider = provider
+			}
+			o := &YafaiOrchestrator{Critic: tt.critic}
+
+			verdict, err := o.Parse(context.Background(), "q", "a", nil)
+			if err != nil || (verdict.Verdict == VerdictFail) != tt.answer {
+				t.Errorf("answer verdict = %+v, %v, reviewed %v", verdict, err, tt.answer)
+			}
+			verdict, err = o.ReviewAgent(context.Background(), "q", "looker", "look", "out")
+			if err != nil || (verdict.Verdict == VerdictFail) != tt.agent {
+				t.Errorf("agent verdict = %+v, %v, reviewed %v", verdict, err, tt.agent)
+			}
+		})
+	}
+}
+
+func TestCriticSettings(t *testing.T) {
+	if got := (&YafaiCritic{}).Revisions(); got != defaultCriticRevisions {
+		t.Errorf("default revisions = %d, want %d", got, defaultCriticRevisions)
+	}
+	if got := (&YafaiCritic{MaxRevisions: 5}).Revisions(); got != 5 {
+		t.Errorf("revisions = %d, want 5", got)
+	}
+	prompt, err := (&YafaiCritic{SysPrompt: "Be strict."}).SetupPrompt("goal", "scope")
+	if err != nil || prompt != "Be strict." {
+		t.Errorf("prompt = %q, %v, want the configured one", prompt, err)
+	}
+	if feedback := CriticFeedback(&CriticVerdict{Verdict: VerdictFail, Feedback: "off topic"}, "your answer"); feedback != "Critic: your answer cannot meet the goal: off topic" {
+		t.Errorf("feedback = %q", feedback)
+	}
+}
@@ -0,0 +1,161 @@
+package executors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultParallelism caps the plan tasks run at once for orchestrators that do not
+// set parallelism.
+const defaultParallelism = 4
+
+// UnmarshalJSON reads a list of task IDs, a single ID or a comma separated string.
+func (ids *TaskIDs) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '"' {
+		var list []string
+		if err := json.Unmarshal(trimmed, &list); err != nil {
+			return err
+		}
+		*ids = cleanTaskIDs(list)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(trimmed, &text); err != nil {
+		return err
+	}
+	*ids = cleanTaskIDs(strings.Split(text, ","))
+	return nil
+}
+
+func cleanTaskIDs(list []string) TaskIDs {
+	var ids TaskIDs
+	for _, id := range list {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// planGraph is a plan as a dependency graph of its tasks, by position in the plan.
+type planGraph struct {
+	tasks []*PlannerTask
+	// needs lists the tasks each task depends on and dependants the tasks depending on it.
+	needs      [][]int
+	dependants [][]int
+}
+
+// numberTasks gives the tasks without an ID the ID of their position, t1 for the first.
+func numberTasks(tasks []*PlannerTask) {
+	for i, task := range tasks {
+		if task != nil && strings.TrimSpace(task.ID) == "" {
+			task.ID = fmt.Sprintf("t%d", i+1)
+		}
+	}
+}
+
+// taskIndex maps the ID of each task to its position, that of the first task for an
+// ID used more than once.
+func taskIndex(tasks []*PlannerTask) map[string]int {
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		if task == nil {
+			continue
+		}
+		if _, taken := index[task.ID]; !taken {
+			index[task.ID] = i
+		}
+	}
+	return index
+}
+
+// linkTasks links the tasks to the tasks they depend on. It does not check the plan:
+// dependencies on unknown tasks or on the task itself are left out, and cycles are
+// kept. validatePlan reports all of them.
+func linkTasks(tasks []*PlannerTask) *planGraph {
+	index := taskIndex(tasks)
+	graph := &planGraph{tasks: make([]*PlannerTask, len(tasks)), needs: make([][]int, len(tasks)), dependants: make([][]int, len(tasks))}
+	for i, task := range tasks {
+		if task == nil {
+			graph.tasks[i] = &PlannerTask{ID: fmt.Sprintf("t%d", i+1)}
+			continue
+		}
+		graph.tasks[i] = task
+		for _, id := range task.DependsOn {
+			if j, known := index[id]; known && j != i {
+				graph.needs[i] = append(graph.needs[i], j)
+				graph.dependants[j] = append(graph.dependants[j], i)
+			}
+		}
+	}
+	return graph
+}
+
+// cycle returns the IDs along a dependency cycle, the first ID repeated at the end,
+// or nil when the graph has none.
+func (g *planGraph) cycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.tasks))
+	var path []int
+	var found []string
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = visiting
+		path = append(path, i)
+		for _, j := range g.needs[i] {
+			switch state[j] {
+			case visiting:
+				for k := len(path) - 1; k >= 0; k-- {
+					if path[k] == j {
+						for _, p := range path[k:] {
+							found = append(found, g.tasks[p].ID)
+						}
+						found = append(found, g.tasks[j].ID)
+						return true
+					}
+				}
+			case unvisited:
+				if visit(j) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return false
+	}
+	for i := range g.tasks {
+		if state[i] == unvisited && visit(i) {
+			return found
+		}
+	}
+	return nil
+}
+
+// release marks task i finished and returns the dependants it leaves ready to start.
+func (g *planGraph) release(waiting []int, i int) []int {
+	var ready []int
+	for _, j := range g.dependants[i] {
+		waiting[j]--
+		if waiting[j] == 0 {
+			ready = append(ready, j)
+		}
+	}
+	return ready
+}
+
+// newPlanGraph builds the graph of a plan the team can run, after checking it with
+// validatePlan. Plans saved before tasks had IDs get them here.
+func newPlanGraph(tasks []*PlannerTask, team map[string]bool) (*planGraph, error) {
+	if violations := validatePlan(tasks, team); len(violations) > 0 {
+		return nil, &PlanValidationError{Violations: violations}
+	}
+	return linkTasks(tasks), nil
+}
@@ -0,0 +1,228 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	skill "yafai/internal/bridge/skill"
+	"yafai/internal/nexus/providers"
+)
+
+func TestTaskIDsUnmarshal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  TaskIDs
+	}{
+		{`["t1", " t2 ", ""]`, TaskIDs{"t1", "t2"}},
+		{`"t1"`, TaskIDs{"t1"}},
+		{`"t1, t2,"`, TaskIDs{"t1", "t2"}},
+		{`""`, nil},
+		{`null`, nil},
+	}
+	for _, tt := range tests {
+		var ids TaskIDs
+		if err := json.Unmarshal([]byte(tt.input), &ids); err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+			t.Errorf("%s = %q, want %q", tt.input, ids, tt.want)
+		}
+	}
+}
+
+func TestPlanGraphCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []*PlannerTask
+		want  []string
+	}{
+		{"chain", []*PlannerTask{planTask("t1", "a"), planTask("t2", "a", "t1"), planTask("t3", "a", "t2")}, nil},
+		{"diamond", []*PlannerTask{planTask("t1", "a"), planTask("t2", "a", "t1"), planTask("t3", "a", "t1"), planTask("t4", "a", "t2", "t3")}, nil},
+		{"self dependency left out", []*PlannerTask{planTask("t1", "a", "t1")}, nil},
+		{"pair", []*PlannerTask{planTask("t1", "a", "t2"), planTask("t2", "a", "t1")}, []string{"t1", "t2", "t1"}},
+		{"behind a valid task", []*PlannerTask{planTask("t1", "a"), planTask("t2", "a", "t1", "t4"), planTask("t3", "a", "t2"), planTask("t4", "a", "t3")}, []string{"t2", "t4", "t3", "t2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linkTasks(tt.tasks).cycle(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("cycle = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPlanGraphRejectsInvalidPlans(t *testing.T) {
+	_, err := newPlanGraph([]*PlannerTask{planTask("t1", "a", "t2"), planTask("t2", "a", "t1")}, map[string]bool{"a": true})
+	var invalid *PlanValidationError
+	if !errors.As(err, &invalid) || invalid.Violations[0].Rule != RuleCycle {
+		t.Errorf("error = %v, want the cycle reported", err)
+	}
+}
+
+// stepLog records when the plan steps of agents start and end.
+type stepLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *stepLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+// at is the position of event in the log, -1 when it is not there.
+func (l *stepLog) at(event string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, e := range l.events {
+		if e == event {
+			return i
+		}
+	}
+	return -1
+}
+
+// stepProvider answers for one agent after a delay, logging the step, and keeps the
+// last request it was sent.
+type stepProvider struct {
+	scriptedProvider
+	agent string
+	log   *stepLog
+	delay time.Duration
+}
+
+func (p *stepProvider) Generate(ctx context.Context, client *http.Client, req providers.GenAIProviderRequest) (*providers.GenAIProviderResponse, error) {
+	p.log.add("start " + p.agent)
+	defer p.log.add("end " + p.agent)
+	time.Sleep(p.delay)
+	return p.scriptedProvider.Generate(ctx, client, req)
+}
+
+func (p *stepProvider) request() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	messages := p.requests[len(p.requests)-1].Messages
+	return messages[len(messages)-1].Content
+}
+
+// planTeam is an orchestrator with an agent answering for each name.
+func planTeam(log *stepLog, names ...string) (*YafaiOrchestrator, map[string]*stepProvider) {
+	o := &YafaiOrchestrator{Team: map[string]*YafaiAgent{}}
+	steps := map[string]*stepProvider{}
+	for _, name := range names {
+		provider := &stepProvider{scriptedProvider: scriptedProvider{replies: []string{"Final Answer: output of " + name}}, agent: name, log: log, delay: 50 * time.Millisecond}
+		steps[name] = provider
+		o.Team[name] = &YafaiAgent{Name: name, Model: "m", GenAIProvider: provider, Tools: []providers.LLMTool{{}}, SkillClient: skill.NewSkillServiceClient(nil)}
+	}
+	return o, steps
+}
+
+func TestRunPlanGraphOrder(t *testing.T) {
+	log := &stepLog{}
+	o, steps := planTeam(log, "a", "b", "c", "d")
+	// a and b run side by side, c needs both and d needs c
+	tasks := []*PlannerTask{planTask("t1", "a"), planTask("t2", "b"), planTask("t3", "c", "t1", "t2"), planTask("t4", "d", "t3")}
+	graph, err := newPlanGraph(tasks, o.teamSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := o.runPlanGraph(context.Background(), graph, "the goal", nil, func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if log.at("start b") > log.at("end a") || log.at("start a") > log.at("end b") {
+		t.Errorf("independent tasks did not run side by side: %v", log.events)
+	}
+	if log.at("start c") < log.at("end a") || log.at("start c") < log.at("end b") || log.at("start d") < log.at("end c") {
+		t.Errorf("a task started before the tasks it depends on ended: %v", log.events)
+	}
+	for i, result := range results {
+		if result.Task != tasks[i] || result.Err != nil || result.Output != "output of "+tasks[i].Agent {
+			t.Errorf("result %d = %+v", i, result)
+		}
+	}
+	request := steps["c"].request()
+	if !strings.Contains(request, "Task t1 (a): output of a") || !strings.Contains(request, "Task t2 (b): output of b") {
+		t.Errorf("request of t3 lacks the outputs it depends on: %q", request)
+	}
+}
+
+func TestRunPlanGraphParallelism(t *testing.T) {
+	log := &stepLog{}
+	o, _ := planTeam(log, "a", "b")
+	o.Parallelism = 1
+	graph, err := newPlanGraph([]*PlannerTask{planTask("t1", "a"), planTask("t2", "b")}, o.teamSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.runPlanGraph(context.Background(), graph, "", nil, func(string) {}); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(log.events) != "[start a end a start b end b]" {
+		t.Errorf("steps = %v, want one at a time in plan order", log.events)
+	}
+}
+
+func TestRunPlanGraphFailureSkipsDependants(t *testing.T) {
+	log := &stepLog{}
+	o, steps := planTeam(log, "a", "b", "c", "d")
+	steps["a"].err = errors.New("down")
+	// t3 needs the failed t1 and t4 needs t3; t2 is another branch
+	tasks := []*PlannerTask{planTask("t1", "a"), planTask("t2", "b"), planTask("t3", "c", "t1"), planTask("t4", "d", "t3")}
+	graph, err := newPlanGraph(tasks, o.teamSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traces := &stepLog{}
+	results, err := o.runPlanGraph(context.Background(), graph, "", nil, traces.add)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0].Err == nil {
+		t.Error("failed task has no error")
+	}
+	if results[1].Err != nil || results[1].Output != "output of b" {
+		t.Errorf("other branch = %+v, want it to run", results[1])
+	}
+	for _, i := range []int{2, 3} {
+		if results[i].Err == nil || !strings.Contains(results[i].Err.Error(), "skipped") {
+			t.Errorf("task %s error = %v, want it skipped", tasks[i].ID, results[i].Err)
+		}
+	}
+	if log.at("start c") >= 0 || log.at("start d") >= 0 {
+		t.Errorf("skipped tasks ran: %v", log.events)
+	}
+	if traces.at("Plan task t3 skipped: t1 failed") < 0 {
+		t.Errorf("traces = %q, want the skip reported", traces.events)
+	}
+}
+
+func TestRunPlanGraphCancelled(t *testing.T) {
+	log := &stepLog{}
+	o, _ := planTeam(log, "a", "b")
+	graph, err := newPlanGraph([]*PlannerTask{planTask("t1", "a"), planTask("t2", "b", "t1")}, o.teamSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := o.runPlanGraph(ctx, graph, "", nil, func(string) {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want cancelled", err)
+	}
+	if log.at("start b") >= 0 {
+		t.Errorf("dependant started after the cancel: %v", log.events)
+	}
+}
@@ -0,0 +1,156 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/usage"
+)
+
+// defaultProviders holds the providers built for actors created outside a workspace,
+// by name, so their calls share a client as those of workspace actors do.
+var defaultProviders sync.Map
+
+// resolveProvider returns the provider attached to an actor at config load, building
+// it from the registry with default settings for actors created outside a workspace.
+func resolveProvider(attached providers.GenAIProvider, name string) (providers.GenAIProvider, error) {
+	if attached != nil {
+		return attached, nil
+	}
+	if provider, ok := defaultProviders.Load(name); ok {
+		return provider.(providers.GenAIProvider), nil
+	}
+	provider, err := providers.GetProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	shared, _ := defaultProviders.LoadOrStore(name, provider)
+	return shared.(providers.GenAIProvider), nil
+}
+
+// withActorTimeout bounds an Execute call by the actor's configured timeout. A zero
+// timeout leaves the caller's deadline, if any, in place.
+func withActorTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// modelChain lists the primary model of an actor followed by its fallbacks.
+func modelChain(primary ModelRef, fallbacks []*ModelRef) []ModelRef {
+	chain := []ModelRef{primary}
+	for _, fallback := range fallbacks {
+		if fallback != nil {
+			chain = append(chain, *fallback)
+		}
+	}
+	return chain
+}
+
+// shouldFallback reports whether a failed model is worth replacing with the next one
+// in the chain: transient failures that outlasted the provider retries, and models
+// the provider no longer serves.
+func shouldFallback(err error) bool {
+	return providers.Retryable(err) || errors.Is(err, providers.ErrModelUnavailable)
+}
+
+// noJSONSchema remembers the models that rejected a `json_schema` response format.
+var noJSONSchema sync.Map
+
+func usesJSONSchema(req providers.GenAIProviderRequest) bool {
+	format, ok := req.ResponseFormat.(*providers.ResponseFormat)
+	return ok && format.Type == "json_schema"
+}
+
+// schemaRejected reports whether err is a model refusing the `json_schema` response
+// format. Other invalid requests, such as an exceeded context or a bad image, leave
+// the schema support of the model alone.
+func schemaRejected(err error) bool {
+	if !errors.Is(err, providers.ErrInvalidRequest) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "response_format") || strings.Contains(message, "json_schema")
+}
+
+func supportsJSONSchema(provider providers.GenAIProvider, ref ModelRef) bool {
+	if _, rejected := noJSONSchema.Load(ref.String()); rejected {
+		return false
+	}
+	return provider.Capabilities().JSONSchema
+}
+
+// call makes a single model call, streaming to onContent when it is set. streamed
+// reports whether any content reached onContent.
+func call(ctx context.Context, provider providers.GenAIProvider, client *http.Client, req providers.GenAIProviderRequest, onContent func(string)) (resp *providers.GenAIProviderResponse, streamed bool, err error) {
+	if onContent == nil {
+		resp, err = provider.Generate(ctx, client, req)
+		return resp, false, err
+	}
+	resp, err = generateStreaming(ctx, provider, client, req, func(fragment string) {
+		streamed = true
+		onContent(fragment)
+	})
+	return resp, streamed, err
+}
+
+// generate runs req against each model of chain in turn until one answers. The model
+// is moved on only for errors accepted by shouldFallback, and never once streamed
+// content has reached onContent. It returns the completion and the model that
+// produced it, whose usage is recorded against actor. Every call is first checked
+// against the workspace budget. A nil onContent makes a blocking call. Requests for
+// a JSON schema fall back to JSON mode on models that cannot take one.
+func generate(ctx context.Context, actor string, chain []ModelRef, req providers.GenAIProviderRequest, onContent func(string)) (*providers.GenAIProviderResponse, ModelRef, error) {
+	var lastErr error
+	for i, ref := range chain {
+		if err := usage.Check(ctx); err != nil {
+			slog.Warn("Model call blocked by budget", "actor", actor, "model", ref.String(), "error", err)
+			return nil, ref, err
+		}
+		provider, err := resolveProvider(ref.GenAIProvider, ref.Provider)
+		if err != nil {
+			return nil, ref, err
+		}
+		client := provider.Init()
+		req.Model = ref.Model
+
+		callReq := req
+		if usesJSONSchema(callReq) && !supportsJSONSchema(provider, ref) {
+			callReq.ResponseFormat = &providers.ResponseFormat{Type: "json_object"}
+		}
+		resp, streamed, err := call(ctx, provider, client, callReq, onContent)
+		if schemaRejected(err) && usesJSONSchema(callReq) && !streamed {
+			// Not every model of a provider accepts schemas; remember and ask again in JSON mode.
+			slog.Warn("Model rejected the JSON schema, retrying in JSON mode", "model", ref.String(), "error", err)
+			noJSONSchema.Store(ref.String(), true)
+			callReq.ResponseFormat = &providers.ResponseFormat{Type: "json_object"}
+			resp, streamed, err = call(ctx, provider, client, callReq, onContent)
+		}
+		// Workspace providers share their client and keep it open
+		provider.Close(client)
+		if err == nil && (resp == nil || len(resp.Choices) == 0) {
+			err = &providers.ProviderError{Provider: ref.Provider, Kind: providers.ErrMalformedResponse, Message: "empty completion"}
+		}
+		if err == nil {
+			usage.Record(ctx, actor, ref.String(), resp.Usage)
+			if i > 0 {
+				slog.Info("Fallback model answered", "model", ref.String(), "primary", chain[0].String())
+			}
+			return resp, ref, nil
+		}
+
+		lastErr = err
+		if streamed || !shouldFallback(err) || ctx.Err() != nil || i == len(chain)-1 {
+			return nil, ref, err
+		}
+		slog.Warn("Model failed, trying fallback", "model", ref.String(), "fallback", chain[i+1].String(), "error", err)
+	}
+	return nil, ModelRef{}, lastErr
+}
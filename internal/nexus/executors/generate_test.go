@@ -0,0 +1,149 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/usage"
+)
+
+func failing(kind error) *scriptedProvider {
+	return &scriptedProvider{err: &providers.ProviderError{Provider: "test", Kind: kind}}
+}
+
+func TestGenerateFallbackChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary *scriptedProvider
+		// wantFallback is set when the fallback model is to answer.
+		wantFallback bool
+		wantErr      error
+	}{
+		{"primary answers", &scriptedProvider{replies: []string{"primary"}}, false, nil},
+		{"server error after retries", failing(providers.ErrServer), true, nil},
+		{"rate limited after retries", failing(providers.ErrRateLimited), true, nil},
+		{"timeout", failing(providers.ErrTimeout), true, nil},
+		{"model no longer served", failing(providers.ErrModelUnavailable), true, nil},
+		{"auth failure stops the chain", failing(providers.ErrAuthFailed), false, providers.ErrAuthFailed},
+		{"invalid request stops the chain", failing(providers.ErrInvalidRequest), false, providers.ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := &scriptedProvider{replies: []string{"fallback"}}
+			chain := []ModelRef{
+				{Provider: "groq", Model: "big", GenAIProvider: tt.primary},
+				{Provider: "ollama", Model: "small", GenAIProvider: fallback},
+			}
+
+			resp, model, err := generate(context.Background(), "agent", chain, providers.GenAIProviderRequest{}, nil)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || model != chain[0] {
+					t.Errorf("generate = %v from %s, want %v from the primary", err, model, tt.wantErr)
+				}
+				if fallback.calls() != 0 {
+					t.Errorf("fallback called %d times after a final error", fallback.calls())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, wantModel := "primary", chain[0]
+			if tt.wantFallback {
+				want, wantModel = "fallback", chain[1]
+			}
+			if got := resp.Choices[0].Message.Content; got != want || model != wantModel {
+				t.Errorf("answer %q from %s, want %q from %s", got, model, want, wantModel)
+			}
+			if fallback.calls() > 0 && fallback.requests[0].Model != "small" {
+				t.Errorf("fallback asked for model %q", fallback.requests[0].Model)
+			}
+		})
+	}
+}
+
+func TestGenerateFallbackOrder(t *testing.T) {
+	first, second, third := failing(providers.ErrServer), failing(providers.ErrModelUnavailable), &scriptedProvider{replies: []string{"third"}}
+	chain := []ModelRef{{Provider: "a", Model: "1", GenAIProvider: first}, {Provider: "b", Model: "2", GenAIProvider: second}, {Provider: "c", Model: "3", GenAIProvider: third}}
+	_, model, err := generate(context.Background(), "agent", chain, providers.GenAIProviderRequest{}, nil)
+	if err != nil || model.String() != "c/3" {
+		t.Fatalf("generate = %v from %s, want the third model", err, model)
+	}
+	if first.calls() != 1 || second.calls() != 1 || third.calls() != 1 {
+		t.Errorf("calls = %d, %d, %d, want each model once in order", first.calls(), second.calls(), third.calls())
+	}
+}
+
+func TestGenerateClosesClients(t *testing.T) {
+	primary, fallback := failing(providers.ErrServer), &scriptedProvider{replies: []string{"fallback"}}
+	chain := []ModelRef{{Provider: "a", Model: "1", GenAIProvider: primary}, {Provider: "b", Model: "2", GenAIProvider: fallback}}
+	for i := 0; i < 2; i++ {
+		if _, _, err := generate(context.Background(), "agent", chain, providers.GenAIProviderRequest{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []*scriptedProvider{primary, fallback} {
+		if p.opened != 2 || p.closed != p.opened {
+			t.Errorf("opened %d clients and closed %d, want each of 2 closed", p.opened, p.closed)
+		}
+	}
+}
+
+func TestGenerateLastModelError(t *testing.T) {
+	chain := []ModelRef{{Provider: "a", Model: "1", GenAIProvider: failing(providers.ErrServer)}, {Provider: "b", Model: "2", GenAIProvider: failing(providers.ErrRateLimited)}}
+	_, model, err := generate(context.Background(), "agent", chain, providers.GenAIProviderRequest{}, nil)
+	if !errors.Is(err, providers.ErrRateLimited) || model.String() != "b/2" {
+		t.Errorf("generate = %v from %s, want the error of the last model", err, model)
+	}
+}
+
+func TestGenerateCancelledStopsTheChain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	primary := &scriptedProvider{err: &providers.ProviderError{Provider: "a", Kind: providers.ErrTimeout, Err: context.Canceled}}
+	fallback := &scriptedProvider{replies: []string{"fallback"}}
+	chain := []ModelRef{{Provider: "a", Model: "1", GenAIProvider: primary}, {Provider: "b", Model: "2", GenAIProvider: fallback}}
+
+	if _, _, err := generate(ctx, "agent", chain, providers.GenAIProviderRequest{}, nil); err == nil {
+		t.Fatal("generate answered after the request was cancelled")
+	}
+	if fallback.calls() != 0 {
+		t.Errorf("fallback called %d times after the request was cancelled", fallback.calls())
+	}
+}
+
+func TestGenerateRecordsUsageOfTheAnsweringModel(t *testing.T) {
+	ledger := usage.NewLedger(nil, usage.Budget{})
+	ctx := usage.WithLedger(context.Background(), ledger, "conn_1")
+	fallback := &scriptedProvider{replies: []string{"fallback"}, usage: providers.ResponseUsage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}}
+	chain := []ModelRef{{Provider: "groq", Model: "big", GenAIProvider: failing(providers.ErrServer)}, {Provider: "ollama", Model: "small", GenAIProvider: fallback}}
+
+	if _, _, err := generate(ctx, "planner", chain, providers.GenAIProviderRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	report := ledger.Report("conn_1")
+	if got := report.ByModel["ollama/small"].TotalTokens; got != 10 {
+		t.Errorf("usage of ollama/small = %d tokens, want 10", got)
+	}
+	if _, ok := report.ByModel["groq/big"]; ok {
+		t.Error("usage recorded against the model that failed")
+	}
+	if got := report.ByAgent["planner"].TotalTokens; got != 10 {
+		t.Errorf("usage of the planner = %d tokens, want 10", got)
+	}
+}
+
+func TestGenerateBudgetStopsTheChain(t *testing.T) {
+	ledger := usage.NewLedger(nil, usage.Budget{MaxTokensPerSession: 10})
+	ledger.Record("conn_1", nil, "agent", "groq/big", providers.ResponseUsage{TotalTokens: 10})
+	ctx := usage.WithLedger(context.Background(), ledger, "conn_1")
+	primary := &scriptedProvider{replies: []string{"primary"}}
+
+	_, _, err := generate(ctx, "agent", []ModelRef{{Provider: "groq", Model: "big", GenAIProvider: primary}}, providers.GenAIProviderRequest{}, nil)
+	if !errors.Is(err, usage.ErrBudgetExhausted) || primary.calls() != 0 {
+		t.Errorf("generate = %v after %d calls, want the budget exhausted before any call", err, primary.calls())
+	}
+}
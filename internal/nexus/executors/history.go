@@ -0,0 +1,51 @@
+package executors
+
+import (
+	"yafai/internal/nexus/providers"
+)
+
+// newChatRecord records msg as sent from one participant to another.
+func newChatRecord(from string, to string, msg providers.RequestMessage) *ChatRecord {
+	return &ChatRecord{
+		From:       from,
+		To:         to,
+		Message:    msg.Content,
+		Role:       msg.Role,
+		Image:      msg.Image,
+		ToolCalls:  msg.ToolCalls,
+		ToolCallID: msg.ToolCallID,
+	}
+}
+
+// requestMessage returns the record as the chat message sent to models.
+func (r *ChatRecord) requestMessage() providers.RequestMessage {
+	return providers.RequestMessage{
+		Role:       r.Role,
+		Content:    r.Message,
+		Image:      r.Image,
+		ToolCalls:  r.ToolCalls,
+		ToolCallID: r.ToolCallID,
+	}
+}
+
+// historyMessages lists the system prompt followed by the history as role tagged
+// messages, so models see tool calls and their results the way they were made.
+func historyMessages(system string, history []*ChatRecord) []providers.RequestMessage {
+	messages := make([]providers.RequestMessage, 0, len(history)+1)
+	messages = append(messages, providers.RequestMessage{Role: "system", Content: system})
+	for _, record := range history {
+		messages = append(messages, record.requestMessage())
+	}
+	return messages
+}
+
+// chatRole maps the sender of a record to its chat role: the actor's own messages are
+// the assistant's, everything it is told is the user's.
+func chatRole(from string, self ...string) string {
+	for _, name := range self {
+		if from == name {
+			return "assistant"
+		}
+	}
+	return "user"
+}
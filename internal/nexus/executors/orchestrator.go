@@ -4,13 +4,22 @@ import (
 	//"fmt"
 	"bytes"
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"strings"
 	"text/template"
+	"time"
 
 	"yafai/internal/nexus/assets/templates"
 	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/usage"
+)
+
+// Default limits of the orchestrator loop answering one request.
+const (
+	defaultMaxIterations = 5
+	defaultMaxFailures   = 2
 )
 
 func (o *YafaiOrchestrator) SetupPrompt() (prompt string, err error) {
@@ -19,11 +28,7 @@ func (o *YafaiOrchestrator) SetupPrompt() (prompt string, err error) {
 	if err != nil {
 		slog.Error(err.Error())
 	}
-	chats, err := o.getChatHistory()
-	if err != nil {
-		slog.Error(err.Error())
-	}
-	var orch_data = OrchestratorPromptStruct{Agents: o.GetAgentInfo(), ChatRecords: chats, Confirmation: "not confirmed", Scope: o.Scope}
+	var orch_data = OrchestratorPromptStruct{Agents: o.GetAgentInfo(), Confirmation: o.planStatus(), Plan: o.Plan.Describe(), Scope: o.Scope}
 
 	var system_prompt_string bytes.Buffer
 
@@ -49,25 +54,27 @@ func (o *YafaiOrchestrator) GetAgentInfo() (agents string) {
 	return agentsBuilder.String()
 }
 
-func (o *YafaiOrchestrator) getChatHistory() (chats string, err error) {
-
-	var historyBuilder strings.Builder
-	for _, record := range o.History {
-		historyBuilder.WriteString("From: " + record.From + "\n")
-		historyBuilder.WriteString("To: " + record.To + "\n")
-		historyBuilder.WriteString("Message: " + record.Message + "\n")
-		historyBuilder.WriteString("-----\n")
-	}
-	chats = historyBuilder.String()
-	return chats, nil
-}
 func (o *YafaiOrchestrator) AppendChatRecord(From string, To string, Message string) error {
 	// Implement the logicto append a new chat record to the conversation history
-	record := &ChatRecord{From: From, To: To, Message: Message}
+	record := &ChatRecord{From: From, To: To, Message: Message, Role: chatRole(From, "orchestrator")}
 	o.History = append(o.History, record)
 	return nil
 }
 
+// Clone returns a copy of the orchestrator and its team without conversation state,
+// sharing only the read-only config and providers, for one connection to use.
+func (o *YafaiOrchestrator) Clone() *YafaiOrchestrator {
+	clone := *o
+	clone.History = nil
+	clone.Plan = nil
+	clone.PlanConfirmed = false
+	clone.Team = make(map[string]*YafaiAgent, len(o.Team))
+	for name, agent := range o.Team {
+		clone.Team[name] = agent.Clone()
+	}
+	return &clone
+}
+
 func (o *YafaiOrchestrator) UpdatePlan(plan *PlannerResponse) error {
 	o.Plan = plan
 	o.PlanConfirmed = false
@@ -79,48 +86,79 @@ func (o *YafaiOrchestrator) UpdatePlanStatus(confirm bool) error {
 	return nil
 }
 
-func (a *YafaiOrchestrator) UpdatePrompt() error {
-	// Implement the logic to update the system prompt based on the converstations state t-3 conversations history + t-3 react steps.
+// UpdatePrompt fits the history to the context of the orchestrator's models before a
+// model call, as set by its context config. The last record is the request answered.
+func (o *YafaiOrchestrator) UpdatePrompt(ctx context.Context, system string) error {
+	chain := modelChain(ModelRef{Provider: o.Provider, Model: o.Model, GenAIProvider: o.GenAIProvider}, o.Fallbacks)
+	window := newContextWindow("orchestrator", chain, o.Context, o.Generation, system, nil)
+	o.History, _ = window.fit(ctx, o.History, len(o.History)-1)
 	return nil
 }
 
+// Limits returns the agent calls and agent failures in a row allowed per request,
+// defaulting to 5 and 2, and the time allowed per request, zero for no limit.
+func (o *YafaiOrchestrator) Limits() (iterations int, failures int, duration time.Duration) {
+	iterations, failures = o.MaxIterations, o.MaxFailures
+	if iterations <= 0 {
+		iterations = defaultMaxIterations
+	}
+	if failures <= 0 {
+		failures = defaultMaxFailures
+	}
+	return iterations, failures, o.MaxDuration
+}
+
 func (o *YafaiOrchestrator) AttachTeam() error {
 	return nil
 }
 
 func (o *YafaiOrchestrator) Execute(ctx context.Context, req *YafaiRequest) (res *YafaiResponse, err error) {
+	ctx, cancel := withActorTimeout(ctx, o.Timeout)
+	defer cancel()
+
 	// Implement the logic to execute the agent's task
 	sys_prompt, err := o.SetupPrompt()
 	if err != nil {
 		slog.Error(err.Error())
 	}
-	provider := providers.GetProvider(o.Provider)
-	client := provider.Init()
-	system_request := providers.RequestMessage{Role: "system", Content: sys_prompt}
-	user_request := providers.RequestMessage{Role: "user", Content: req.Request.Content}
-
-	provider_req := providers.GenAIProviderRequest{Model: o.Model, Messages: []providers.RequestMessage{system_request, user_request}, Stream: false, ResponseFormat: &providers.ResponseFormat{Type: "json_object"}}
-	completion, err := provider.Generate(ctx, client, provider_req)
-	payload := &providers.ResponseMessage{Role: "assistant", Content: completion.Choices[0].Message.Content}
-	payload.Content = strings.ReplaceAll(payload.Content, "\\", "")
-	//payload.Content = strings.ReplaceAll(strings.ReplaceAll(payload.Content, "\n", ""), "\\", "")
-	return &YafaiResponse{Source: "orchestrator", Response: payload}, err
-}
-
-func (o *YafaiOrchestrator) Parse(ctx context.Context, agentLogs []*ChatRecord) (string, error) {
-	slog.Info("Parsing final response and checking goal", "logs", agentLogs)
-	// Implement logic here to analyze the agent logs and determine if the overall goal
-	// has been achieved. This might involve another call to a language model
-	// that considers the entire interaction.
-	finalResponse := "Agent Activity:\n"
-	for agent, log := range agentLogs {
-		finalResponse += fmt.Sprintf("--- %s ---\n%s\n", agent, log)
+	// The history is sent as messages: user requests and agent observations as user
+	// turns, the orchestrator's earlier actions as assistant turns. The request stays
+	// in the history only once it is answered.
+	user_request := newChatRecord("user", "orchestrator", providers.RequestMessage{Role: "user", Content: req.Request.Content})
+	o.History = append(o.History, user_request)
+	if err := o.UpdatePrompt(ctx, sys_prompt); err != nil {
+		slog.Error("Failed to fit history to the model context", "error", err)
 	}
-
-	// Example logic for goal checking - replace with your actual implementation
-	if strings.Contains(strings.ToLower(finalResponse), "goal achieved") || strings.Contains(strings.ToLower(finalResponse), "done") {
-		return finalResponse + "\nGoal Achieved.", nil
-	} else {
-		return finalResponse + "\nGoal Not Yet Achieved.", nil
+	messages := historyMessages(sys_prompt, o.History)
+	o.History = o.History[:len(o.History)-1]
+
+	provider_req := providers.GenAIProviderRequest{Model: o.Model, Messages: messages, Stream: false, GenerationConfig: o.Generation}
+	var onContent func(string)
+	if req.OnDelta != nil {
+		extractor := &JSONAnswerExtractor{}
+		onContent = func(fragment string) {
+			if delta := extractor.Write(fragment); delta != "" {
+				req.OnDelta(delta)
+			}
+		}
+	}
+	chain := modelChain(ModelRef{Provider: o.Provider, Model: o.Model, GenAIProvider: o.GenAIProvider}, o.Fallbacks)
+	var action OrchestratorAction
+	_, model, err := generateStructured(ctx, "orchestrator", chain, provider_req, "orchestrator_action", &action, onContent)
+	if errors.Is(err, usage.ErrBudgetExhausted) {
+		// Answer in the orchestrator's own format so the loop ends with a message to the user.
+		action = OrchestratorAction{Action: ActionAnswer, Answer: err.Error()}
+		model = ModelRef{}
+	} else if err != nil {
+		slog.Error("Orchestrator generation failed", "model", model.String(), "error", err)
+		return nil, err
+	}
+	// The content is the validated action, re-encoded so callers can decode it as is.
+	content, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
 	}
+	o.History = append(o.History, user_request, newChatRecord("orchestrator", "user", providers.RequestMessage{Role: "assistant", Content: string(content)}))
+	payload := &providers.ResponseMessage{Role: "assistant", Content: string(content)}
+	return &YafaiResponse{Source: "orchestrator", Response: payload, Model: model.String()}, nil
 }
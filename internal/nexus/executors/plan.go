@@ -0,0 +1,297 @@
+package executors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+
+	"yafai/internal/nexus/assets/templates"
+	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/usage"
+)
+
+// ErrNoConfirmedPlan is returned by ExecutePlan when no plan waits for execution.
+var ErrNoConfirmedPlan = errors.New("no confirmed plan to execute")
+
+// noAgent is the agent the planner names for tasks no agent can handle.
+const noAgent = "none"
+
+// Describe lists the tasks of the plan, one line each with its ID, agent and the
+// tasks it depends on, followed by its reasoning.
+func (p *PlannerResponse) Describe() string {
+	if p == nil {
+		return ""
+	}
+	var b strings.Builder
+	for i, task := range p.Response {
+		id := task.ID
+		if id == "" {
+			id = fmt.Sprint(i + 1)
+		}
+		fmt.Fprintf(&b, "%s. [%s] %s", id, task.Agent, task.Task)
+		if len(task.DependsOn) > 0 {
+			fmt.Fprintf(&b, " (after %s)", strings.Join(task.DependsOn, ", "))
+		}
+		b.WriteString("\n")
+		if task.Thought != "" {
+			fmt.Fprintf(&b, "   Why: %s\n", task.Thought)
+		}
+	}
+	return b.String()
+}
+
+// planStatus describes the plan for the orchestrator prompt.
+func (o *YafaiOrchestrator) planStatus() string {
+	switch {
+	case o.Plan == nil:
+		return "no plan"
+	case o.PlanConfirmed:
+		return "confirmed"
+	default:
+		return "not confirmed"
+	}
+}
+
+// stepRequest builds the request of a plan task, passing on the outputs of the
+// tasks it depends on.
+func stepRequest(goal string, task *PlannerTask, inputs []PlanStepResult) string {
+	var b strings.Builder
+	b.WriteString(task.Task)
+	if goal != "" {
+		fmt.Fprintf(&b, "\n\nThis task is part of a plan for the user request: %s", goal)
+	}
+	if len(inputs) > 0 {
+		b.WriteString("\n\nResults of the tasks this one builds on:")
+		for _, input := range inputs {
+			fmt.Fprintf(&b, "\nTask %s (%s): %s", input.Task.ID, input.Task.Agent, input.outcome())
+		}
+	}
+	return b.String()
+}
+
+// outcome is the output of the step, or what went wrong.
+func (r PlanStepResult) outcome() string {
+	if r.Err != nil {
+		return "failed: " + r.Err.Error()
+	}
+	return r.Output
+}
+
+// ExecutePlan runs the confirmed plan as a dependency graph, then synthesizes the
+// answer from the outputs of all its tasks. A task starts once the tasks it depends
+// on are done and gets their outputs; tasks that do not depend on each other run
+// concurrently, at most Parallelism at a time. A failed task skips the tasks that
+// depend on it while the other branches go on. Every step is reported to req.OnTrace
+// and the answer streams to req.OnDelta.
+func (o *YafaiOrchestrator) ExecutePlan(ctx context.Context, req *YafaiRequest) (*YafaiResponse, error) {
+	if o.Plan == nil || !o.PlanConfirmed {
+		return nil, ErrNoConfirmedPlan
+	}
+	plan := o.Plan
+	graph, err := newPlanGraph(plan.Response, o.teamSet())
+	if err != nil {
+		return nil, fmt.Errorf("plan cannot run: %w", err)
+	}
+
+	// Traces come from concurrent tasks, the client gets them one at a time
+	var traceMu sync.Mutex
+	onTrace := func(message string) {
+		if req.OnTrace != nil {
+			traceMu.Lock()
+			defer traceMu.Unlock()
+			req.OnTrace(message)
+		}
+	}
+	images := plan.Image
+	if req.Request != nil {
+		images = append(images[:len(images):len(images)], req.Request.Image...)
+	}
+
+	results, err := o.runPlanGraph(ctx, graph, plan.Request, images, onTrace)
+	if err != nil {
+		return nil, err
+	}
+
+	answer, model, err := o.synthesize(ctx, plan.Request, results, req.OnDelta)
+	if err != nil {
+		return nil, err
+	}
+	o.recordPlanRun(plan, answer)
+	payload := &providers.ResponseMessage{Role: "assistant", Content: answer}
+	return &YafaiResponse{Source: "orchestrator", Response: payload, Model: model.String()}, nil
+}
+
+// runPlanGraph runs the tasks of graph in dependency order and returns their results
+// in plan order. An agent keeps one history, so the tasks of one agent take turns.
+func (o *YafaiOrchestrator) runPlanGraph(ctx context.Context, graph *planGraph, goal string, images []string, onTrace func(string)) ([]PlanStepResult, error) {
+	limit := o.Parallelism
+	if limit <= 0 {
+		limit = defaultParallelism
+	}
+	total := len(graph.tasks)
+	trace := func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		slog.Info("Plan step", "trace", message)
+		onTrace(message)
+	}
+
+	agentLocks := make(map[string]*sync.Mutex)
+	for _, task := range graph.tasks {
+		if agentLocks[task.Agent] == nil {
+			agentLocks[task.Agent] = &sync.Mutex{}
+		}
+	}
+
+	results := make([]PlanStepResult, total)
+	waiting := make([]int, total)
+	var ready []int
+	for i := range graph.tasks {
+		results[i].Task = graph.tasks[i]
+		waiting[i] = len(graph.needs[i])
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	done := make(chan int)
+	running, finished := 0, 0
+	for finished < total {
+		for len(ready) > 0 && running < limit && ctx.Err() == nil {
+			i := ready[0]
+			ready = ready[1:]
+			task := graph.tasks[i]
+
+			// The results of finished tasks are no longer written
+			inputs := make([]PlanStepResult, 0, len(graph.needs[i]))
+			var failed []string
+			for _, j := range graph.needs[i] {
+				inputs = append(inputs, results[j])
+				if results[j].Err != nil {
+					failed = append(failed, graph.tasks[j].ID)
+				}
+			}
+			if len(failed) > 0 {
+				results[i].Err = fmt.Errorf("skipped, task %s it depends on failed", strings.Join(failed, ", "))
+				trace("Plan task %s skipped: %s failed", task.ID, strings.Join(failed, ", "))
+				finished++
+				ready = append(ready, graph.release(waiting, i)...)
+				continue
+			}
+
+			trace("Plan task %s started (%d/%d): %s: %s", task.ID, finished+running+1, total, task.Agent, task.Task)
+			running++
+			go func(i int, lock *sync.Mutex) {
+				lock.Lock()
+				defer lock.Unlock()
+				result := o.runPlanStep(ctx, goal, graph.tasks[i], inputs, images, onTrace)
+				results[i] = result
+				done <- i
+			}(i, agentLocks[task.Agent])
+		}
+		if running == 0 {
+			// Nothing runs and nothing may start: ctx ended
+			break
+		}
+
+		i := <-done
+		running--
+		finished++
+		task := graph.tasks[i]
+		if err := results[i].Err; err != nil {
+			slog.Error("Plan task failed", "task", task.ID, "agent", task.Agent, "error", err)
+			trace("Plan task %s failed: %v", task.ID, err)
+		} else {
+			trace("Plan task %s done", task.ID)
+		}
+		ready = append(ready, graph.release(waiting, i)...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (o *YafaiOrchestrator) runPlanStep(ctx context.Context, goal string, task *PlannerTask, inputs []PlanStepResult, images []string, onTrace func(string)) PlanStepResult {
+	result := PlanStepResult{Task: task}
+	agent, exists := o.Team[task.Agent]
+	switch {
+	case task.Agent == "" || task.Agent == noAgent:
+		result.Err = errors.New("no agent of the team can handle this task")
+		return result
+	case !exists:
+		result.Err = fmt.Errorf("agent '%s' not found", task.Agent)
+		return result
+	}
+
+	request := &providers.RequestMessage{Role: "user", Content: stepRequest(goal, task, inputs), Image: images}
+	res, err := agent.Execute(ctx, &YafaiRequest{Source: "orchestrator", Request: request, OnTrace: onTrace})
+	if res != nil {
+		result.Model = res.Model
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Output = res.Response.Content
+	return result
+}
+
+// synthesize writes the answer to the plan's request from the step results, using
+// the orchestrator's models. A spent budget is handed back as the answer.
+func (o *YafaiOrchestrator) synthesize(ctx context.Context, goal string, results []PlanStepResult, onDelta func(string)) (string, ModelRef, error) {
+	record, err := template.New("AgentLog").Parse(templates.AgentLogRecord)
+	if err != nil {
+		return "", ModelRef{}, err
+	}
+	var logs bytes.Buffer
+	for _, result := range results {
+		entry := AgentLog{Name: result.Task.Agent, Task: result.Task.Task, Response: result.outcome()}
+		if err := record.Execute(&logs, entry); err != nil {
+			return "", ModelRef{}, err
+		}
+	}
+	synth, err := template.New("Synth").Parse(templates.SynthPrompt)
+	if err != nil {
+		return "", ModelRef{}, err
+	}
+	var prompt bytes.Buffer
+	if err := synth.Execute(&prompt, struct{ AgentLogs string }{logs.String()}); err != nil {
+		return "", ModelRef{}, err
+	}
+
+	req := providers.GenAIProviderRequest{
+		Messages: []providers.RequestMessage{
+			{Role: "system", Content: prompt.String()},
+			{Role: "user", Content: goal},
+		},
+		GenerationConfig: o.Generation,
+	}
+	chain := modelChain(ModelRef{Provider: o.Provider, Model: o.Model, GenAIProvider: o.GenAIProvider}, o.Fallbacks)
+	resp, model, err := generate(ctx, "orchestrator", chain, req, onDelta)
+	if errors.Is(err, usage.ErrBudgetExhausted) {
+		return err.Error(), ModelRef{}, nil
+	}
+	if err != nil {
+		slog.Error("Plan synthesis failed", "model", model.String(), "error", err)
+		return "", model, err
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), model, nil
+}
+
+// recordPlanRun adds the executed plan and its answer to the history, the answer in
+// the orchestrator's own action format, and clears the plan.
+func (o *YafaiOrchestrator) recordPlanRun(plan *PlannerResponse, answer string) {
+	content, _ := json.Marshal(OrchestratorAction{Action: ActionAnswer, Answer: answer})
+	o.History = append(o.History,
+		&ChatRecord{From: "user", To: "orchestrator", Role: "user", Message: fmt.Sprintf("Run the confirmed plan for: %s\n%s", plan.Request, plan.Describe())},
+		&ChatRecord{From: "orchestrator", To: "user", Role: "assistant", Message: string(content)},
+	)
+	o.Plan = nil
+	o.PlanConfirmed = false
+}
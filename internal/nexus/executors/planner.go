@@ -20,7 +20,7 @@ func (p *YafaiPlanner) SetupPrompt() (prompt string, err error) {
 		if err != nil {
 			slog.Error(err.Error())
 		}
-		var data = AgentDescription{Name: agent.Name, Description: agent.Description}
+		var data = AgentDescription{Name: agent.Name, Description: agent.Description, DependsOn: agent.DependsOn}
 		err = tmpl.Execute(&output, data)
 		if err != nil {
 			slog.Error(err.Error())
@@ -45,50 +45,80 @@ func (p *YafaiPlanner) SetupPrompt() (prompt string, err error) {
 }
 
 func (p *YafaiPlanner) Execute(ctx context.Context, req *YafaiRequest) (response *YafaiResponse, err error) {
+	ctx, cancel := withActorTimeout(ctx, p.Timeout)
+	defer cancel()
+
 	// Implement the logic to execute the agent's task
 	sys_prompt, err := p.SetupPrompt()
 	slog.Info(sys_prompt)
 	if err != nil {
 		slog.Error(err.Error())
 	}
-	slog.Info("%s-%s", p.Model, p.Provider)
-	provider := providers.GetProvider(p.Provider)
-	client := provider.Init()
+	slog.Info("Planner model", "model", p.Model, "provider", p.Provider)
 	system_request := providers.RequestMessage{Role: "system", Content: sys_prompt}
 	user_request := providers.RequestMessage{Role: "user", Content: req.Request.Content}
 
-	var steps []*PlannerTask
+	provider_req := providers.GenAIProviderRequest{Model: p.Model, Messages: []providers.RequestMessage{system_request, user_request}, Stream: false, ReasoningFormat: "parsed", GenerationConfig: p.Generation}
+	chain := modelChain(ModelRef{Provider: p.Provider, Model: p.Model, GenAIProvider: p.GenAIProvider}, p.Fallbacks)
+	revisions := p.MaxRevisions
+	if revisions <= 0 {
+		revisions = defaultPlanRevisions
+	}
 
-	provider_req := providers.GenAIProviderRequest{Model: p.Model, Messages: []providers.RequestMessage{system_request, user_request}, Stream: false, ReasoningFormat: "parsed"}
-	completion, err := provider.Generate(ctx, client, provider_req)
-	if err != nil {
-		slog.Error(err.Error())
+	// A plan the team cannot run goes back to the model with the rules it breaks. The
+	// last plan is returned either way and Parse reports what is still wrong with it.
+	var plan PlannerOutput
+	var completion *providers.GenAIProviderResponse
+	var model ModelRef
+	for revision := 0; ; revision++ {
+		completion, model, err = generateStructured(ctx, "planner", chain, provider_req, "plan", &plan, nil)
+		if err != nil {
+			slog.Error("Planner generation failed", "model", model.String(), "error", err)
+			return nil, err
+		}
+		violations := validatePlan(plan.Tasks, p.agentSet())
+		if len(violations) == 0 || revision == revisions {
+			break
+		}
+		slog.Warn("Plan rejected, asking the planner to revise it", "model", model.String(), "revision", revision+1, "violations", len(violations))
+		messages := make([]providers.RequestMessage, len(provider_req.Messages), len(provider_req.Messages)+2)
+		copy(messages, provider_req.Messages)
+		provider_req.Messages = append(messages,
+			providers.RequestMessage{Role: "assistant", Content: completion.Choices[0].Message.Content},
+			providers.RequestMessage{Role: "user", Content: revisionRequest(violations)},
+		)
 	}
-	err = json.Unmarshal([]byte(completion.Choices[0].Message.Content), &steps)
 
+	// The content is the decoded task list, re-encoded so Parse can decode it as is.
+	content, err := json.Marshal(plan.Tasks)
 	if err != nil {
-		slog.Error("Failed to unmarshal completion into steps: %v", err.Error())
+		return nil, err
 	}
+	payload := &providers.ResponseMessage{Role: "assistant", Content: string(content), Thought: completion.Choices[0].Message.Thought}
+	response = &YafaiResponse{Source: "planner", Response: payload, Model: model.String()}
 
-	payload := &providers.ResponseMessage{Role: "assistant", Content: completion.Choices[0].Message.Content, Thought: completion.Choices[0].Message.Thought}
-	response = &YafaiResponse{Source: "planner", Response: payload}
-
-	return response, err
+	return response, nil
 }
 
 func (p *YafaiPlanner) Parse(plan *YafaiResponse) (PlanSteps []*PlannerTask, err error) {
 
-	var steps []*PlannerTask
+	var output PlannerOutput
 	planString := plan.Response.Content
-	slog.Info("%v", planString)
 	slog.Info(planString)
-	err = json.Unmarshal([]byte(planString), &steps)
+	err = decodeStructured(planString, &output)
 
 	if err != nil {
-		slog.Error("Failed to unmarshal completion into steps: %v", err)
+		slog.Error("Failed to unmarshal completion into steps", "error", err)
+		return output.Tasks, err
+	}
+
+	// The tasks are handed back with the rules they break, for callers to show
+	if violations := validatePlan(output.Tasks, p.agentSet()); len(violations) > 0 {
+		err = &PlanValidationError{Violations: violations}
+		slog.Warn("Plan is invalid", "error", err)
 	}
 
-	return steps, err
+	return output.Tasks, err
 }
 
 func (p *YafaiPlanner) Close() error {
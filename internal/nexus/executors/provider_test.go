@@ -0,0 +1,66 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"yafai/internal/nexus/providers"
+)
+
+// scriptedProvider answers with replies in turn, repeating the last one, or fails
+// every call with err. Every reply reports usage. It keeps the requests it was sent
+// and counts the clients opened and closed.
+type scriptedProvider struct {
+	mu       sync.Mutex
+	replies  []string
+	err      error
+	usage    providers.ResponseUsage
+	requests []providers.GenAIProviderRequest
+	opened   int
+	closed   int
+	// schema is reported as the JSONSchema capability.
+	schema bool
+}
+
+func (p *scriptedProvider) Init() *http.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.opened++
+	return &http.Client{}
+}
+
+func (p *scriptedProvider) Generate(ctx context.Context, client *http.Client, req providers.GenAIProviderRequest) (*providers.GenAIProviderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return nil, p.err
+	}
+	reply := p.replies[0]
+	if len(p.replies) > 1 {
+		p.replies = p.replies[1:]
+	}
+	return &providers.GenAIProviderResponse{Choices: []providers.ResponseChoice{{Message: providers.ResponseMessage{Role: "assistant", Content: reply}}}, Usage: p.usage}, nil
+}
+
+func (p *scriptedProvider) GenerateStream(ctx context.Context, client *http.Client, req providers.GenAIProviderRequest) (<-chan providers.StreamChunk, error) {
+	return nil, errors.New("streaming is not scripted")
+}
+
+func (p *scriptedProvider) Close(client *http.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed++
+}
+
+func (p *scriptedProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{JSONSchema: p.schema}
+}
+
+func (p *scriptedProvider) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
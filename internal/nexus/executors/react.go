@@ -0,0 +1,57 @@
+package executors
+
+import (
+	"fmt"
+	"strings"
+
+	"yafai/internal/nexus/providers"
+)
+
+// Defaults of the ReAct loop limits of an agent.
+const (
+	defaultMaxSteps     = 5
+	defaultMaxToolCalls = 10
+)
+
+// maxTraceLength caps the text of a thought or observation quoted in a trace.
+const maxTraceLength = 200
+
+// limits returns the configured step and tool call limits, or their defaults.
+func (a *YafaiAgent) limits() (maxSteps int, maxToolCalls int) {
+	maxSteps, maxToolCalls = a.MaxSteps, a.MaxToolCalls
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxSteps
+	}
+	if maxToolCalls <= 0 {
+		maxToolCalls = defaultMaxToolCalls
+	}
+	return maxSteps, maxToolCalls
+}
+
+// stopped answers for an agent that hit a loop limit, passing on the last successful
+// tool result so the orchestrator can still make use of the work done.
+func (a *YafaiAgent) stopped(limit string, lastObservation string, model ModelRef) *YafaiResponse {
+	answer := fmt.Sprintf("I stopped after reaching %s without a final answer.", limit)
+	if lastObservation != "" {
+		answer += "\nThe last tool result was:\n" + lastObservation
+	}
+	a.AppendChatRecord("agent", "user", answer)
+	return &YafaiResponse{Response: &providers.ResponseMessage{
+		Role:    "assistant",
+		Content: answer,
+	}, Model: model.String()}
+}
+
+// extractThought returns the reasoning of a reply for the step trace: the text after
+// "Thought:" up to the next line, or else the first line of the reply.
+func extractThought(content string) string {
+	if idx := strings.Index(content, "Thought:"); idx != -1 {
+		content = content[idx+len("Thought:"):]
+	}
+	thought, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	thought = strings.TrimSpace(thought)
+	if len(thought) > maxTraceLength {
+		thought = thought[:maxTraceLength] + "..."
+	}
+	return thought
+}
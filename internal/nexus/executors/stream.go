@@ -0,0 +1,171 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"yafai/internal/nexus/providers"
+)
+
+// generateStreaming runs req through the provider's streaming endpoint, handing every
+// content fragment to onContent, and returns the assembled completion. If the provider
+// rejects the streaming request as invalid it falls back to a blocking Generate call.
+func generateStreaming(ctx context.Context, provider providers.GenAIProvider, client *http.Client, req providers.GenAIProviderRequest, onContent func(string)) (*providers.GenAIProviderResponse, error) {
+	chunks, err := provider.GenerateStream(ctx, client, req)
+	if errors.Is(err, providers.ErrInvalidRequest) {
+		slog.Warn("Streaming request rejected, falling back to blocking generate", "error", err)
+		return provider.Generate(ctx, client, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var acc providers.StreamAccumulator
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return nil, chunk.Err
+		}
+		acc.Add(chunk)
+		if chunk.Content != "" {
+			onContent(chunk.Content)
+		}
+	}
+	// A stream cut short by the context closes without an error chunk.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return acc.Response(), nil
+}
+
+// StreamExtractor turns raw model output fragments into the user facing part of an
+// answer. Write returns only the newly available text, so it can be forwarded as-is.
+type StreamExtractor interface {
+	Write(fragment string) string
+}
+
+var answerFieldPattern = regexp.MustCompile(`"(chat|answer)"\s*:\s*"`)
+
+// JSONAnswerExtractor streams the value of the "chat" or "answer" key of the
+// orchestrator's JSON reply while the JSON itself is still incomplete. Structured
+// output emits every key, so keys whose value is an empty string are skipped.
+type JSONAnswerExtractor struct {
+	raw     strings.Builder
+	start   int
+	emitted int
+}
+
+func (e *JSONAnswerExtractor) Write(fragment string) string {
+	e.raw.WriteString(fragment)
+	raw := e.raw.String()
+
+	if e.start == 0 {
+		for _, loc := range answerFieldPattern.FindAllStringIndex(raw, -1) {
+			rest := raw[loc[1]:]
+			if rest == "" {
+				return ""
+			}
+			if rest[0] != '"' {
+				e.start = loc[1]
+				break
+			}
+		}
+		if e.start == 0 {
+			return ""
+		}
+	}
+
+	value := decodePartialJSONString(raw[e.start:])
+	if len(value) <= e.emitted {
+		return ""
+	}
+	delta := value[e.emitted:]
+	e.emitted = len(value)
+	return delta
+}
+
+// decodePartialJSONString decodes the body of a JSON string literal (without the
+// opening quote) up to the closing quote or, when the literal is still being
+// streamed, up to the last complete character.
+func decodePartialJSONString(s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			return out.String()
+		case c == '\\':
+			if i+1 >= len(s) {
+				return out.String()
+			}
+			switch s[i+1] {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case 'r':
+				out.WriteByte('\r')
+			case 'b':
+				out.WriteByte('\b')
+			case 'f':
+				out.WriteByte('\f')
+			case 'u':
+				if i+6 > len(s) {
+					return out.String()
+				}
+				code, err := strconv.ParseUint(s[i+2:i+6], 16, 32)
+				if err != nil {
+					return out.String()
+				}
+				out.WriteRune(rune(code))
+				i += 6
+				continue
+			default:
+				out.WriteByte(s[i+1])
+			}
+			i += 2
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 && !utf8.FullRuneInString(s[i:]) {
+				return out.String()
+			}
+			out.WriteString(s[i : i+size])
+			i += size
+		}
+	}
+	return out.String()
+}
+
+// ReactAnswerExtractor streams whatever follows the "Final Answer:" or "Query:"
+// marker of a ReAct style agent reply.
+type ReactAnswerExtractor struct {
+	raw     strings.Builder
+	emitted int
+}
+
+func (e *ReactAnswerExtractor) Write(fragment string) string {
+	e.raw.WriteString(fragment)
+	raw := e.raw.String()
+
+	start := -1
+	for _, marker := range []string{"Final Answer:", "Query:"} {
+		if idx := strings.Index(raw, marker); idx != -1 && (start == -1 || idx+len(marker) < start) {
+			start = idx + len(marker)
+		}
+	}
+	if start == -1 {
+		return ""
+	}
+	value := strings.TrimLeft(raw[start:], " \t\n")
+	if len(value) <= e.emitted {
+		return ""
+	}
+	delta := value[e.emitted:]
+	e.emitted = len(value)
+	return delta
+}
@@ -0,0 +1,131 @@
+package executors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"yafai/internal/nexus/providers"
+)
+
+// maxRepairs bounds the re-prompts sent when a reply does not match its schema.
+const maxRepairs = 2
+
+// structuredOutput is implemented by the replies of actors that answer in JSON.
+type structuredOutput interface {
+	validate() error
+}
+
+// validate checks the action is complete. Replies to the plain text prompt name no
+// action, so it is inferred from the populated field.
+func (a *OrchestratorAction) validate() error {
+	if a.Action == "" {
+		switch {
+		case a.Answer != "":
+			a.Action = ActionAnswer
+		case a.Chat != "":
+			a.Action = ActionChat
+		case a.Name != "":
+			a.Action = ActionAgentInvoke
+		}
+	}
+	switch a.Action {
+	case ActionAgentInvoke:
+		if a.Name == "" || a.Task == "" {
+			return errors.New("agent_invoke needs both name and task")
+		}
+	case ActionChat:
+		if a.Chat == "" {
+			return errors.New("chat action needs a chat message")
+		}
+	case ActionAnswer:
+		if a.Answer == "" {
+			return errors.New("answer action needs an answer")
+		}
+	default:
+		return fmt.Errorf("unknown action %q, expected agent_invoke, chat or answer", a.Action)
+	}
+	return nil
+}
+
+// UnmarshalJSON also accepts a bare task array, as asked for by the planner prompt.
+func (p *PlannerOutput) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, &p.Tasks)
+	}
+	type plain PlannerOutput
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// validate only checks the reply holds tasks. Whether the team can run them is up to
+// validatePlan, which reports every rule broken.
+func (p *PlannerOutput) validate() error {
+	if len(p.Tasks) == 0 {
+		return errors.New("plan has no tasks")
+	}
+	numberTasks(p.Tasks)
+	return nil
+}
+
+// decodeStructured reads a JSON reply into out and validates it. Models answering
+// without a schema may wrap the JSON in reasoning tags, markdown fences or prose, so
+// the first JSON value after any reasoning is used.
+func decodeStructured(content string, out structuredOutput) error {
+	value := reflect.ValueOf(out).Elem()
+	value.Set(reflect.Zero(value.Type()))
+
+	if idx := strings.LastIndex(content, "</think>"); idx != -1 {
+		content = content[idx+len("</think>"):]
+	}
+	start := strings.IndexAny(content, "{[")
+	if start == -1 {
+		return errors.New("reply contains no JSON")
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(strings.NewReader(content[start:])).Decode(&raw); err != nil {
+		return fmt.Errorf("reply is not valid JSON: %w", err)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("reply does not match the schema: %w", err)
+	}
+	return out.validate()
+}
+
+// generateStructured asks chain for a reply matching the schema of out and decodes
+// it into out. Providers with structured output get the schema as response format.
+// Replies that still fail to decode or validate are sent back with the problem, up
+// to maxRepairs times. Only the first attempt streams to onContent.
+func generateStructured(ctx context.Context, actor string, chain []ModelRef, req providers.GenAIProviderRequest, name string, out structuredOutput, onContent func(string)) (*providers.GenAIProviderResponse, ModelRef, error) {
+	req.ResponseFormat = providers.JSONSchemaFormat(name, out)
+
+	var problem error
+	var model ModelRef
+	for attempt := 0; attempt <= maxRepairs; attempt++ {
+		completion, answered, err := generate(ctx, actor, chain, req, onContent)
+		if err != nil {
+			return nil, answered, err
+		}
+		model = answered
+
+		content := completion.Choices[0].Message.Content
+		if problem = decodeStructured(content, out); problem == nil {
+			return completion, model, nil
+		}
+		slog.Warn("Structured reply rejected, asking for a repair", "actor", actor, "model", model.String(), "attempt", attempt+1, "error", problem)
+
+		schema, _ := json.Marshal(req.ResponseFormat.(*providers.ResponseFormat).JSONSchema.Schema)
+		messages := make([]providers.RequestMessage, len(req.Messages), len(req.Messages)+2)
+		copy(messages, req.Messages)
+		req.Messages = append(messages,
+			providers.RequestMessage{Role: "assistant", Content: content},
+			providers.RequestMessage{Role: "user", Content: fmt.Sprintf("Your previous reply could not be used: %v. Reply again with only a JSON object matching this schema, no other text:\n%s", problem, schema)},
+		)
+		onContent = nil
+	}
+	return nil, model, &providers.ProviderError{Provider: model.Provider, Kind: providers.ErrMalformedResponse, Message: fmt.Sprintf("reply does not match the %s schema: %v", name, problem)}
+}
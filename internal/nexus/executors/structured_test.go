@@ -0,0 +1,171 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"yafai/internal/nexus/providers"
+)
+
+func TestDecodeStructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    OrchestratorAction
+		wantErr string
+	}{
+		{"plain", `{"action":"answer","answer":"42"}`, OrchestratorAction{Action: ActionAnswer, Answer: "42"}, ""},
+		{"reasoning and fences", "<think>{\"action\":\"chat\"}</think>\n```json\n{\"action\":\"chat\",\"chat\":\"hi\"}\n```", OrchestratorAction{Action: ActionChat, Chat: "hi"}, ""},
+		{"prose around", `Sure: {"action":"agent_invoke","name":"a","task":"t"} done`, OrchestratorAction{Action: ActionAgentInvoke, Name: "a", Task: "t"}, ""},
+		{"action inferred", `{"answer":"42"}`, OrchestratorAction{Action: ActionAnswer, Answer: "42"}, ""},
+		{"no JSON", "I cannot answer", OrchestratorAction{}, "reply contains no JSON"},
+		{"broken JSON", `{"action":`, OrchestratorAction{}, "reply is not valid JSON"},
+		{"wrong type", `{"action":7}`, OrchestratorAction{}, "reply does not match the schema"},
+		{"incomplete action", `{"action":"agent_invoke","name":"a"}`, OrchestratorAction{}, "needs both name and task"},
+		{"unknown action", `{"action":"dance"}`, OrchestratorAction{}, `unknown action "dance"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := OrchestratorAction{Chat: "left over"}
+			err := decodeStructured(tt.content, &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("decoded %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeStructuredPlannerArray(t *testing.T) {
+	var plan PlannerOutput
+	if err := decodeStructured(`[{"task":"look it up","agent":"a"}]`, &plan); err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Tasks) != 1 || plan.Tasks[0].ID != "t1" {
+		t.Errorf("tasks = %+v, want one task numbered t1", plan.Tasks)
+	}
+	if err := decodeStructured(`{"tasks":[]}`, &plan); err == nil {
+		t.Error("empty plan accepted")
+	}
+}
+
+func TestGenerateStructuredResponseFormat(t *testing.T) {
+	for _, schema := range []bool{true, false} {
+		provider := &scriptedProvider{replies: []string{`{"action":"answer","answer":"42"}`}, schema: schema}
+		chain := []ModelRef{{Provider: "test", Model: "format", GenAIProvider: provider}}
+		var action OrchestratorAction
+		if _, _, err := generateStructured(context.Background(), "orchestrator", chain, providers.GenAIProviderRequest{}, "action", &action, nil); err != nil {
+			t.Fatal(err)
+		}
+		format := provider.requests[0].ResponseFormat.(*providers.ResponseFormat)
+		want := "json_object"
+		if schema {
+			want = "json_schema"
+		}
+		if format.Type != want {
+			t.Errorf("schema support %v: response format %q, want %q", schema, format.Type, want)
+		}
+	}
+}
+
+func TestGenerateStructuredRepairs(t *testing.T) {
+	replies := []string{"no idea", `{"action":"agent_invoke","name":"a"}`, `{"action":"agent_invoke","name":"a","task":"t"}`}
+	provider := &scriptedProvider{replies: replies}
+	chain := []ModelRef{{Provider: "test", Model: "repair", GenAIProvider: provider}}
+	request := providers.GenAIProviderRequest{Messages: []providers.RequestMessage{{Role: "user", Content: "go"}}}
+
+	var action OrchestratorAction
+	_, model, err := generateStructured(context.Background(), "orchestrator", chain, request, "action", &action, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action.Task != "t" || model != chain[0] || provider.calls() != 3 {
+		t.Fatalf("action %+v from %s after %d calls, want the third reply", action, model, provider.calls())
+	}
+	// Each repair carries the rejected reply and what was wrong with it
+	for i, want := range []string{"reply contains no JSON", "needs both name and task"} {
+		messages := provider.requests[i+1].Messages
+		rejected, repair := messages[len(messages)-2], messages[len(messages)-1]
+		if rejected.Role != "assistant" || rejected.Content != replies[i] {
+			t.Errorf("repair %d does not send the rejected reply back: %+v", i+1, rejected)
+		}
+		if repair.Role != "user" || !strings.Contains(repair.Content, want) || !strings.Contains(repair.Content, `"action"`) {
+			t.Errorf("repair %d = %q, want the problem %q and the schema", i+1, repair.Content, want)
+		}
+	}
+	if len(request.Messages) != 1 {
+		t.Errorf("repairs changed the caller's messages: %+v", request.Messages)
+	}
+}
+
+func TestGenerateStructuredGivesUp(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{"no idea"}}
+	chain := []ModelRef{{Provider: "test", Model: "stubborn", GenAIProvider: provider}}
+	var action OrchestratorAction
+	_, _, err := generateStructured(context.Background(), "orchestrator", chain, providers.GenAIProviderRequest{}, "action", &action, nil)
+	if !errors.Is(err, providers.ErrMalformedResponse) || provider.calls() != maxRepairs+1 {
+		t.Errorf("error = %v after %d calls, want a malformed response after %d", err, provider.calls(), maxRepairs+1)
+	}
+}
+
+// schemaRefusingProvider fails requests with a JSON schema with err.
+type schemaRefusingProvider struct {
+	scriptedProvider
+	refusal error
+}
+
+func (p *schemaRefusingProvider) Generate(ctx context.Context, client *http.Client, req providers.GenAIProviderRequest) (*providers.GenAIProviderResponse, error) {
+	if usesJSONSchema(req) {
+		p.mu.Lock()
+		p.requests = append(p.requests, req)
+		p.mu.Unlock()
+		return nil, p.refusal
+	}
+	return p.scriptedProvider.Generate(ctx, client, req)
+}
+
+func TestGenerateStructuredSchemaRejected(t *testing.T) {
+	refusal := &providers.ProviderError{Provider: "test", Kind: providers.ErrInvalidRequest, StatusCode: 400, Message: "'response_format' of type 'json_schema' is not supported with this model"}
+	provider := &schemaRefusingProvider{scriptedProvider: scriptedProvider{replies: []string{`{"answer":"42"}`}, schema: true}, refusal: refusal}
+	ref := ModelRef{Provider: "test", Model: "no-schema", GenAIProvider: provider}
+	t.Cleanup(func() { noJSONSchema.Delete(ref.String()) })
+
+	var action OrchestratorAction
+	for i := 0; i < 2; i++ {
+		if _, _, err := generateStructured(context.Background(), "orchestrator", []ModelRef{ref}, providers.GenAIProviderRequest{}, "action", &action, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var formats []string
+	for _, request := range provider.requests {
+		formats = append(formats, request.ResponseFormat.(*providers.ResponseFormat).Type)
+	}
+	// The model is asked again in JSON mode, and from then on in JSON mode only
+	if strings.Join(formats, " ") != "json_schema json_object json_object" {
+		t.Errorf("response formats = %v", formats)
+	}
+}
+
+func TestGenerateStructuredOtherInvalidRequest(t *testing.T) {
+	refusal := &providers.ProviderError{Provider: "test", Kind: providers.ErrInvalidRequest, StatusCode: 400, Message: "this model's maximum context length is 8192 tokens"}
+	provider := &schemaRefusingProvider{scriptedProvider: scriptedProvider{replies: []string{`{"answer":"42"}`}, schema: true}, refusal: refusal}
+	ref := ModelRef{Provider: "test", Model: "long-context", GenAIProvider: provider}
+	t.Cleanup(func() { noJSONSchema.Delete(ref.String()) })
+
+	var action OrchestratorAction
+	_, _, err := generateStructured(context.Background(), "orchestrator", []ModelRef{ref}, providers.GenAIProviderRequest{}, "action", &action, nil)
+	if !errors.Is(err, providers.ErrInvalidRequest) || provider.calls() != 1 {
+		t.Errorf("error = %v after %d calls, want the invalid request reported as is", err, provider.calls())
+	}
+	if _, rejected := noJSONSchema.Load(ref.String()); rejected {
+		t.Error("model marked as refusing schemas after an unrelated error")
+	}
+}
@@ -0,0 +1,83 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"yafai/internal/nexus/providers"
+)
+
+// defaultToolConcurrency caps the tool calls run at once for agents that do not set
+// tool_concurrency.
+const defaultToolConcurrency = 4
+
+// runToolCalls executes the tool calls of one model turn concurrently, at most
+// ToolConcurrency at a time. Results keep the order of calls; a failing call does not
+// stop the others.
+func (a *YafaiAgent) runToolCalls(ctx context.Context, calls []providers.ToolCall) []ToolResult {
+	limit := a.ToolConcurrency
+	if limit <= 0 {
+		limit = defaultToolConcurrency
+	}
+
+	results := make([]ToolResult, len(calls))
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		results[i].Call = call
+		wg.Add(1)
+		go func(result *ToolResult) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return
+			}
+			defer func() { <-slots }()
+			result.Output, result.Err = a.runToolCall(ctx, result.Call)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+func (a *YafaiAgent) runToolCall(ctx context.Context, call providers.ToolCall) (string, error) {
+	input, err := a.ConvertToolCallToExecutionInput(call)
+	if err != nil {
+		return "", fmt.Errorf("invalid tool input: %w", err)
+	}
+	result, err := a.ExecuteTool(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Response), nil
+}
+
+// Message returns the tool message answering the call, which tells the model about
+// a failure so it can retry or work around it.
+func (r ToolResult) Message() providers.RequestMessage {
+	content := r.Output
+	if r.Err != nil {
+		content = fmt.Sprintf("Error: tool %s failed: %v", r.Call.Function.Name, r.Err)
+	}
+	return providers.RequestMessage{Role: "tool", Content: content, ToolCallID: r.Call.ID}
+}
+
+// withToolCallIDs gives calls without an ID, as some backends return them, one that
+// is unique within the turn so their results can still be correlated.
+func withToolCallIDs(calls []providers.ToolCall, turn int) []providers.ToolCall {
+	identified := make([]providers.ToolCall, len(calls))
+	for i, call := range calls {
+		if call.ID == "" {
+			call.ID = fmt.Sprintf("call_%d_%d", turn, i)
+		}
+		if call.Type == "" {
+			call.Type = "function"
+		}
+		identified[i] = call
+	}
+	return identified
+}
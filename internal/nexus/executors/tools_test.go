@@ -0,0 +1,88 @@
+package executors
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	skill "yafai/internal/bridge/skill"
+	"yafai/internal/nexus/providers"
+)
+
+// slowSkills answers every action once the call is cancelled.
+type slowSkills struct {
+	skill.UnimplementedSkillServiceServer
+}
+
+func (slowSkills) ExecuteAction(ctx context.Context, req *skill.ExecuteActionRequest) (*skill.ExecuteActionResponse, error) {
+	<-ctx.Done()
+	return nil, status.FromContextError(ctx.Err()).Err()
+}
+
+// serveSkills runs server on the skill socket under a temporary home directory.
+func serveSkills(t *testing.T, server skill.SkillServiceServer) {
+	t.Helper()
+	home, err := os.MkdirTemp("", "yafai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(home) })
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".yafai", "plugins")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, "skill.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	skill.RegisterSkillServiceServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+}
+
+func TestExecuteToolStopsWithTheRequest(t *testing.T) {
+	serveSkills(t, slowSkills{})
+	agent := &YafaiAgent{Name: "a"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := agent.ExecuteTool(ctx, ToolExecutionInput{Name: "slow"})
+	if code := status.Code(err); code != codes.DeadlineExceeded {
+		t.Errorf("error = %v, want the request deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("tool call ran %s after the request deadline", elapsed)
+	}
+}
+
+func TestRunToolCallsCancelled(t *testing.T) {
+	serveSkills(t, slowSkills{})
+	agent := &YafaiAgent{Name: "a", Actions: []*skill.Action{{Name: "slow"}}}
+	calls := []providers.ToolCall{
+		{ID: "call_1", Function: providers.ToolCallFunc{Name: "slow", Arguments: "{}"}},
+		{ID: "call_2", Function: providers.ToolCallFunc{Name: "slow", Arguments: "{}"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	results := agent.runToolCalls(ctx, calls)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("tool calls ran %s after the request was cancelled", elapsed)
+	}
+	for _, result := range results {
+		if status.Code(result.Err) != codes.Canceled {
+			t.Errorf("result of %s = %v, want cancelled", result.Call.ID, result.Err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package executors
 
 import (
 	"context"
+	"time"
 	"yafai/internal/bridge/skill"
 	"yafai/internal/nexus/providers"
 )
@@ -9,25 +10,36 @@ import (
 type ILLMActor interface {
 	GetInfo() (name string, description string)
 	SetupPrompt() (prompt string, err error)
-	UpdatePrompt() error
+	UpdatePrompt(ctx context.Context, system string) error
 	Execute(ctx context.Context, req *YafaiRequest) (res *YafaiResponse, err error)
 	Parse() error
 }
 
 type YafaiAgent struct {
-	Name          string                  `yaml:"-"`
-	Description   string                  `yaml:"description"`
-	Capabilities  string                  `yaml:"capabilities,omitempty"`
-	Model         string                  `yaml:"model"`
-	Provider      string                  `yaml:"provider"`
-	GenAIProvider providers.GenAIProvider `yaml:"provider_obj,omitempty"`
-	Goal          string                  `yaml:"goal"`
-	DependsOn     string                  `yaml:"depends"`
-	RespondsTo    string                  `yaml:"responds"`
-	SysPrompt     string                  `yaml:"sys_prompt,omitempty"`
+	Name          string                     `yaml:"-"`
+	Description   string                     `yaml:"description"`
+	Capabilities  string                     `yaml:"capabilities,omitempty"`
+	Model         string                     `yaml:"model"`
+	Provider      string                     `yaml:"provider"`
+	GenAIProvider providers.GenAIProvider    `yaml:"provider_obj,omitempty"`
+	Fallbacks     []*ModelRef                `yaml:"fallbacks,omitempty"`
+	Timeout       time.Duration              `yaml:"timeout,omitempty"`
+	Generation    providers.GenerationConfig `yaml:"generation,omitempty"`
+	Goal          string                     `yaml:"goal"`
+	DependsOn     string                     `yaml:"depends"`
+	RespondsTo    string                     `yaml:"responds"`
+	SysPrompt     string                     `yaml:"sys_prompt,omitempty"`
 	Actions       []*skill.Action
 	Tools         []providers.LLMTool `yaml:"tools,omitempty"`
-	History       []*ChatRecord       `json:"history,omitempty"`
+	// ToolConcurrency caps the tool calls of one model turn that run at once.
+	ToolConcurrency int `yaml:"tool_concurrency,omitempty"`
+	// MaxSteps caps the model calls of one Execute and MaxToolCalls the tool calls.
+	MaxSteps     int           `yaml:"max_steps,omitempty"`
+	MaxToolCalls int           `yaml:"max_tool_calls,omitempty"`
+	Context      ContextConfig `yaml:"context,omitempty"`
+	History      []*ChatRecord `json:"history,omitempty"`
+	// current is the index in History of the request being executed.
+	current int
 	//Integrations  map[string]interface{}   `yaml:"integrations"`
 	SkillClient skill.SkillServiceClient
 	Status      string            `yaml:"status"`
@@ -35,49 +47,169 @@ type YafaiAgent struct {
 }
 
 type YafaiOrchestrator struct {
-	Name          string                  `json:"name"`
-	Description   string                  `json:"description"`
-	Scope         string                  `json:"scope"`
-	Goal          string                  `json:"goal"`
-	Model         string                  `json:"model"`
-	Provider      string                  `json:"provider"`
-	GenAIProvider providers.GenAIProvider `json:"provider_obj"`
-	Team          map[string]*YafaiAgent  `json:"team"`
-	SysPrompt     string                  `json:"prompt,omitempty"`
-	History       []*ChatRecord           `json:"history,omitempty"`
-	Plan          *PlannerResponse        `json:"plan,omitempty"`
-	PlanConfirmed bool                    `json:"plan_confirmed"`
+	Name          string                     `json:"name"`
+	Description   string                     `json:"description"`
+	Scope         string                     `json:"scope"`
+	Goal          string                     `json:"goal"`
+	Model         string                     `json:"model"`
+	Provider      string                     `json:"provider"`
+	GenAIProvider providers.GenAIProvider    `json:"provider_obj"`
+	Fallbacks     []*ModelRef                `json:"fallbacks,omitempty"`
+	Timeout       time.Duration              `json:"timeout,omitempty"`
+	Generation    providers.GenerationConfig `json:"generation,omitempty"`
+	Context       ContextConfig              `json:"context,omitempty"`
+	Team          map[string]*YafaiAgent     `json:"team"`
+	SysPrompt     string                     `json:"prompt,omitempty"`
+	History       []*ChatRecord              `json:"history,omitempty"`
+	Plan          *PlannerResponse           `json:"plan,omitempty"`
+	PlanConfirmed bool                       `json:"plan_confirmed"`
+	// Parallelism caps the plan tasks that run at once.
+	Parallelism int `json:"parallelism,omitempty"`
+	// MaxIterations caps the agent calls answering one request, MaxFailures the agent
+	// failures in a row and MaxDuration the time spent on it.
+	MaxIterations int           `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+	MaxFailures   int           `json:"max_failures,omitempty" yaml:"max_failures,omitempty"`
+	MaxDuration   time.Duration `json:"max_duration,omitempty" yaml:"max_duration,omitempty"`
+	// Critic reviews agent outputs and answers, attached from the workspace config.
+	Critic *YafaiCritic `json:"-" yaml:"-"`
+}
+
+// YafaiCritic reviews agent outputs and the orchestrator's answers against the
+// orchestrator's goal and scope, and a rubric of its own.
+type YafaiCritic struct {
+	Model         string                     `yaml:"model"`
+	Provider      string                     `yaml:"provider,omitempty"`
+	GenAIProvider providers.GenAIProvider    `yaml:"-"`
+	Fallbacks     []*ModelRef                `yaml:"fallbacks,omitempty"`
+	Timeout       time.Duration              `yaml:"timeout,omitempty"`
+	Generation    providers.GenerationConfig `yaml:"generation,omitempty"`
+	// Rubric lists what a good answer must do, in the critic's prompt.
+	Rubric    string `yaml:"rubric,omitempty"`
+	SysPrompt string `yaml:"sys_prompt,omitempty"`
+	// MaxRevisions caps the revisions of an answer the critic asks for per request.
+	MaxRevisions int `yaml:"max_revisions,omitempty"`
+	// SkipAgents leaves agent outputs unreviewed, so only answers are.
+	SkipAgents bool `yaml:"skip_agents,omitempty"`
+}
+
+// Critic verdicts.
+const (
+	VerdictPass   = "pass"
+	VerdictRevise = "revise"
+	VerdictFail   = "fail"
+)
+
+// CriticVerdict is the structured reply of the critic.
+type CriticVerdict struct {
+	Verdict  string `json:"verdict" enum:"pass,revise,fail"`
+	Feedback string `json:"feedback" description:"For revise, what to change; for fail, why the goal cannot be met; empty for pass"`
+}
+
+// CriticPromptStruct fills the critic prompt.
+type CriticPromptStruct struct {
+	Goal   string
+	Scope  string
+	Rubric string
 }
 
 type YafaiPlanner struct {
-	Agents        []*YafaiAgent           `yaml:"agents,omitempty"`
-	Model         string                  `yaml:"model"`
-	Provider      string                  `yaml:"provider,omitempty"`
-	GenAIProvider providers.GenAIProvider `yaml:"provider_obj,omitempty"`
-	Tasks         []*PlannerTask          `yaml:"tasks,omitempty"`
-	SysPrompt     string                  `yaml:"sys_prompt,omitempty"`
+	Agents        []*YafaiAgent              `yaml:"agents,omitempty"`
+	Model         string                     `yaml:"model"`
+	Provider      string                     `yaml:"provider,omitempty"`
+	GenAIProvider providers.GenAIProvider    `yaml:"provider_obj,omitempty"`
+	Fallbacks     []*ModelRef                `yaml:"fallbacks,omitempty"`
+	Timeout       time.Duration              `yaml:"timeout,omitempty"`
+	Generation    providers.GenerationConfig `yaml:"generation,omitempty"`
+	Tasks         []*PlannerTask             `yaml:"tasks,omitempty"`
+	SysPrompt     string                     `yaml:"sys_prompt,omitempty"`
+	// MaxRevisions caps the times an invalid plan is sent back to the model.
+	MaxRevisions int `yaml:"max_revisions,omitempty"`
+}
+
+// ModelRef names a provider/model pair an actor can generate with. Fallbacks are
+// tried in order when the primary pair fails with a retriable error.
+type ModelRef struct {
+	Provider      string                  `yaml:"provider" json:"provider"`
+	Model         string                  `yaml:"model" json:"model"`
+	GenAIProvider providers.GenAIProvider `yaml:"-" json:"-"`
+}
+
+func (m ModelRef) String() string {
+	if m.Model == "" {
+		return ""
+	}
+	return m.Provider + "/" + m.Model
 }
 
 type YafaiRequest struct {
 	Source  string
 	Request *providers.RequestMessage
+	// OnDelta, when set, switches the executor to streaming and receives the
+	// user facing part of the answer as it is generated.
+	OnDelta func(delta string)
+	// OnTrace, when set, receives a line describing each step of an agent run.
+	OnTrace func(trace string)
 }
 
 type YafaiResponse struct {
 	Source   string
 	Response *providers.ResponseMessage
+	// Model is the provider/model pair that produced the response, which differs
+	// from the configured one when a fallback answered.
+	Model string
+}
+
+// ToolResult is the outcome of one tool call. A failed call carries Err instead of
+// failing the agent turn.
+type ToolResult struct {
+	Call   providers.ToolCall
+	Output string
+	Err    error
 }
 
+// Context strategies applied when an actor's history outgrows the model context.
+const (
+	// ContextSliding drops the oldest messages.
+	ContextSliding = "sliding"
+	// ContextKeepEnds keeps the first and the last messages and drops the middle.
+	ContextKeepEnds = "keep_ends"
+	// ContextSummarize replaces older messages with a running summary written by the
+	// actor's model.
+	ContextSummarize = "summarize"
+)
+
+// ContextConfig bounds the history an actor sends to its model. The history of the
+// request being answered is only trimmed once the older turns are gone.
+type ContextConfig struct {
+	// Strategy is sliding (the default), keep_ends or summarize.
+	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+	// MaxTokens overrides the context window of the model.
+	MaxTokens int `yaml:"max_tokens,omitempty" json:"max_tokens,omitempty"`
+	// Reserve is kept free for the reply, by default the generation max_tokens or a
+	// quarter of the window up to 4096 tokens.
+	Reserve int `yaml:"reserve,omitempty" json:"reserve,omitempty"`
+	// KeepFirst is the number of oldest messages kept by keep_ends.
+	KeepFirst int `yaml:"keep_first,omitempty" json:"keep_first,omitempty"`
+	// KeepLast is the number of latest messages kept verbatim by keep_ends and summarize.
+	KeepLast int `yaml:"keep_last,omitempty" json:"keep_last,omitempty"`
+}
+
+// ChatRecord is one message of an actor's history. Role is the chat role the message
+// is sent to models with; assistant tool calls and tool results keep their IDs.
 type ChatRecord struct {
-	From    string
-	To      string
-	Message string
+	From       string               `json:"from"`
+	To         string               `json:"to"`
+	Message    string               `json:"message"`
+	Role       string               `json:"role"`
+	Image      []string             `json:"image,omitempty"`
+	ToolCalls  []providers.ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string               `json:"tool_call_id,omitempty"`
 }
 
 type OrchestratorPromptStruct struct {
 	Agents       string
-	ChatRecords  string
 	Confirmation string
+	Plan         string
 	Scope        string
 }
 
@@ -87,14 +219,59 @@ type PlannerRequest struct {
 }
 
 type PlannerTask struct {
-	Task      string
-	Agent     string
-	Thought   string
-	DependsOn string
+	ID        string  `json:"id" description:"Short unique ID of the task, such as t1"`
+	Task      string  `json:"task" description:"Clearly defined SMART task"`
+	Agent     string  `json:"agent" description:"Name of one agent from the agent list, or none"`
+	Thought   string  `json:"thought" description:"Brief reasoning behind choosing this task and agent"`
+	DependsOn TaskIDs `json:"dependson" description:"IDs of the tasks whose output this task needs, empty when it needs none"`
+}
+
+// TaskIDs lists the tasks a plan task depends on. It also reads from a single,
+// possibly comma separated, string as older plans and some models write it.
+type TaskIDs []string
+
+// PlannerOutput is the structured reply of the planner. Structured output needs an
+// object at the root, so the task list is wrapped.
+type PlannerOutput struct {
+	Tasks []*PlannerTask `json:"tasks"`
+}
+
+// PlanViolation is one rule of a valid plan that a plan breaks.
+type PlanViolation struct {
+	// Task is the ID of the offending task, empty when the plan as a whole is at fault.
+	Task    string `json:"task,omitempty"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PlanValidationError is returned for a plan that still breaks rules once the
+// planner ran out of revisions.
+type PlanValidationError struct {
+	Violations []PlanViolation
 }
 
 type PlannerResponse struct {
+	// Request is the user request the plan was made for.
+	Request  string `json:"request,omitempty"`
 	Response []*PlannerTask
+	// Image holds the images attached to the request, handed to every task.
+	Image []string `json:"image,omitempty"`
+}
+
+// PlanStepResult is the outcome of one task of an executed plan. A failed task
+// carries Err instead of stopping the plan.
+type PlanStepResult struct {
+	Task   *PlannerTask
+	Output string
+	Model  string
+	Err    error
+}
+
+// AgentLog is one executed plan step as given to the synthesizer.
+type AgentLog struct {
+	Name     string
+	Task     string
+	Response string
 }
 
 type PlannerTemplateStruct struct {
@@ -105,10 +282,28 @@ type PlannerTemplateStruct struct {
 
 //Orchestrator Types
 
+// Orchestrator actions.
+const (
+	ActionAgentInvoke = "agent_invoke"
+	ActionChat        = "chat"
+	ActionAnswer      = "answer"
+)
+
+// OrchestratorAction is the reply of the orchestrator on every turn: invoke an agent
+// with a task, chat with the user, or give the final answer.
+type OrchestratorAction struct {
+	Action string `json:"action" enum:"agent_invoke,chat,answer"`
+	Name   string `json:"name" description:"Agent to invoke, empty unless action is agent_invoke"`
+	Task   string `json:"task" description:"Task for the agent, empty unless action is agent_invoke"`
+	Chat   string `json:"chat" description:"Reply to the user, empty unless action is chat"`
+	Answer string `json:"answer" description:"Final answer, empty unless action is answer"`
+}
+
 type AgentDescription struct {
 	Name         string
 	Description  string
 	Capabilities string
+	DependsOn    string
 }
 
 type ToolDescription struct {
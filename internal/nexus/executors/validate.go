@@ -0,0 +1,140 @@
+package executors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultPlanRevisions caps the revisions asked of planners that do not set
+// max_revisions.
+const defaultPlanRevisions = 2
+
+// Rules a valid plan follows, as named in PlanViolation.
+const (
+	RuleNoTasks           = "no_tasks"
+	RuleEmptyTask         = "empty_task"
+	RuleMissingAgent      = "missing_agent"
+	RuleUnknownAgent      = "unknown_agent"
+	RuleNoneMixed         = "none_mixed"
+	RuleDuplicateID       = "duplicate_id"
+	RuleUnknownDependency = "unknown_dependency"
+	RuleSelfDependency    = "self_dependency"
+	RuleCycle             = "cycle"
+)
+
+func (e *PlanValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return fmt.Sprintf("invalid plan: %s", strings.Join(messages, "; "))
+}
+
+func (v PlanViolation) String() string {
+	if v.Task == "" {
+		return v.Message
+	}
+	return fmt.Sprintf("task %s: %s", v.Task, v.Message)
+}
+
+// validatePlan checks tasks against the rules of a plan the team can run and returns
+// every rule broken. Tasks need a description and an agent of the team; "none", for a
+// request no agent can handle, stands alone. IDs are unique and dependencies name
+// other tasks of the plan without forming a cycle.
+func validatePlan(tasks []*PlannerTask, team map[string]bool) []PlanViolation {
+	if len(tasks) == 0 {
+		return []PlanViolation{{Rule: RuleNoTasks, Message: "the plan has no tasks"}}
+	}
+	numberTasks(tasks)
+
+	var violations []PlanViolation
+	add := func(task string, rule string, format string, args ...interface{}) {
+		violations = append(violations, PlanViolation{Task: task, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	none := 0
+	for i, task := range tasks {
+		if task == nil {
+			add(fmt.Sprintf("t%d", i+1), RuleEmptyTask, "the task is empty")
+			continue
+		}
+		if strings.TrimSpace(task.Task) == "" {
+			add(task.ID, RuleEmptyTask, "the task has no description")
+		}
+		switch {
+		case task.Agent == noAgent:
+			none++
+		case task.Agent == "":
+			add(task.ID, RuleMissingAgent, "the task names no agent")
+		case !team[task.Agent]:
+			add(task.ID, RuleUnknownAgent, "agent '%s' is not in the team, use one of: %s", task.Agent, teamNames(team))
+		}
+	}
+	if none > 0 && none < len(tasks) {
+		add("", RuleNoneMixed, "agent 'none' marks a request no agent can handle and must be the only task, not mixed with tasks for agents")
+	}
+
+	// Dependencies are checked on the tasks that are there, by the first task of an ID
+	index := taskIndex(tasks)
+	for i, task := range tasks {
+		if task == nil {
+			continue
+		}
+		if index[task.ID] != i {
+			add(task.ID, RuleDuplicateID, "the ID is used by more than one task")
+		}
+		for _, id := range task.DependsOn {
+			j, known := index[id]
+			switch {
+			case !known:
+				add(task.ID, RuleUnknownDependency, "depends on task %s, which is not in the plan", id)
+			case j == i:
+				add(task.ID, RuleSelfDependency, "depends on itself")
+			}
+		}
+	}
+	if cycle := linkTasks(tasks).cycle(); cycle != nil {
+		add(cycle[0], RuleCycle, "tasks depend on each other in a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return violations
+}
+
+// teamNames lists the agents of team for messages.
+func teamNames(team map[string]bool) string {
+	names := make([]string, 0, len(team))
+	for name := range team {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// revisionRequest asks the planner to fix the violations of its last plan.
+func revisionRequest(violations []PlanViolation) string {
+	var b strings.Builder
+	b.WriteString("Your plan cannot be used, it breaks these rules:\n")
+	for _, violation := range violations {
+		fmt.Fprintf(&b, "- %s\n", violation)
+	}
+	b.WriteString("Reply with the corrected plan in the same JSON format, no other text.")
+	return b.String()
+}
+
+// agentSet names the agents the planner may assign tasks to.
+func (p *YafaiPlanner) agentSet() map[string]bool {
+	team := make(map[string]bool, len(p.Agents))
+	for _, agent := range p.Agents {
+		team[agent.Name] = true
+	}
+	return team
+}
+
+// teamSet names the agents of the orchestrator's team.
+func (o *YafaiOrchestrator) teamSet() map[string]bool {
+	team := make(map[string]bool, len(o.Team))
+	for name := range o.Team {
+		team[name] = true
+	}
+	return team
+}
@@ -0,0 +1,120 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"yafai/internal/nexus/providers"
+)
+
+func planTask(id, agent string, dependsOn ...string) *PlannerTask {
+	return &PlannerTask{ID: id, Task: "do " + id, Agent: agent, DependsOn: dependsOn}
+}
+
+func TestValidatePlan(t *testing.T) {
+	team := map[string]bool{"a": true, "b": true}
+	tests := []struct {
+		name  string
+		tasks []*PlannerTask
+		want  []string
+	}{
+		{"valid", []*PlannerTask{planTask("t1", "a"), planTask("t2", "b", "t1")}, nil},
+		{"none alone", []*PlannerTask{planTask("t1", noAgent)}, nil},
+		{"no tasks", nil, []string{RuleNoTasks}},
+		{"nil task", []*PlannerTask{planTask("t1", "a"), nil}, []string{RuleEmptyTask}},
+		{"no description", []*PlannerTask{{ID: "t1", Agent: "a"}}, []string{RuleEmptyTask}},
+		{"missing agent", []*PlannerTask{planTask("t1", "")}, []string{RuleMissingAgent}},
+		{"unknown agent", []*PlannerTask{planTask("t1", "c")}, []string{RuleUnknownAgent}},
+		{"none mixed", []*PlannerTask{planTask("t1", "a"), planTask("t2", noAgent)}, []string{RuleNoneMixed}},
+		{"duplicate id", []*PlannerTask{planTask("t1", "a"), planTask("t1", "b")}, []string{RuleDuplicateID}},
+		{"unknown dependency", []*PlannerTask{planTask("t1", "a", "t9")}, []string{RuleUnknownDependency}},
+		{"self dependency", []*PlannerTask{planTask("t1", "a", "t1")}, []string{RuleSelfDependency}},
+		{"cycle", []*PlannerTask{planTask("t1", "a", "t3"), planTask("t2", "b", "t1"), planTask("t3", "a", "t2")}, []string{RuleCycle}},
+		{"several", []*PlannerTask{planTask("t1", "c", "t1"), planTask("t2", "", "t7")}, []string{RuleUnknownAgent, RuleMissingAgent, RuleSelfDependency, RuleUnknownDependency}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, violation := range validatePlan(tt.tasks, team) {
+				got = append(got, violation.Rule)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePlanNumbersTasks(t *testing.T) {
+	tasks := []*PlannerTask{{Task: "first", Agent: "a"}, {Task: "second", Agent: "a", DependsOn: TaskIDs{"t1"}}}
+	if violations := validatePlan(tasks, map[string]bool{"a": true}); len(violations) > 0 {
+		t.Fatalf("violations = %v", violations)
+	}
+	if tasks[0].ID != "t1" || tasks[1].ID != "t2" {
+		t.Errorf("IDs = %s, %s, want t1, t2", tasks[0].ID, tasks[1].ID)
+	}
+}
+
+func TestPlannerRevisesInvalidPlans(t *testing.T) {
+	tests := []struct {
+		name    string
+		invalid string
+		rule    string
+	}{
+		{"unknown agent", `{"tasks":[{"id":"t1","task":"look it up","agent":"ghost"}]}`, "agent 'ghost' is not in the team"},
+		{"missing agent", `{"tasks":[{"id":"t1","task":"look it up","agent":""}]}`, "the task names no agent"},
+		{"empty task", `{"tasks":[{"id":"t1","task":"","agent":"a"}]}`, "the task has no description"},
+		{"none mixed", `{"tasks":[{"id":"t1","task":"look it up","agent":"a"},{"id":"t2","task":"give up","agent":"none"}]}`, "must be the only task"},
+		{"duplicate id", `{"tasks":[{"id":"t1","task":"look it up","agent":"a"},{"id":"t1","task":"write","agent":"a"}]}`, "task t1: the ID is used by more than one task"},
+		{"unknown dependency", `{"tasks":[{"id":"t1","task":"look it up","agent":"a","dependson":["t5"]}]}`, "depends on task t5, which is not in the plan"},
+		{"self dependency", `{"tasks":[{"id":"t1","task":"look it up","agent":"a","dependson":"t1"}]}`, "task t1: depends on itself"},
+		{"cycle", `{"tasks":[{"id":"t1","task":"look it up","agent":"a","dependson":"t2"},{"id":"t2","task":"write","agent":"a","dependson":"t1"}]}`, "in a cycle: t1 -> t2 -> t1"},
+	}
+	valid := `{"tasks":[{"id":"t1","task":"look it up","agent":"a"}]}`
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{replies: []string{tt.invalid, valid}}
+			planner := &YafaiPlanner{Agents: []*YafaiAgent{{Name: "a", Description: "looks things up"}}, Model: "m", GenAIProvider: provider}
+
+			res, err := planner.Execute(context.Background(), &YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: "find it"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if provider.calls() != 2 {
+				t.Fatalf("planner called %d times, want 2", provider.calls())
+			}
+			revision := provider.requests[1].Messages
+			if last := revision[len(revision)-1]; last.Role != "user" || !strings.Contains(last.Content, tt.rule) {
+				t.Errorf("revision request = %q, want it to name %q", last.Content, tt.rule)
+			}
+			if previous := revision[len(revision)-2]; previous.Role != "assistant" || previous.Content != tt.invalid {
+				t.Errorf("rejected plan not sent back: %+v", previous)
+			}
+			tasks, err := planner.Parse(res)
+			if err != nil || len(tasks) != 1 || tasks[0].Agent != "a" {
+				t.Errorf("Parse = %v, %v, want the revised plan", tasks, err)
+			}
+		})
+	}
+}
+
+func TestPlannerStopsRevisingAtTheLimit(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{`{"tasks":[{"id":"t1","task":"look it up","agent":"ghost"}]}`}}
+	planner := &YafaiPlanner{Agents: []*YafaiAgent{{Name: "a"}}, Model: "m", GenAIProvider: provider, MaxRevisions: 1}
+
+	res, err := planner.Execute(context.Background(), &YafaiRequest{Request: &providers.RequestMessage{Role: "user", Content: "find it"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.calls() != 2 {
+		t.Errorf("planner called %d times, want 2", provider.calls())
+	}
+	_, err = planner.Parse(res)
+	var invalid *PlanValidationError
+	if !errors.As(err, &invalid) || invalid.Violations[0].Rule != RuleUnknownAgent {
+		t.Errorf("Parse error = %v, want the unknown agent reported", err)
+	}
+}
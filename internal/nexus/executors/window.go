@@ -0,0 +1,239 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"yafai/internal/nexus/assets/templates"
+	"yafai/internal/nexus/providers"
+)
+
+// Defaults of the context config.
+const (
+	defaultKeepFirst = 1
+	defaultKeepLast  = 6
+	maxReserve       = 4096
+)
+
+// summaryFrom is the sender of the record holding the running summary of the
+// messages dropped by the summarize strategy.
+const summaryFrom = "summary"
+
+// Validate rejects unknown strategies and negative limits.
+func (c ContextConfig) Validate() error {
+	switch c.Strategy {
+	case "", ContextSliding, ContextKeepEnds, ContextSummarize:
+	default:
+		return fmt.Errorf("unknown context strategy %q (expected %s, %s or %s)", c.Strategy, ContextSliding, ContextKeepEnds, ContextSummarize)
+	}
+	if c.MaxTokens < 0 || c.Reserve < 0 || c.KeepFirst < 0 || c.KeepLast < 0 {
+		return fmt.Errorf("context max_tokens, reserve, keep_first and keep_last must not be negative")
+	}
+	if c.MaxTokens > 0 && c.Reserve >= c.MaxTokens {
+		return fmt.Errorf("context reserve %d leaves no room in max_tokens %d", c.Reserve, c.MaxTokens)
+	}
+	return nil
+}
+
+// contextWindow fits an actor's history into the context of its models.
+type contextWindow struct {
+	actor  string
+	chain  []ModelRef
+	config ContextConfig
+	system string
+	tools  []providers.LLMTool
+	// budget is the number of prompt tokens the history may take.
+	budget int
+}
+
+// newContextWindow sizes the window by the smallest context of the models in chain,
+// so a fallback can take the same history, less the tokens reserved for the reply.
+func newContextWindow(actor string, chain []ModelRef, config ContextConfig, generation providers.GenerationConfig, system string, tools []providers.LLMTool) *contextWindow {
+	limit := config.MaxTokens
+	if limit <= 0 {
+		for _, ref := range chain {
+			provider, err := resolveProvider(ref.GenAIProvider, ref.Provider)
+			if err != nil {
+				continue
+			}
+			if window := providers.ContextWindow(provider, ref.Model); limit <= 0 || window < limit {
+				limit = window
+			}
+		}
+	}
+	reserve := config.Reserve
+	if reserve <= 0 {
+		if generation.MaxTokens != nil {
+			reserve = *generation.MaxTokens
+		} else {
+			reserve = min(limit/4, maxReserve)
+		}
+	}
+	return &contextWindow{actor: actor, chain: chain, config: config, system: system, tools: tools, budget: limit - reserve}
+}
+
+func (w *contextWindow) tokens(history []*ChatRecord) int {
+	return providers.EstimateTokens(historyMessages(w.system, history), w.tools)
+}
+
+func (w *contextWindow) fits(older []*ChatRecord, turn []*ChatRecord) bool {
+	return w.tokens(join(older, turn)) <= w.budget
+}
+
+// fit returns history trimmed to the window and the new index of the request at
+// current. The records before it are older turns, handled by the configured strategy
+// and then dropped oldest first. The request itself is always kept, and the records
+// after it are only dropped once no older turn is left.
+func (w *contextWindow) fit(ctx context.Context, history []*ChatRecord, current int) ([]*ChatRecord, int) {
+	if current < 0 || current > len(history) {
+		current = 0
+	}
+	before := w.tokens(history)
+	if before <= w.budget {
+		return history, current
+	}
+	older, turn := history[:current:current], history[current:]
+
+	switch w.config.Strategy {
+	case ContextKeepEnds:
+		older = w.keepEnds(older, turn)
+	case ContextSummarize:
+		older = w.summarize(ctx, older, turn)
+	}
+	for len(older) > 0 && !w.fits(older, turn) {
+		// The running summary goes last, it stands for more than any single message
+		if older[0].From == summaryFrom && len(older) > 1 {
+			older = join(older[:1], dropOldest(older[1:], 0))
+			continue
+		}
+		older = dropOldest(older, 0)
+	}
+	// Some providers want the conversation to open with a user message
+	for len(older) > 0 && older[0].Role == "assistant" {
+		older = dropOldest(older, 0)
+	}
+	for !w.fits(older, turn) {
+		trimmed := dropOldest(turn, 1)
+		if len(trimmed) == len(turn) {
+			break
+		}
+		turn = trimmed
+	}
+
+	fitted := join(older, turn)
+	after := w.tokens(fitted)
+	slog.Info("Trimmed history to the model context", "actor", w.actor, "strategy", w.config.Strategy, "messages", len(history), "kept", len(fitted), "tokens", before, "fitted_tokens", after, "budget", w.budget)
+	if after > w.budget {
+		slog.Warn("Current request exceeds the model context", "actor", w.actor, "tokens", after, "budget", w.budget)
+	}
+	return fitted, len(older)
+}
+
+// keepEnds drops the middle of the older turns, keeping the first and last ones. The
+// last ones give way first when the ends alone do not fit.
+func (w *contextWindow) keepEnds(older []*ChatRecord, turn []*ChatRecord) []*ChatRecord {
+	first, last := w.keepFirst(), w.keepLast()
+	if len(older) <= first+last {
+		last = max(len(older)-first, 0)
+	}
+	head := older[:exchangeEnd(older, min(first, len(older)))]
+	tail := older[exchangeEnd(older, max(len(older)-last, len(head))):]
+	for len(tail) > 0 && !w.fits(join(head, tail), turn) {
+		tail = dropOldest(tail, 0)
+	}
+	return join(head, tail)
+}
+
+// summarize replaces all but the last older turns with a summary written by the
+// actor's model, merging any earlier summary. A failed summary leaves the turns to
+// the sliding window.
+func (w *contextWindow) summarize(ctx context.Context, older []*ChatRecord, turn []*ChatRecord) []*ChatRecord {
+	split := exchangeEnd(older, max(len(older)-w.keepLast(), 0))
+	if split == 0 || (split == 1 && older[0].From == summaryFrom) {
+		return older
+	}
+
+	var transcript strings.Builder
+	for _, record := range older[:split] {
+		transcript.WriteString(recordTranscript(record))
+	}
+	text := transcript.String()
+	// The summary request must fit the window too; the latest part matters most.
+	if limit := w.budget * 3; limit > 0 && len(text) > limit {
+		text = text[len(text)-limit:]
+	}
+
+	req := providers.GenAIProviderRequest{Messages: []providers.RequestMessage{
+		{Role: "system", Content: templates.SummaryPrompt},
+		{Role: "user", Content: text},
+	}}
+	resp, model, err := generate(ctx, w.actor, w.chain, req, nil)
+	if err != nil {
+		slog.Warn("Summarizing history failed, dropping the oldest messages instead", "actor", w.actor, "model", model.String(), "error", err)
+		return older
+	}
+	summary := &ChatRecord{
+		From:    summaryFrom,
+		To:      w.actor,
+		Role:    "user",
+		Message: "Summary of the earlier conversation:\n" + strings.TrimSpace(resp.Choices[0].Message.Content),
+	}
+	slog.Info("Summarized history", "actor", w.actor, "model", model.String(), "messages", split)
+	return join([]*ChatRecord{summary}, older[split:])
+}
+
+func (w *contextWindow) keepFirst() int {
+	if w.config.KeepFirst > 0 {
+		return w.config.KeepFirst
+	}
+	return defaultKeepFirst
+}
+
+func (w *contextWindow) keepLast() int {
+	if w.config.KeepLast > 0 {
+		return w.config.KeepLast
+	}
+	return defaultKeepLast
+}
+
+// recordTranscript renders a record as a transcript line for the summarizer.
+func recordTranscript(record *ChatRecord) string {
+	var line strings.Builder
+	fmt.Fprintf(&line, "%s -> %s: %s\n", record.From, record.To, record.Message)
+	for _, call := range record.ToolCalls {
+		fmt.Fprintf(&line, "%s called %s with %s\n", record.From, call.Function.Name, call.Function.Arguments)
+	}
+	return line.String()
+}
+
+// dropOldest removes the record at i of records together with the tool results that
+// would be left without their call. For i > 0 a drop that would leave nothing after i
+// is not made, so the latest exchange survives; records is then returned as is.
+func dropOldest(records []*ChatRecord, i int) []*ChatRecord {
+	if i >= len(records) {
+		return records
+	}
+	end := exchangeEnd(records, i+1)
+	if i > 0 && end >= len(records) {
+		return records
+	}
+	return join(records[:i], records[end:])
+}
+
+// exchangeEnd moves i past the tool results at it, so a cut at the returned index
+// neither ends records[:i] with calls missing their results nor opens records[i:]
+// with results missing their call.
+func exchangeEnd(records []*ChatRecord, i int) int {
+	for i < len(records) && records[i].Role == "tool" {
+		i++
+	}
+	return i
+}
+
+func join(a []*ChatRecord, b []*ChatRecord) []*ChatRecord {
+	joined := make([]*ChatRecord, 0, len(a)+len(b))
+	joined = append(joined, a...)
+	return append(joined, b...)
+}
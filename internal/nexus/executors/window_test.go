@@ -0,0 +1,218 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"yafai/internal/nexus/providers"
+)
+
+// pad makes every message 14 tokens long: 40 characters plus the message overhead.
+func pad(text string) string {
+	return text + strings.Repeat(".", 40-len(text))
+}
+
+func userRecord(text string) *ChatRecord {
+	return &ChatRecord{From: "user", To: "agent", Role: "user", Message: pad(text)}
+}
+
+func assistantRecord(text string) *ChatRecord {
+	return &ChatRecord{From: "agent", To: "user", Role: "assistant", Message: pad(text)}
+}
+
+func callRecord(ids ...string) *ChatRecord {
+	record := &ChatRecord{From: "agent", To: "user", Role: "assistant", Message: pad("calling")}
+	for _, id := range ids {
+		record.ToolCalls = append(record.ToolCalls, providers.ToolCall{ID: id, Type: "function", Function: providers.ToolCallFunc{Name: "tool", Arguments: "{}"}})
+	}
+	return record
+}
+
+func toolRecord(id string) *ChatRecord {
+	return &ChatRecord{From: "tool", To: "agent", Role: "tool", Message: pad("result " + id), ToolCallID: id}
+}
+
+// conversation is a history of exchanges, each a user message and a reply, with a
+// tool exchange in every third one, followed by the current request.
+func conversation(exchanges int) ([]*ChatRecord, int) {
+	var history []*ChatRecord
+	for i := 0; i < exchanges; i++ {
+		history = append(history, userRecord(fmt.Sprintf("question %d", i)))
+		if i%3 == 1 {
+			a, b := fmt.Sprintf("call_%d_a", i), fmt.Sprintf("call_%d_b", i)
+			history = append(history, callRecord(a, b), toolRecord(a), toolRecord(b))
+		}
+		history = append(history, assistantRecord(fmt.Sprintf("answer %d", i)))
+	}
+	current := len(history)
+	return append(history, userRecord("current request")), current
+}
+
+// checkToolPairs fails when a tool result lost its call or a call lost a result.
+func checkToolPairs(t *testing.T, records []*ChatRecord) {
+	t.Helper()
+	for i, record := range records {
+		if record.Role == "tool" {
+			found := false
+			for j := i - 1; j >= 0 && !found; j-- {
+				for _, call := range records[j].ToolCalls {
+					found = found || call.ID == record.ToolCallID
+				}
+			}
+			if !found {
+				t.Errorf("tool result %s kept without its call", record.ToolCallID)
+			}
+		}
+		for _, call := range record.ToolCalls {
+			found := false
+			for _, later := range records[i+1:] {
+				found = found || later.ToolCallID == call.ID
+			}
+			if !found {
+				t.Errorf("tool call %s kept without its result", call.ID)
+			}
+		}
+	}
+}
+
+func testWindow(strategy string, budget int, provider providers.GenAIProvider) *contextWindow {
+	return &contextWindow{
+		actor:  "agent",
+		chain:  []ModelRef{{Provider: "test", Model: "m", GenAIProvider: provider}},
+		config: ContextConfig{Strategy: strategy, KeepFirst: 2, KeepLast: 3},
+		system: pad("system prompt"),
+		budget: budget,
+	}
+}
+
+func TestContextWindowStrategiesFit(t *testing.T) {
+	for _, strategy := range []string{ContextSliding, ContextKeepEnds, ContextSummarize} {
+		for _, budget := range []int{60, 100, 150, 250} {
+			t.Run(fmt.Sprintf("%s/%d", strategy, budget), func(t *testing.T) {
+				history, current := conversation(12)
+				w := testWindow(strategy, budget, &scriptedProvider{replies: []string{"they talked"}})
+
+				fitted, at := w.fit(context.Background(), history, current)
+
+				if got := w.tokens(fitted); got > budget {
+					t.Errorf("fitted history takes %d tokens, budget %d", got, budget)
+				}
+				if at < 0 || at >= len(fitted) || fitted[at] != history[current] {
+					t.Fatalf("current request not at %d of the fitted history", at)
+				}
+				if messages := historyMessages(w.system, fitted); messages[0].Role != "system" || messages[0].Content != w.system {
+					t.Errorf("system prompt not kept: %+v", messages[0])
+				}
+				if fitted[0].Role == "assistant" {
+					t.Errorf("fitted history opens with an assistant message: %q", fitted[0].Message)
+				}
+				checkToolPairs(t, fitted)
+			})
+		}
+	}
+}
+
+func TestContextWindowUntouchedWhenItFits(t *testing.T) {
+	history, current := conversation(2)
+	w := testWindow(ContextSliding, 1000, nil)
+	fitted, at := w.fit(context.Background(), history, current)
+	if len(fitted) != len(history) || at != current {
+		t.Errorf("fit changed a history within budget: %d records, current %d", len(fitted), at)
+	}
+}
+
+func TestContextWindowSlidingKeepsLatest(t *testing.T) {
+	history, current := conversation(6)
+	w := testWindow(ContextSliding, 80, nil)
+	fitted, _ := w.fit(context.Background(), history, current)
+	// The newest records survive, in order
+	if tail := history[len(history)-len(fitted):]; fmt.Sprint(tail) != fmt.Sprint(fitted) {
+		t.Errorf("sliding kept %d records that are not the latest", len(fitted))
+	}
+}
+
+func TestContextWindowDropsLeadingAssistant(t *testing.T) {
+	// Dropping the oldest user message would leave the reply to open the history
+	history := []*ChatRecord{userRecord("q1"), assistantRecord("a1"), assistantRecord("a2"), userRecord("q2"), userRecord("current")}
+	w := testWindow(ContextSliding, 5+14*4, nil)
+	fitted, at := w.fit(context.Background(), history, 4)
+	if len(fitted) == 0 || fitted[0].Role != "user" {
+		t.Fatalf("fitted = %v, want a user message first", fitted)
+	}
+	if fitted[at] != history[4] || fitted[0] != history[3] {
+		t.Errorf("fitted = %v, want q2 and the current request", fitted)
+	}
+}
+
+func TestContextWindowToolPairsInTheTurn(t *testing.T) {
+	// Steps of the current turn give way oldest first, calls with their results
+	history := []*ChatRecord{
+		userRecord("old"),
+		userRecord("current"),
+		callRecord("c1", "c2"), toolRecord("c1"), toolRecord("c2"),
+		callRecord("c3"), toolRecord("c3"),
+	}
+	w := testWindow(ContextSliding, 5+14*4, nil)
+	fitted, at := w.fit(context.Background(), history, 1)
+	if fitted[at] != history[1] {
+		t.Fatalf("current request dropped: %v", fitted)
+	}
+	checkToolPairs(t, fitted)
+	if last := fitted[len(fitted)-1]; last != history[6] {
+		t.Errorf("latest step dropped, history ends with %q", last.Message)
+	}
+}
+
+func TestContextWindowKeepEnds(t *testing.T) {
+	history, current := conversation(10)
+	w := testWindow(ContextKeepEnds, 150, nil)
+	fitted, at := w.fit(context.Background(), history, current)
+	if fitted[0] != history[0] || fitted[1] != history[1] {
+		t.Errorf("keep_ends dropped the first messages: %q, %q", fitted[0].Message, fitted[1].Message)
+	}
+	if fitted[at-1] != history[current-1] {
+		t.Errorf("keep_ends dropped the last message before the request")
+	}
+	if at >= current {
+		t.Errorf("keep_ends kept all %d older messages", at)
+	}
+}
+
+func TestContextWindowSummarize(t *testing.T) {
+	history, current := conversation(10)
+	provider := &scriptedProvider{replies: []string{"they talked"}}
+	w := testWindow(ContextSummarize, 150, provider)
+	fitted, at := w.fit(context.Background(), history, current)
+
+	if provider.calls() != 1 {
+		t.Fatalf("summarizer called %d times, want 1", provider.calls())
+	}
+	if fitted[0].From != summaryFrom || fitted[0].Role != "user" || !strings.HasSuffix(fitted[0].Message, "they talked") {
+		t.Errorf("first record = %+v, want the summary", fitted[0])
+	}
+	// The last keep_last older records stay verbatim
+	kept := fitted[1:at]
+	split := current - len(kept)
+	if want := history[split:current]; fmt.Sprint(kept) != fmt.Sprint(want) || len(kept) < 3 {
+		t.Errorf("kept %d records verbatim after the summary, want the latest 3 or more", len(kept))
+	}
+	// The summarizer reads the records replaced, up to the last one
+	if transcript := provider.requests[0].Messages[1].Content; !strings.HasSuffix(transcript, recordTranscript(history[split-1])) {
+		t.Errorf("summarizer was not sent the replaced records: %q", transcript)
+	}
+}
+
+func TestContextWindowSummarizeFailureSlides(t *testing.T) {
+	history, current := conversation(10)
+	w := testWindow(ContextSummarize, 100, &scriptedProvider{err: errors.New("down")})
+	fitted, at := w.fit(context.Background(), history, current)
+	if fitted[0].From == summaryFrom {
+		t.Error("summary added although the summarizer failed")
+	}
+	if w.tokens(fitted) > 100 || fitted[at] != history[current] {
+		t.Errorf("fallback to the sliding window did not fit: %d tokens", w.tokens(fitted))
+	}
+}
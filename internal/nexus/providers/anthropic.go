@@ -1,72 +1,316 @@
 package providers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
-	"os"
+	"strings"
+)
+
+const (
+	AnthropicDefaultHost      = "https://api.anthropic.com"
+	AnthropicAPIVersion       = "2023-06-01"
+	AnthropicDefaultMaxTokens = 4096
 )
 
 type AnthropicProvider struct {
-	Host string
+	ProviderConfig
+}
+
+func init() {
+	Register("anthropic", ProviderConfig{
+		Host:      AnthropicDefaultHost,
+		HostEnv:   "ANTHROPIC_HOST",
+		APIKeyEnv: "ANTHROPIC_TOKEN",
+	}, func(cfg ProviderConfig) GenAIProvider { return AnthropicProvider{cfg} })
 }
 
 func (p AnthropicProvider) Init() *http.Client {
-	client := http.Client{}
+	client := http.Client{Timeout: p.Timeout}
 	return &client
 }
 
-func (p AnthropicProvider) Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+// toAnthropicRequest translates the OpenAI-shaped request used across YAFAI into a
+// Messages API request. System messages are lifted into the top-level system field,
+// assistant tool calls become tool_use blocks, tool messages are sent back as user turns
+// holding tool_result blocks and consecutive turns of the same role are merged since
+// the API expects user/assistant alternation.
+func toAnthropicRequest(req GenAIProviderRequest) AnthropicRequest {
+	var system []string
+	var messages []AnthropicMessage
 
-	url := fmt.Sprintf("%s/v1/messages", p.Host)
-	token := os.Getenv("ANTHROPIC_TOKEN")
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
 
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(req)
-	if err != nil {
-		slog.Info("%s", err)
+		role := msg.Role
+		if role != "assistant" {
+			role = "user"
+		}
+		var blocks []AnthropicContentBlock
+		if msg.Role == "tool" && msg.ToolCallID != "" {
+			blocks = append(blocks, AnthropicContentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content})
+		} else {
+			for _, image := range msg.Image {
+				blocks = append(blocks, anthropicImageBlock(image))
+			}
+			if msg.Content != "" {
+				blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			blocks = append(blocks, AnthropicContentBlock{Type: "tool_use", ID: call.ID, Name: call.Function.Name, Input: toolArguments(call.Function.Arguments)})
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			continue
+		}
+		messages = append(messages, AnthropicMessage{Role: role, Content: blocks})
 	}
 
-	//slog.Info(req)
-	req_obj, err := http.NewRequest(http.MethodPost, url, &buf)
+	var tools []AnthropicTool
+	for _, tool := range req.Tools {
+		schema := tool.Function.Parameters
+		if schema.Type == "" {
+			schema.Type = "object"
+		}
+		if schema.Properties == nil {
+			schema.Properties = map[string]LLMProperty{}
+		}
+		tools = append(tools, AnthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
 
-	if err != nil {
-		panic(fmt.Sprintf("Error creating request:", err))
+	// The Messages API has no seed or penalties; those settings are dropped. Its
+	// temperature tops out at 1 rather than 2.
+	maxTokens := AnthropicDefaultMaxTokens
+	if req.MaxTokens != nil {
+		maxTokens = *req.MaxTokens
+	}
+	temperature := req.Temperature
+	if temperature != nil && *temperature > 1 {
+		one := 1.0
+		temperature = &one
+	}
+	return AnthropicRequest{
+		Model:         req.Model,
+		System:        strings.Join(system, "\n\n"),
+		Messages:      messages,
+		MaxTokens:     maxTokens,
+		Temperature:   temperature,
+		TopP:          req.TopP,
+		StopSequences: req.Stop,
+		Tools:         tools,
+	}
+}
+
+// anthropicImageBlock sends a data URL image inline and any other image by URL.
+func anthropicImageBlock(image string) AnthropicContentBlock {
+	if mediaType, data, ok := splitDataURL(image); ok {
+		return AnthropicContentBlock{Type: "image", Source: map[string]interface{}{"type": "base64", "media_type": mediaType, "data": data}}
+	}
+	return AnthropicContentBlock{Type: "image", Source: map[string]interface{}{"type": "url", "url": image}}
+}
+
+// fromAnthropicResponse folds the content blocks of a Messages API reply into a single
+// choice: text blocks are concatenated and tool_use blocks become ToolCalls.
+func fromAnthropicResponse(resp AnthropicResponse) *GenAIProviderResponse {
+	var text strings.Builder
+	var toolCalls []ToolCall
 
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			args := string(block.Input)
+			if args == "" {
+				args = "{}"
+			}
+			toolCalls = append(toolCalls, ToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: ToolCallFunc{Name: block.Name, Arguments: args},
+			})
+		}
 	}
-	req_obj.Header.Set("Content-Type", "application/json")
-	req_obj.Header.Set("x-api-key", token)
 
-	if err != nil {
-		slog.Error(err.Error())
+	return &GenAIProviderResponse{
+		ID:     resp.ID,
+		Object: "chat.completion",
+		Model:  resp.Model,
+		Choices: []ResponseChoice{{
+			Index:        0,
+			Message:      ResponseMessage{Role: "assistant", Content: text.String(), ToolCalls: toolCalls},
+			FinishReason: anthropicFinishReason(resp.StopReason),
+		}},
+		Usage: ResponseUsage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+}
+
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens":
+		return "length"
+	}
+	return stopReason
+}
+
+// post sends a Messages API request and returns the response once a 2xx status has
+// been confirmed; API error bodies are turned into a *ProviderError.
+func (p AnthropicProvider) post(ctx context.Context, client *http.Client, req AnthropicRequest) (*http.Response, error) {
+	url := fmt.Sprintf("%s/v1/messages", p.Host)
+	headers := map[string]string{
+		"x-api-key":         p.APIKey(),
+		"anthropic-version": AnthropicAPIVersion,
+	}
+	for key, value := range p.Headers {
+		headers[key] = value
 	}
-	resp, err := client.Do(req_obj)
+	return postJSON(ctx, client, p.Name, url, headers, req)
+}
+
+func (p AnthropicProvider) Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+
+	resp, err := p.post(ctx, client, toAnthropicRequest(req))
 	if err != nil {
-		slog.Error(err.Error())
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	bytes, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		slog.Error(err.Error())
+		return nil, newTransportError(p.Name, err)
+	}
+
+	var result AnthropicResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		slog.Error("Error unmarshaling response", "provider", p.Name, "error", err)
+		return nil, newMalformedError(p.Name, err)
 	}
+	if result.Type != "message" {
+		return nil, newMalformedError(p.Name, fmt.Errorf("unexpected response type %q", result.Type))
+	}
+
+	return fromAnthropicResponse(result), nil
+}
 
-	// Unmarshal the response body into the struct
-	var result GenAIProviderResponse
+// GenerateStream maps the Messages API event stream onto StreamChunks. Each tool_use
+// content block is reported under its block index, with input_json_delta fragments
+// arriving as partial tool call arguments.
+func (p AnthropicProvider) GenerateStream(ctx context.Context, client *http.Client, req GenAIProviderRequest) (<-chan StreamChunk, error) {
+	anthropicReq := toAnthropicRequest(req)
+	anthropicReq.Stream = true
 
-	err = json.Unmarshal(bytes, &result)
+	resp, err := p.post(ctx, client, anthropicReq)
 	if err != nil {
-		slog.Error("Error unmarshaling response")
+		return nil, err
 	}
-	//slog.Info(result.Message.ToolCall)
-	return &result, nil
 
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		var inputTokens int
+		err := readSSE(resp.Body, func(event string, data string) error {
+			var ev AnthropicStreamEvent
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				return newMalformedError(p.Name, fmt.Errorf("decoding stream event: %w", err))
+			}
+
+			var chunk StreamChunk
+			switch ev.Type {
+			case "message_start":
+				if ev.Message == nil {
+					return nil
+				}
+				inputTokens = ev.Message.Usage.InputTokens
+				chunk = StreamChunk{ID: ev.Message.ID, Model: ev.Message.Model}
+			case "content_block_start":
+				if ev.ContentBlock == nil || ev.ContentBlock.Type != "tool_use" {
+					return nil
+				}
+				chunk.ToolCalls = []ToolCallDelta{{
+					Index:    ev.Index,
+					ID:       ev.ContentBlock.ID,
+					Type:     "function",
+					Function: ToolCallFunc{Name: ev.ContentBlock.Name},
+				}}
+			case "content_block_delta":
+				switch ev.Delta.Type {
+				case "text_delta":
+					chunk.Content = ev.Delta.Text
+				case "input_json_delta":
+					chunk.ToolCalls = []ToolCallDelta{{Index: ev.Index, Function: ToolCallFunc{Arguments: ev.Delta.PartialJSON}}}
+				default:
+					return nil
+				}
+			case "message_delta":
+				chunk.FinishReason = anthropicFinishReason(ev.Delta.StopReason)
+				if ev.Usage != nil {
+					chunk.Usage = &ResponseUsage{
+						PromptTokens:     inputTokens,
+						CompletionTokens: ev.Usage.OutputTokens,
+						TotalTokens:      inputTokens + ev.Usage.OutputTokens,
+					}
+				}
+			case "message_stop":
+				return io.EOF
+			case "error":
+				perr := &ProviderError{Provider: p.Name, Kind: ErrServer, Message: "stream error"}
+				if ev.Error != nil {
+					perr.Message = ev.Error.Message
+					switch ev.Error.Type {
+					case "rate_limit_error":
+						perr.Kind = ErrRateLimited
+					case "authentication_error", "permission_error":
+						perr.Kind = ErrAuthFailed
+					case "invalid_request_error":
+						perr.Kind = ErrInvalidRequest
+					}
+				}
+				return perr
+			default:
+				return nil
+			}
+
+			if !sendChunk(ctx, out, chunk) {
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil && err != io.EOF {
+			if _, ok := err.(*ProviderError); !ok && ctx.Err() == nil {
+				err = newTransportError(p.Name, err)
+			}
+			sendChunk(ctx, out, StreamChunk{Err: err})
+		}
+	}()
+
+	return out, nil
 }
 
 func (p AnthropicProvider) Close(client *http.Client) {
 	client.CloseIdleConnections()
-	return
 }
@@ -0,0 +1,312 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// anthropicServer stands in for the Messages API, answering every request with
+// handler after checking the path and headers.
+func anthropicServer(t *testing.T, handler func(w http.ResponseWriter, req AnthropicRequest)) AnthropicProvider {
+	t.Helper()
+	t.Setenv("TEST_ANTHROPIC_TOKEN", "secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		if got := r.Header.Get("x-api-key"); got != "secret" {
+			t.Errorf("x-api-key = %q", got)
+		}
+		if got := r.Header.Get("anthropic-version"); got != AnthropicAPIVersion {
+			t.Errorf("anthropic-version = %q", got)
+		}
+		var req AnthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		handler(w, req)
+	}))
+	t.Cleanup(server.Close)
+	return AnthropicProvider{ProviderConfig{Name: "anthropic", Host: server.URL, APIKeyEnv: "TEST_ANTHROPIC_TOKEN"}}
+}
+
+func TestToAnthropicRequest(t *testing.T) {
+	temperature, maxTokens := 1.7, 256
+	req := GenAIProviderRequest{
+		Model: "claude",
+		Messages: []RequestMessage{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: "weather?"},
+			{Role: "assistant", Content: "checking", ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: ToolCallFunc{Name: "weather", Arguments: `{"city":"Oslo"}`}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "rain"},
+			{Role: "user", Content: "thanks"},
+			{Role: "system", Content: "answer in English"},
+		},
+		Tools:            []LLMTool{{Type: "function", Function: LLMFunction{Name: "weather", Description: "Weather of a city"}}},
+		GenerationConfig: GenerationConfig{Temperature: &temperature, MaxTokens: &maxTokens, Stop: []string{"END"}},
+	}
+	got := toAnthropicRequest(req)
+
+	if got.System != "be brief\n\nanswer in English" {
+		t.Errorf("system = %q", got.System)
+	}
+	want := []AnthropicMessage{
+		{Role: "user", Content: []AnthropicContentBlock{{Type: "text", Text: "weather?"}}},
+		{Role: "assistant", Content: []AnthropicContentBlock{
+			{Type: "text", Text: "checking"},
+			{Type: "tool_use", ID: "call_1", Name: "weather", Input: json.RawMessage(`{"city":"Oslo"}`)},
+		}},
+		// The tool result and the next user turn merge into one user turn
+		{Role: "user", Content: []AnthropicContentBlock{
+			{Type: "tool_result", ToolUseID: "call_1", Content: "rain"},
+			{Type: "text", Text: "thanks"},
+		}},
+	}
+	if !reflect.DeepEqual(got.Messages, want) {
+		t.Errorf("messages = %+v, want %+v", got.Messages, want)
+	}
+	if got.Temperature == nil || *got.Temperature != 1 {
+		t.Errorf("temperature = %v, want clamped to 1", got.Temperature)
+	}
+	if got.MaxTokens != 256 || !reflect.DeepEqual(got.StopSequences, []string{"END"}) {
+		t.Errorf("max_tokens = %d, stop_sequences = %v", got.MaxTokens, got.StopSequences)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].InputSchema.Type != "object" || got.Tools[0].InputSchema.Properties == nil {
+		t.Errorf("tools = %+v, want an object input schema", got.Tools)
+	}
+}
+
+func TestToAnthropicRequestDefaults(t *testing.T) {
+	temperature := 0.5
+	got := toAnthropicRequest(GenAIProviderRequest{
+		Model:            "claude",
+		Messages:         []RequestMessage{{Role: "user", Content: "hi"}, {Role: "user", Content: "there"}},
+		GenerationConfig: GenerationConfig{Temperature: &temperature},
+	})
+	if got.MaxTokens != AnthropicDefaultMaxTokens {
+		t.Errorf("max_tokens = %d, want %d", got.MaxTokens, AnthropicDefaultMaxTokens)
+	}
+	if *got.Temperature != 0.5 {
+		t.Errorf("temperature = %v, want 0.5 kept", *got.Temperature)
+	}
+	if got.System != "" || len(got.Messages) != 1 || len(got.Messages[0].Content) != 2 {
+		t.Errorf("messages = %+v, want one merged user turn", got.Messages)
+	}
+}
+
+func TestFromAnthropicResponse(t *testing.T) {
+	got := fromAnthropicResponse(AnthropicResponse{
+		ID:    "msg_1",
+		Type:  "message",
+		Model: "claude",
+		Content: []AnthropicContentBlock{
+			{Type: "text", Text: "Let me "},
+			{Type: "text", Text: "check."},
+			{Type: "tool_use", ID: "toolu_1", Name: "weather", Input: json.RawMessage(`{"city":"Oslo"}`)},
+			{Type: "tool_use", ID: "toolu_2", Name: "time"},
+		},
+		StopReason: "tool_use",
+		Usage:      AnthropicUsage{InputTokens: 10, OutputTokens: 5},
+	})
+
+	if got.ID != "msg_1" || got.Model != "claude" || len(got.Choices) != 1 {
+		t.Fatalf("response = %+v", got)
+	}
+	choice := got.Choices[0]
+	if choice.Message.Content != "Let me check." || choice.FinishReason != "tool_calls" {
+		t.Errorf("choice = %+v", choice)
+	}
+	wantCalls := []ToolCall{
+		{ID: "toolu_1", Type: "function", Function: ToolCallFunc{Name: "weather", Arguments: `{"city":"Oslo"}`}},
+		{ID: "toolu_2", Type: "function", Function: ToolCallFunc{Name: "time", Arguments: "{}"}},
+	}
+	if !reflect.DeepEqual(choice.Message.ToolCalls, wantCalls) {
+		t.Errorf("tool calls = %+v, want %+v", choice.Message.ToolCalls, wantCalls)
+	}
+	if got.Usage.PromptTokens != 10 || got.Usage.CompletionTokens != 5 || got.Usage.TotalTokens != 15 {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+}
+
+func TestAnthropicGenerate(t *testing.T) {
+	p := anthropicServer(t, func(w http.ResponseWriter, req AnthropicRequest) {
+		if req.Model != "claude" || req.System != "be brief" || req.Stream {
+			t.Errorf("request = %+v", req)
+		}
+		io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"hello"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`)
+	})
+	client := p.Init()
+	resp, err := p.Generate(context.Background(), client, GenAIProviderRequest{
+		Model:    "claude",
+		Messages: []RequestMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Content != "hello" || resp.Choices[0].FinishReason != "stop" || resp.Usage.TotalTokens != 4 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestAnthropicGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		kind    error
+		message string
+	}{
+		{"bad request", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"messages: field required"}}`, ErrInvalidRequest, "messages: field required"},
+		{"context too long", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens"}}`, ErrContextLength, "prompt is too long: 210000 tokens"},
+		{"auth", 401, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, ErrAuthFailed, "invalid x-api-key"},
+		{"unknown model", 404, `{"type":"error","error":{"type":"not_found_error","message":"model: claude-x"}}`, ErrModelUnavailable, "model: claude-x"},
+		{"rate limited", 429, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, ErrRateLimited, "slow down"},
+		{"overloaded", 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, ErrServer, "Overloaded"},
+		{"plain body", 502, `bad gateway`, ErrServer, "bad gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := anthropicServer(t, func(w http.ResponseWriter, req AnthropicRequest) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+			_, err := p.Generate(context.Background(), p.Init(), GenAIProviderRequest{Model: "claude", Messages: []RequestMessage{{Role: "user", Content: "hi"}}})
+			var perr *ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.kind) || perr.StatusCode != tt.status || perr.Message != tt.message || perr.Provider != "anthropic" {
+				t.Errorf("error = %+v, want kind %v, status %d, message %q", perr, tt.kind, tt.status, tt.message)
+			}
+		})
+	}
+}
+
+// sse writes events in the Messages API stream format.
+func sse(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		var typed struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal([]byte(event), &typed)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typed.Type, event)
+	}
+}
+
+func collect(t *testing.T, chunks <-chan StreamChunk) []StreamChunk {
+	t.Helper()
+	var all []StreamChunk
+	for chunk := range chunks {
+		all = append(all, chunk)
+	}
+	return all
+}
+
+func TestAnthropicGenerateStream(t *testing.T) {
+	p := anthropicServer(t, func(w http.ResponseWriter, req AnthropicRequest) {
+		if !req.Stream {
+			t.Error("stream not requested")
+		}
+		sse(w,
+			`{"type":"message_start","message":{"id":"msg_1","type":"message","model":"claude","content":[],"usage":{"input_tokens":12,"output_tokens":0}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`,
+			`{"type":"ping"}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"weather","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Oslo\"}"}}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+			`{"type":"message_stop"}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"after stop"}}`,
+		)
+	})
+	chunks, err := p.GenerateStream(context.Background(), p.Init(), GenAIProviderRequest{Model: "claude", Messages: []RequestMessage{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StreamChunk{
+		{ID: "msg_1", Model: "claude"},
+		{Content: "Hel"},
+		{Content: "lo"},
+		{ToolCalls: []ToolCallDelta{{Index: 1, ID: "toolu_1", Type: "function", Function: ToolCallFunc{Name: "weather"}}}},
+		{ToolCalls: []ToolCallDelta{{Index: 1, Function: ToolCallFunc{Arguments: `{"city":`}}}},
+		{ToolCalls: []ToolCallDelta{{Index: 1, Function: ToolCallFunc{Arguments: `"Oslo"}`}}}},
+		{FinishReason: "tool_calls", Usage: &ResponseUsage{PromptTokens: 12, CompletionTokens: 7, TotalTokens: 19}},
+	}
+	if got := collect(t, chunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnthropicGenerateStreamErrorEvent(t *testing.T) {
+	tests := []struct {
+		errorType string
+		kind      error
+	}{
+		{"rate_limit_error", ErrRateLimited},
+		{"authentication_error", ErrAuthFailed},
+		{"permission_error", ErrAuthFailed},
+		{"invalid_request_error", ErrInvalidRequest},
+		{"overloaded_error", ErrServer},
+		{"api_error", ErrServer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.errorType, func(t *testing.T) {
+			p := anthropicServer(t, func(w http.ResponseWriter, req AnthropicRequest) {
+				sse(w,
+					`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"partial"}}`,
+					fmt.Sprintf(`{"type":"error","error":{"type":%q,"message":"stream failed"}}`, tt.errorType),
+				)
+			})
+			chunks, err := p.GenerateStream(context.Background(), p.Init(), GenAIProviderRequest{Model: "claude", Messages: []RequestMessage{{Role: "user", Content: "hi"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := collect(t, chunks)
+			if len(got) != 2 || got[0].Content != "partial" {
+				t.Fatalf("chunks = %+v, want the text then the error", got)
+			}
+			var perr *ProviderError
+			if !errors.As(got[1].Err, &perr) || !errors.Is(perr, tt.kind) || perr.Message != "stream failed" {
+				t.Errorf("error = %v, want kind %v", got[1].Err, tt.kind)
+			}
+		})
+	}
+}
+
+func TestAnthropicGenerateStreamMalformedEvent(t *testing.T) {
+	p := anthropicServer(t, func(w http.ResponseWriter, req AnthropicRequest) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "event: message_start\ndata: {not json\n\n")
+	})
+	chunks, err := p.GenerateStream(context.Background(), p.Init(), GenAIProviderRequest{Model: "claude", Messages: []RequestMessage{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := collect(t, chunks)
+	if len(got) != 1 || !errors.Is(got[0].Err, ErrMalformedResponse) {
+		t.Errorf("chunks = %+v, want one malformed response error", got)
+	}
+}
+
+func TestAnthropicGenerateStreamHTTPError(t *testing.T) {
+	p := anthropicServer(t, func(w http.ResponseWriter, req AnthropicRequest) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+	})
+	_, err := p.GenerateStream(context.Background(), p.Init(), GenAIProviderRequest{Model: "claude", Messages: []RequestMessage{{Role: "user", Content: "hi"}}})
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "slow down") {
+		t.Errorf("error = %v, want a rate limit error", err)
+	}
+}
@@ -0,0 +1,34 @@
+package providers
+
+import (
+	"net/http"
+	"sync"
+)
+
+// sharedClient decorates a provider so that all its callers share one http.Client,
+// built on first use. Keep-alive connections are reused from call to call, so Close
+// leaves them open.
+type sharedClient struct {
+	GenAIProvider
+	once   sync.Once
+	client *http.Client
+}
+
+// withSharedClient wraps provider so Init always returns the same client.
+func withSharedClient(provider GenAIProvider) GenAIProvider {
+	return &sharedClient{GenAIProvider: provider}
+}
+
+func (p *sharedClient) Init() *http.Client {
+	p.once.Do(func() {
+		p.client = p.GenAIProvider.Init()
+	})
+	return p.client
+}
+
+// Close keeps the shared client open for the next call.
+func (p *sharedClient) Close(client *http.Client) {}
+
+func (p *sharedClient) Unwrap() GenAIProvider {
+	return p.GenAIProvider
+}
@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewProviderSharesClient(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		io.WriteString(w, `{"id":"c1","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"hello"}}]}`)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	p, err := NewProvider("local", map[string]ProviderConfig{"local": {Type: "openai", BaseURL: server.URL, Auth: AuthConfig{Scheme: AuthNone}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := p.Init()
+	for i := 0; i < 3; i++ {
+		client := p.Init()
+		if client != first {
+			t.Fatal("Init built a new client")
+		}
+		req := GenAIProviderRequest{Model: "m", Messages: []RequestMessage{{Role: "user", Content: "hi"}}}
+		if _, err := p.Generate(context.Background(), client, req); err != nil {
+			t.Fatal(err)
+		}
+		p.Close(client)
+	}
+	if got := connections.Load(); got != 1 {
+		t.Errorf("calls opened %d connections, want 1 kept alive", got)
+	}
+}
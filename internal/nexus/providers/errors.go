@@ -0,0 +1,239 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error kinds reported by providers. Match them with errors.Is; the concrete error is
+// a *ProviderError carrying the provider name, HTTP status and API message.
+var (
+	ErrRateLimited       = errors.New("rate limited")
+	ErrAuthFailed        = errors.New("authentication failed")
+	ErrContextLength     = errors.New("context length exceeded")
+	ErrModelUnavailable  = errors.New("model unavailable")
+	ErrInvalidRequest    = errors.New("invalid request")
+	ErrServer            = errors.New("provider server error")
+	ErrUnavailable       = errors.New("provider unavailable")
+	ErrTimeout           = errors.New("timed out")
+	ErrMalformedResponse = errors.New("malformed provider response")
+)
+
+type ProviderError struct {
+	Provider   string
+	Kind       error
+	StatusCode int
+	Message    string
+	Err        error
+	// RetryAfter is the wait the provider asked for through its rate limit headers,
+	// zero when it gave none.
+	RetryAfter time.Duration
+}
+
+func (e *ProviderError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Provider)
+	b.WriteString(": ")
+	b.WriteString(e.Kind.Error())
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, " (status %d)", e.StatusCode)
+	}
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	} else if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *ProviderError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// apiErrorBody covers the error envelopes of OpenAI-compatible APIs and Anthropic.
+type apiErrorBody struct {
+	Error struct {
+		Message string      `json:"message"`
+		Type    string      `json:"type"`
+		Code    interface{} `json:"code"`
+	} `json:"error"`
+}
+
+// plainErrorBody is the error envelope of the Ollama native API.
+type plainErrorBody struct {
+	Error string `json:"error"`
+}
+
+var modelUnavailableHints = []string{
+	"model_not_found",
+	"model_decommissioned",
+	"has been decommissioned",
+	"does not exist",
+	"not found",
+}
+
+var contextLengthHints = []string{
+	"context_length_exceeded",
+	"context length",
+	"context window",
+	"maximum context",
+	"prompt is too long",
+	"too many tokens",
+}
+
+// newHTTPError classifies a non-2xx provider response.
+func newHTTPError(provider string, resp *http.Response, body []byte) *ProviderError {
+	perr := &ProviderError{Provider: provider, StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header)}
+
+	var apiErr apiErrorBody
+	var plainErr plainErrorBody
+	detail := ""
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+		perr.Message = apiErr.Error.Message
+		detail = strings.ToLower(fmt.Sprintf("%s %s %v", apiErr.Error.Message, apiErr.Error.Type, apiErr.Error.Code))
+	} else if json.Unmarshal(body, &plainErr) == nil && plainErr.Error != "" {
+		perr.Message = plainErr.Error
+		detail = strings.ToLower(plainErr.Error)
+	} else {
+		perr.Message = strings.TrimSpace(string(body))
+		detail = strings.ToLower(perr.Message)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || strings.Contains(detail, "rate_limit"):
+		perr.Kind = ErrRateLimited
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		perr.Kind = ErrAuthFailed
+	case resp.StatusCode == http.StatusNotFound || (resp.StatusCode < 500 && containsAny(detail, modelUnavailableHints)):
+		perr.Kind = ErrModelUnavailable
+	case resp.StatusCode == http.StatusRequestEntityTooLarge || containsAny(detail, contextLengthHints):
+		perr.Kind = ErrContextLength
+	case resp.StatusCode >= 500:
+		perr.Kind = ErrServer
+	default:
+		perr.Kind = ErrInvalidRequest
+	}
+	return perr
+}
+
+// newTransportError wraps failures to reach the provider at all. Deadlines, whether
+// from the request context or the client timeout, are reported as ErrTimeout.
+func newTransportError(provider string, err error) *ProviderError {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &ProviderError{Provider: provider, Kind: ErrTimeout, Err: err}
+	}
+	return &ProviderError{Provider: provider, Kind: ErrUnavailable, Err: err}
+}
+
+// newMalformedError reports a response that could not be understood.
+func newMalformedError(provider string, err error) *ProviderError {
+	return &ProviderError{Provider: provider, Kind: ErrMalformedResponse, Err: err}
+}
+
+// retryAfter reads the wait requested by a provider. The standard retry-after header
+// wins; otherwise the x-ratelimit-reset-* durations (as sent by Groq and OpenAI, e.g.
+// "7.66s" or "2m59.56s") are used, preferring the buckets whose matching
+// x-ratelimit-remaining-* header reports nothing left.
+func retryAfter(header http.Header) time.Duration {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			if wait := time.Until(at); wait > 0 {
+				return wait
+			}
+			return 0
+		}
+	}
+
+	var exhausted, longest time.Duration
+	for key, values := range header {
+		lower := strings.ToLower(key)
+		if !strings.HasPrefix(lower, "x-ratelimit-reset-") || len(values) == 0 {
+			continue
+		}
+		reset, ok := parseReset(values[0])
+		if !ok {
+			continue
+		}
+		if reset > longest {
+			longest = reset
+		}
+		bucket := strings.TrimPrefix(lower, "x-ratelimit-reset-")
+		if header.Get("x-ratelimit-remaining-"+bucket) == "0" && reset > exhausted {
+			exhausted = reset
+		}
+	}
+	if exhausted > 0 {
+		return exhausted
+	}
+	return longest
+}
+
+func parseReset(value string) (time.Duration, bool) {
+	if reset, err := time.ParseDuration(value); err == nil {
+		return reset, true
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// Retryable reports whether err is a transient provider failure worth another attempt.
+func Retryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServer) || errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout)
+}
+
+func containsAny(s string, needles []string) bool {
+	for _, needle := range needles {
+		if strings.Contains(s, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe renders a provider error as a short message suitable for end users.
+func Describe(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrRateLimited):
+		return "The model provider is rate limiting requests, please try again shortly."
+	case errors.Is(err, ErrAuthFailed):
+		return "The model provider rejected the credentials, check the API key configured for this workspace."
+	case errors.Is(err, ErrContextLength):
+		return "The conversation is too long for the model's context window."
+	case errors.Is(err, ErrModelUnavailable):
+		return "The configured model is not available from the provider, check the model name or configure a fallback."
+	case errors.Is(err, ErrServer):
+		return "The model provider returned a server error, please try again."
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "The model did not answer within the configured timeout."
+	case errors.Is(err, context.Canceled):
+		return "The request was cancelled."
+	case errors.Is(err, ErrUnavailable):
+		return "The model provider could not be reached."
+	case errors.Is(err, ErrMalformedResponse):
+		return "The model provider returned a response that could not be understood."
+	case errors.Is(err, ErrInvalidRequest):
+		return fmt.Sprintf("The model provider rejected the request: %v", err)
+	}
+	return err.Error()
+}
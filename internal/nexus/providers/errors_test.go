@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProviderErrorsNameTheConfiguredProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{"error":{"message":"overloaded"}}`)
+	}))
+	defer server.Close()
+
+	for _, kind := range []string{"groq", "openai", "anthropic", "ollama"} {
+		t.Run(kind, func(t *testing.T) {
+			alias := kind + "-fast"
+			cfg, factory, err := ResolveConfig(alias, map[string]ProviderConfig{alias: {Type: kind, Host: server.URL}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := factory(cfg)
+			req := GenAIProviderRequest{Model: "m", Messages: []RequestMessage{{Role: "user", Content: "hi"}}}
+
+			_, err = p.Generate(context.Background(), p.Init(), req)
+			var perr *ProviderError
+			if !errors.As(err, &perr) || perr.Provider != alias || !errors.Is(err, ErrServer) {
+				t.Errorf("Generate error = %#v, want a server error of %s", err, alias)
+			}
+			_, err = p.GenerateStream(context.Background(), p.Init(), req)
+			if !errors.As(err, &perr) || perr.Provider != alias {
+				t.Errorf("GenerateStream error = %#v, want an error of %s", err, alias)
+			}
+		})
+	}
+}
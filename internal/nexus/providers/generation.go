@@ -0,0 +1,27 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate rejects sampling parameters outside the ranges the providers accept.
+func (g GenerationConfig) Validate() error {
+	var errs []error
+	if g.Temperature != nil && (*g.Temperature < 0 || *g.Temperature > 2) {
+		errs = append(errs, fmt.Errorf("temperature %v is outside [0, 2]", *g.Temperature))
+	}
+	if g.TopP != nil && (*g.TopP <= 0 || *g.TopP > 1) {
+		errs = append(errs, fmt.Errorf("top_p %v is outside (0, 1]", *g.TopP))
+	}
+	if g.MaxTokens != nil && *g.MaxTokens <= 0 {
+		errs = append(errs, fmt.Errorf("max_tokens must be positive, got %d", *g.MaxTokens))
+	}
+	if g.FrequencyPenalty != nil && (*g.FrequencyPenalty < -2 || *g.FrequencyPenalty > 2) {
+		errs = append(errs, fmt.Errorf("frequency_penalty %v is outside [-2, 2]", *g.FrequencyPenalty))
+	}
+	if g.PresencePenalty != nil && (*g.PresencePenalty < -2 || *g.PresencePenalty > 2) {
+		errs = append(errs, fmt.Errorf("presence_penalty %v is outside [-2, 2]", *g.PresencePenalty))
+	}
+	return errors.Join(errs...)
+}
@@ -1,69 +1,46 @@
 package providers
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"log/slog"
 	"net/http"
-	"os"
 )
 
 type GroqProvider struct {
-	Host string
+	ProviderConfig
+}
+
+func init() {
+	Register("groq", ProviderConfig{
+		Host:             "https://api.groq.com/openai",
+		HostEnv:          "GROQ_HOST",
+		APIKeyEnv:        "GROQ_TOKEN",
+		StructuredOutput: boolPtr(true),
+		ReasoningFormat:  boolPtr(true),
+	}, func(cfg ProviderConfig) GenAIProvider { return GroqProvider{cfg} })
 }
 
 func (p GroqProvider) Init() *http.Client {
-	client := http.Client{}
+	client := http.Client{Timeout: p.Timeout}
 	return &client
 }
 
-func (p GroqProvider) Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
-
-	url := fmt.Sprintf("%s/v1/chat/completions", p.Host)
-	token := fmt.Sprintf("Bearer %s", os.Getenv("GROQ_TOKEN"))
-
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(req)
-	if err != nil {
-		slog.Info("%s", err)
-	}
-
-	//slog.Info(req)
-	req_obj, err := http.NewRequest(http.MethodPost, url, &buf)
-
-	if err != nil {
-		panic(fmt.Sprintf("Error creating request:", err))
-
-	}
-	req_obj.Header.Set("Content-Type", "application/json")
-	req_obj.Header.Set("Authorization", token)
-
-	if err != nil {
-		slog.Error(err.Error())
-	}
-	resp, err := client.Do(req_obj)
-	if err != nil {
-		slog.Error(err.Error())
-	}
-
-	bytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		slog.Error(err.Error())
+func (p GroqProvider) headers() map[string]string {
+	headers := map[string]string{"Authorization": fmt.Sprintf("Bearer %s", p.APIKey())}
+	for key, value := range p.Headers {
+		headers[key] = value
 	}
+	return headers
+}
 
-	// Unmarshal the response body into the struct
-	var result GenAIProviderResponse
-
-	err = json.Unmarshal(bytes, &result)
-	if err != nil {
-		slog.Error("Error unmarshaling response")
-	}
-	//slog.Info(result.Message.ToolCall)
-	return &result, nil
+func (p GroqProvider) Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+	url := fmt.Sprintf("%s/v1/chat/completions", p.Host)
+	return generateOpenAICompatible(ctx, client, p.ProviderConfig, url, p.headers(), req)
+}
 
+func (p GroqProvider) GenerateStream(ctx context.Context, client *http.Client, req GenAIProviderRequest) (<-chan StreamChunk, error) {
+	url := fmt.Sprintf("%s/v1/chat/completions", p.Host)
+	return streamOpenAICompatible(ctx, client, p.ProviderConfig, url, p.headers(), req)
 }
 
 func (p GroqProvider) Close(client *http.Client) {
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ImageDataURL encodes image bytes as a base64 data URL, the form images travel in on
+// a RequestMessage. Data that is not a recognised image format is rejected.
+func ImageDataURL(data []byte) (string, error) {
+	mediaType := http.DetectContentType(data)
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("unsupported image type %s", mediaType)
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mediaType, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// splitDataURL returns the media type and base64 payload of a base64 data URL.
+func splitDataURL(image string) (mediaType string, data string, ok bool) {
+	rest, found := strings.CutPrefix(image, "data:")
+	if !found {
+		return "", "", false
+	}
+	header, data, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+	mediaType, found = strings.CutSuffix(header, ";base64")
+	if !found {
+		return "", "", false
+	}
+	return mediaType, data, true
+}
+
+// MarshalJSON writes the message in the OpenAI chat format. Messages with images send
+// their content as text and image_url parts.
+func (m RequestMessage) MarshalJSON() ([]byte, error) {
+	type plain RequestMessage
+	if len(m.Image) == 0 {
+		return json.Marshal(plain(m))
+	}
+
+	parts := make([]ContentPart, 0, len(m.Image)+1)
+	if m.Content != "" {
+		parts = append(parts, ContentPart{Type: "text", Text: m.Content})
+	}
+	for _, image := range m.Image {
+		parts = append(parts, ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: image}})
+	}
+	return json.Marshal(struct {
+		plain
+		Content []ContentPart `json:"content"`
+	}{plain(m), parts})
+}
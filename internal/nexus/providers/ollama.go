@@ -1,61 +1,549 @@
 package providers
 
 import (
-	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Ollama API modes.
+const (
+	OllamaAPIOpenAI = "openai"
+	OllamaAPINative = "native"
+)
+
+// tagsTimeout bounds the lookup of the models available on an Ollama host.
+const tagsTimeout = 30 * time.Second
+
+// maxImageBytes bounds the images fetched from URLs for the native API.
+const maxImageBytes = 20 << 20
+
+// OllamaConfig holds the settings specific to Ollama hosts.
+type OllamaConfig struct {
+	// API selects the endpoint: openai (/v1/chat/completions, the default) or native
+	// (/api/chat). Options, keep_alive and the native format need the native API.
+	API string `yaml:"api,omitempty"`
+	// Options are sent as the options of native requests, e.g. num_ctx, temperature
+	// or seed.
+	Options map[string]interface{} `yaml:"options,omitempty"`
+	// KeepAlive sets how long the host keeps the model loaded after a native request,
+	// as a duration such as "10m", 0 to unload at once or -1 to keep it loaded.
+	KeepAlive string `yaml:"keep_alive,omitempty"`
+	// Pull downloads the models the workspace references but the host lacks when the
+	// workspace starts. It is on unless set to false.
+	Pull *bool `yaml:"pull,omitempty"`
+}
+
+func (o OllamaConfig) merge(override OllamaConfig) OllamaConfig {
+	if override.API != "" {
+		o.API = override.API
+	}
+	if len(override.Options) > 0 {
+		options := make(map[string]interface{}, len(o.Options)+len(override.Options))
+		for key, value := range o.Options {
+			options[key] = value
+		}
+		for key, value := range override.Options {
+			options[key] = value
+		}
+		o.Options = options
+	}
+	if override.KeepAlive != "" {
+		o.KeepAlive = override.KeepAlive
+	}
+	if override.Pull != nil {
+		o.Pull = override.Pull
+	}
+	return o
+}
+
+// set reports whether any Ollama setting is given.
+func (o OllamaConfig) set() bool {
+	return o.API != "" || len(o.Options) > 0 || o.KeepAlive != "" || o.Pull != nil
+}
+
+func (o OllamaConfig) validate() error {
+	switch o.API {
+	case "", OllamaAPIOpenAI, OllamaAPINative:
+		return nil
+	}
+	return fmt.Errorf("unknown ollama api %q (expected %s or %s)", o.API, OllamaAPIOpenAI, OllamaAPINative)
+}
+
+// keepAlive returns keep_alive as sent to Ollama, which reads bare numbers as seconds
+// and strings as durations.
+func (o OllamaConfig) keepAlive() interface{} {
+	if o.KeepAlive == "" {
+		return nil
+	}
+	if seconds, err := strconv.ParseFloat(o.KeepAlive, 64); err == nil {
+		return seconds
+	}
+	return o.KeepAlive
+}
+
 type OllamaProvider struct {
-	Host string
+	ProviderConfig
+}
+
+func init() {
+	Register("ollama", ProviderConfig{
+		Host:             "http://localhost:11434",
+		HostEnv:          "OLLAMA_HOST",
+		StructuredOutput: boolPtr(true),
+	}, func(cfg ProviderConfig) GenAIProvider { return OllamaProvider{cfg} })
 }
 
 func (p OllamaProvider) Init() *http.Client {
-	client := http.Client{}
+	client := http.Client{Timeout: p.Timeout}
 	slog.Info(fmt.Sprintf("Provider client initialised on %s", p.Host))
 	return &client
 
 }
 
+// headers adds a bearer token only when an API key is configured, e.g. for an Ollama
+// instance behind an authenticating proxy.
+func (p OllamaProvider) headers() map[string]string {
+	headers := map[string]string{}
+	if key := p.APIKey(); key != "" {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", key)
+	}
+	for key, value := range p.Headers {
+		headers[key] = value
+	}
+	return headers
+}
+
+func (p OllamaProvider) native() bool {
+	return p.Ollama.API == OllamaAPINative
+}
+
 func (p OllamaProvider) Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+	if p.native() {
+		return p.generateNative(ctx, client, req)
+	}
+	url := fmt.Sprintf("%s/v1/chat/completions", p.Host)
+	return generateOpenAICompatible(ctx, client, p.ProviderConfig, url, p.headers(), req)
+}
 
+func (p OllamaProvider) GenerateStream(ctx context.Context, client *http.Client, req GenAIProviderRequest) (<-chan StreamChunk, error) {
+	if p.native() {
+		return p.streamNative(ctx, client, req)
+	}
 	url := fmt.Sprintf("%s/v1/chat/completions", p.Host)
+	return streamOpenAICompatible(ctx, client, p.ProviderConfig, url, p.headers(), req)
+}
+
+func (p OllamaProvider) Close(client *http.Client) {
+	client.CloseIdleConnections()
+	slog.Info("Provider client released.")
+}
+
+// chatRequest converts req to the body of /api/chat, fetching the images given as URLs
+// with client.
+func (p OllamaProvider) chatRequest(ctx context.Context, client *http.Client, req GenAIProviderRequest, stream bool) (OllamaChatRequest, error) {
+	messages := make([]OllamaMessage, 0, len(req.Messages))
+	// Tool messages name their tool rather than the call they answer.
+	toolNames := map[string]string{}
+	for _, message := range req.Messages {
+		images, err := p.images(ctx, client, message.Image)
+		if err != nil {
+			return OllamaChatRequest{}, err
+		}
+		native := OllamaMessage{Role: message.Role, Content: message.Content, Images: images}
+		for _, call := range message.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+			var nativeCall OllamaToolCall
+			nativeCall.Function.Name = call.Function.Name
+			nativeCall.Function.Arguments = toolArguments(call.Function.Arguments)
+			native.ToolCalls = append(native.ToolCalls, nativeCall)
+		}
+		if message.ToolCallID != "" {
+			native.ToolName = toolNames[message.ToolCallID]
+		}
+		messages = append(messages, native)
+	}
+	return OllamaChatRequest{
+		Model:     req.Model,
+		Messages:  messages,
+		Tools:     req.Tools,
+		Format:    ollamaFormat(req.ResponseFormat),
+		Options:   p.options(req.GenerationConfig),
+		KeepAlive: p.Ollama.keepAlive(),
+		Stream:    stream,
+	}, nil
+}
+
+// options layers the sampling parameters of a request over the configured options,
+// using the native option names.
+func (p OllamaProvider) options(generation GenerationConfig) map[string]interface{} {
+	options := make(map[string]interface{}, len(p.Ollama.Options))
+	for key, value := range p.Ollama.Options {
+		options[key] = value
+	}
+	set := func(key string, value interface{}, ok bool) {
+		if ok {
+			options[key] = value
+		}
+	}
+	set("temperature", generation.Temperature, generation.Temperature != nil)
+	set("top_p", generation.TopP, generation.TopP != nil)
+	set("num_predict", generation.MaxTokens, generation.MaxTokens != nil)
+	set("stop", generation.Stop, len(generation.Stop) > 0)
+	set("seed", generation.Seed, generation.Seed != nil)
+	set("frequency_penalty", generation.FrequencyPenalty, generation.FrequencyPenalty != nil)
+	set("presence_penalty", generation.PresencePenalty, generation.PresencePenalty != nil)
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// images converts images to the bare base64 the native API takes. Data URLs lose their
+// header and http(s) URLs are fetched, since Ollama does not fetch images itself. An
+// image that cannot be sent fails the request rather than going missing.
+func (p OllamaProvider) images(ctx context.Context, client *http.Client, images []string) ([]string, error) {
+	var encoded []string
+	for _, image := range images {
+		if _, data, ok := splitDataURL(image); ok {
+			encoded = append(encoded, data)
+			continue
+		}
+		if !strings.HasPrefix(image, "http://") && !strings.HasPrefix(image, "https://") {
+			return nil, &ProviderError{Provider: p.Name, Kind: ErrInvalidRequest, Message: "images must be base64 data URLs or http(s) URLs"}
+		}
+		data, err := fetchImage(ctx, client, image)
+		if err != nil {
+			return nil, &ProviderError{Provider: p.Name, Kind: ErrInvalidRequest, Message: fmt.Sprintf("fetching image %s", image), Err: err}
+		}
+		encoded = append(encoded, base64.StdEncoding.EncodeToString(data))
+	}
+	return encoded, nil
+}
 
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(req)
+// fetchImage downloads the image at url. The provider headers are not sent, as the
+// image may be hosted anywhere.
+func fetchImage(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req_obj, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		slog.Info("%s", err)
+		return nil, err
+	}
+	resp, err := client.Do(req_obj)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
 	}
-	req_obj, err := http.NewRequest(http.MethodPost, url, &buf)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image is larger than %s", formatBytes(maxImageBytes))
+	}
+	if mediaType := http.DetectContentType(data); !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("unsupported image type %s", mediaType)
+	}
+	return data, nil
+}
 
+// ollamaFormat maps a response format to the native format field: the bare schema
+// for json_schema and "json" for JSON mode.
+func ollamaFormat(format interface{}) interface{} {
+	responseFormat, ok := format.(*ResponseFormat)
+	if !ok || responseFormat == nil {
+		return nil
+	}
+	switch responseFormat.Type {
+	case "json_schema":
+		if responseFormat.JSONSchema != nil {
+			return responseFormat.JSONSchema.Schema
+		}
+		return "json"
+	case "json_object":
+		return "json"
+	}
+	return nil
+}
+
+// toolCalls converts native tool calls, which carry no ID and arguments as an object.
+// first numbers the calls of a stream across lines.
+func (m OllamaMessage) toolCalls(first int) []ToolCall {
+	var calls []ToolCall
+	for i, call := range m.ToolCalls {
+		arguments := string(call.Function.Arguments)
+		if arguments == "" || arguments == "null" {
+			arguments = "{}"
+		}
+		calls = append(calls, ToolCall{
+			ID:       fmt.Sprintf("call_%d", first+i),
+			Type:     "function",
+			Function: ToolCallFunc{Name: call.Function.Name, Arguments: arguments},
+		})
+	}
+	return calls
+}
+
+func (r OllamaChatResponse) usage() ResponseUsage {
+	return ResponseUsage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+		TotalTime:        time.Duration(r.TotalDuration).Seconds(),
+	}
+}
+
+func (r OllamaChatResponse) finishReason(toolCalls bool) string {
+	switch {
+	case toolCalls:
+		return "tool_calls"
+	case r.DoneReason != "":
+		return r.DoneReason
+	case r.Done:
+		return "stop"
+	}
+	return ""
+}
+
+func (p OllamaProvider) generateNative(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+	chat, err := p.chatRequest(ctx, client, req, false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := postJSON(ctx, client, p.Name, p.Host+"/api/chat", p.headers(), chat)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newTransportError(p.Name, err)
+	}
+
+	var result OllamaChatResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		slog.Error("Error unmarshaling response", "provider", p.Name, "error", err)
+		return nil, newMalformedError(p.Name, err)
+	}
+	if result.Error != "" {
+		return nil, &ProviderError{Provider: p.Name, Kind: ErrServer, Message: result.Error}
+	}
+
+	toolCalls := result.Message.toolCalls(0)
+	return &GenAIProviderResponse{
+		Object: "chat.completion",
+		Model:  result.Model,
+		Choices: []ResponseChoice{{
+			Message: ResponseMessage{
+				Role:      "assistant",
+				Content:   result.Message.Content,
+				Thought:   result.Message.Thinking,
+				ToolCalls: toolCalls,
+			},
+			FinishReason: result.finishReason(len(toolCalls) > 0),
+		}},
+		Usage: result.usage(),
+	}, nil
+}
+
+// streamNative reads the newline delimited JSON stream of /api/chat.
+func (p OllamaProvider) streamNative(ctx context.Context, client *http.Client, req GenAIProviderRequest) (<-chan StreamChunk, error) {
+	chat, err := p.chatRequest(ctx, client, req, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := postJSON(ctx, client, p.Name, p.Host+"/api/chat", p.headers(), chat)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		calls := 0
+		err := readNDJSON(resp.Body, func(line []byte) error {
+			var chunk OllamaChatResponse
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				return newMalformedError(p.Name, fmt.Errorf("decoding stream chunk: %w", err))
+			}
+			if chunk.Error != "" {
+				return &ProviderError{Provider: p.Name, Kind: ErrServer, Message: chunk.Error}
+			}
+
+			sc := StreamChunk{Model: chunk.Model, Content: chunk.Message.Content, Thought: chunk.Message.Thinking}
+			for i, call := range chunk.Message.toolCalls(calls) {
+				sc.ToolCalls = append(sc.ToolCalls, ToolCallDelta{Index: calls + i, ID: call.ID, Type: call.Type, Function: call.Function})
+			}
+			calls += len(chunk.Message.ToolCalls)
+			if chunk.Done {
+				usage := chunk.usage()
+				sc.Usage = &usage
+				sc.FinishReason = chunk.finishReason(calls > 0)
+			}
+			if !sendChunk(ctx, out, sc) {
+				return ctx.Err()
+			}
+			if chunk.Done {
+				return io.EOF
+			}
+			return nil
+		})
+		if err != nil && err != io.EOF {
+			if _, ok := err.(*ProviderError); !ok && ctx.Err() == nil {
+				err = newTransportError(p.Name, err)
+			}
+			sendChunk(ctx, out, StreamChunk{Err: err})
+		}
+	}()
+
+	return out, nil
+}
+
+// EnsureModels pulls the models missing from the Ollama host. Missing models are only
+// reported when pulling is disabled. Pulls run without the generation timeout.
+func (p OllamaProvider) EnsureModels(ctx context.Context, models []string, progress func(string)) error {
+	client := &http.Client{}
+	available, err := p.localModels(ctx, client)
 	if err != nil {
-		slog.Error(err.Error())
+		return err
+	}
+
+	var errs []error
+	for _, model := range models {
+		if available[ollamaModelName(model)] {
+			continue
+		}
+		if p.Ollama.Pull != nil && !*p.Ollama.Pull {
+			progress(fmt.Sprintf("%s: model %s is missing and pulling is disabled", p.Name, model))
+			continue
+		}
+		if err := p.pull(ctx, client, model, progress); err != nil {
+			errs = append(errs, fmt.Errorf("pulling %s: %w", model, err))
+			continue
+		}
+		available[ollamaModelName(model)] = true
+	}
+	return errors.Join(errs...)
+}
+
+// ollamaModelName adds the implicit latest tag, the way Ollama names local models.
+func ollamaModelName(model string) string {
+	if !strings.Contains(model[strings.LastIndex(model, "/")+1:], ":") {
+		return model + ":latest"
+	}
+	return model
+}
+
+// localModels lists the models available on the host via /api/tags.
+func (p OllamaProvider) localModels(ctx context.Context, client *http.Client) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, tagsTimeout)
+	defer cancel()
+
+	req_obj, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Host+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: creating request: %w", p.Name, err)
+	}
+	for key, value := range p.headers() {
+		req_obj.Header.Set(key, value)
 	}
 	resp, err := client.Do(req_obj)
 	if err != nil {
-		slog.Error(err.Error())
+		return nil, newTransportError(p.Name, err)
 	}
+	defer resp.Body.Close()
 
-	bytes, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		slog.Error(err.Error())
+		return nil, newTransportError(p.Name, err)
 	}
-	// Unmarshal the response body into the struct
-	var result GenAIProviderResponse
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newHTTPError(p.Name, resp, body)
+	}
+
+	var tags OllamaTagsResponse
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return nil, newMalformedError(p.Name, err)
+	}
+	available := make(map[string]bool, len(tags.Models))
+	for _, model := range tags.Models {
+		available[ollamaModelName(model.Name)] = true
+		if model.Model != "" {
+			available[ollamaModelName(model.Model)] = true
+		}
+	}
+	return available, nil
+}
+
+// pull downloads model through /api/pull, reporting each new step and every tenth of
+// a layer downloaded.
+func (p OllamaProvider) pull(ctx context.Context, client *http.Client, model string, progress func(string)) error {
+	slog.Info("Pulling model", "provider", p.Name, "model", model)
+	progress(fmt.Sprintf("%s: pulling %s", p.Name, model))
 
-	err = json.Unmarshal(bytes, &result)
+	resp, err := postJSON(ctx, client, p.Name, p.Host+"/api/pull", p.headers(), OllamaPullRequest{Model: model, Stream: true})
 	if err != nil {
-		slog.Error("Error unmarshaling response")
+		return err
 	}
-	return &result, nil
+	defer resp.Body.Close()
+
+	lastStatus, lastStep := "", -1
+	err = readNDJSON(resp.Body, func(line []byte) error {
+		var update OllamaPullProgress
+		if err := json.Unmarshal(line, &update); err != nil {
+			return newMalformedError(p.Name, fmt.Errorf("decoding pull progress: %w", err))
+		}
+		if update.Error != "" {
+			return &ProviderError{Provider: p.Name, Kind: ErrModelUnavailable, Message: update.Error}
+		}
+
+		step := -1
+		if update.Total > 0 {
+			step = int(update.Completed * 10 / update.Total)
+		}
+		if update.Status == lastStatus && step == lastStep {
+			return nil
+		}
+		lastStatus, lastStep = update.Status, step
 
+		message := fmt.Sprintf("%s: %s %s", p.Name, model, update.Status)
+		if step >= 0 {
+			message += fmt.Sprintf(" %d%% of %s", step*10, formatBytes(update.Total))
+		}
+		progress(message)
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(*ProviderError); !ok {
+			err = newTransportError(p.Name, err)
+		}
+		return err
+	}
+	slog.Info("Pulled model", "provider", p.Name, "model", model)
+	return nil
 }
 
-func (p OllamaProvider) Close(client *http.Client) {
-	client.CloseIdleConnections()
-	slog.Info("Provider client released.")
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "kMGT"[exp])
 }
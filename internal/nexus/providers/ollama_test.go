@@ -0,0 +1,353 @@
+package providers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// pngImage starts with the PNG signature, enough to be detected as an image.
+var pngImage = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// ollamaHost stands in for an Ollama host. It answers /api/chat with chat, lists
+// tags on /api/tags, streams pull for /api/pull and serves pngImage on /image.png.
+type ollamaHost struct {
+	t      *testing.T
+	server *httptest.Server
+	chat   string
+	tags   []string
+	pull   map[string]string
+
+	mu      sync.Mutex
+	request OllamaChatRequest
+	pulled  []string
+	paths   []string
+}
+
+func newOllamaHost(t *testing.T) *ollamaHost {
+	t.Helper()
+	h := &ollamaHost{t: t}
+	h.server = httptest.NewServer(http.HandlerFunc(h.serve))
+	t.Cleanup(h.server.Close)
+	return h
+}
+
+func (h *ollamaHost) serve(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.paths = append(h.paths, r.URL.Path)
+	switch r.URL.Path {
+	case "/api/chat":
+		if err := json.NewDecoder(r.Body).Decode(&h.request); err != nil {
+			h.t.Errorf("decoding chat request: %v", err)
+		}
+		io.WriteString(w, h.chat)
+	case "/api/tags":
+		var tags OllamaTagsResponse
+		for _, name := range h.tags {
+			tags.Models = append(tags.Models, OllamaModel{Name: name})
+		}
+		json.NewEncoder(w).Encode(tags)
+	case "/api/pull":
+		var pull OllamaPullRequest
+		json.NewDecoder(r.Body).Decode(&pull)
+		h.pulled = append(h.pulled, pull.Model)
+		io.WriteString(w, h.pull[pull.Model])
+	case "/image.png":
+		w.Write(pngImage)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// provider is a native mode provider of the host with the given settings.
+func (h *ollamaHost) provider(settings OllamaConfig) OllamaProvider {
+	h.t.Helper()
+	settings.API = OllamaAPINative
+	cfg, _, err := ResolveConfig("gpu", map[string]ProviderConfig{"gpu": {Type: "ollama", Host: h.server.URL, Ollama: settings}})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return OllamaProvider{cfg}
+}
+
+func TestOllamaKeepAlive(t *testing.T) {
+	tests := []struct {
+		keepAlive string
+		want      interface{}
+	}{
+		{"", nil},
+		{"10m", "10m"},
+		{"300", 300.0},
+		{"0", 0.0},
+		{"-1", -1.0},
+	}
+	for _, tt := range tests {
+		if got := (OllamaConfig{KeepAlive: tt.keepAlive}).keepAlive(); got != tt.want {
+			t.Errorf("keepAlive(%q) = %#v, want %#v", tt.keepAlive, got, tt.want)
+		}
+	}
+}
+
+func TestOllamaNativeRequest(t *testing.T) {
+	h := newOllamaHost(t)
+	h.chat = `{"model":"llava","message":{"role":"assistant","content":"a cat"},"done":true}`
+	p := h.provider(OllamaConfig{KeepAlive: "10m", Options: map[string]interface{}{"num_ctx": 8192, "temperature": 0.9}})
+
+	temperature, maxTokens := 0.2, 64
+	inline := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("inline"))
+	req := GenAIProviderRequest{
+		Model: "llava",
+		Messages: []RequestMessage{
+			{Role: "system", Content: "describe"},
+			{Role: "user", Content: "what is this?", Image: []string{inline, h.server.URL + "/image.png"}},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: ToolCallFunc{Name: "zoom", Arguments: `{"x":1}`}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "zoomed"},
+		},
+		ResponseFormat:   JSONSchemaFormat("reply", &struct{ Answer string }{}),
+		GenerationConfig: GenerationConfig{Temperature: &temperature, MaxTokens: &maxTokens},
+	}
+	if _, err := p.Generate(context.Background(), p.Init(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := h.request
+	if got.Model != "llava" || got.Stream || got.KeepAlive != "10m" {
+		t.Errorf("model %q, stream %v, keep_alive %v", got.Model, got.Stream, got.KeepAlive)
+	}
+	wantImages := []string{base64.StdEncoding.EncodeToString([]byte("inline")), base64.StdEncoding.EncodeToString(pngImage)}
+	if !reflect.DeepEqual(got.Messages[1].Images, wantImages) {
+		t.Errorf("images = %q, want the inline image and the fetched one", got.Messages[1].Images)
+	}
+	if calls := got.Messages[2].ToolCalls; len(calls) != 1 || calls[0].Function.Name != "zoom" || string(calls[0].Function.Arguments) != `{"x":1}` {
+		t.Errorf("tool calls = %+v", calls)
+	}
+	if got.Messages[3].ToolName != "zoom" {
+		t.Errorf("tool message names %q, want zoom", got.Messages[3].ToolName)
+	}
+	// Generation settings win over the configured options
+	wantOptions := map[string]interface{}{"num_ctx": 8192.0, "temperature": 0.2, "num_predict": 64.0}
+	if !reflect.DeepEqual(got.Options, wantOptions) {
+		t.Errorf("options = %v, want %v", got.Options, wantOptions)
+	}
+	if format, ok := got.Format.(map[string]interface{}); !ok || format["type"] != "object" {
+		t.Errorf("format = %v, want the schema", got.Format)
+	}
+}
+
+func TestOllamaNativeJSONMode(t *testing.T) {
+	h := newOllamaHost(t)
+	h.chat = `{"message":{"role":"assistant","content":"{}"},"done":true}`
+	p := h.provider(OllamaConfig{})
+	req := GenAIProviderRequest{Model: "m", ResponseFormat: &ResponseFormat{Type: "json_object"}}
+	if _, err := p.Generate(context.Background(), p.Init(), req); err != nil {
+		t.Fatal(err)
+	}
+	if h.request.Format != "json" || h.request.KeepAlive != nil || h.request.Options != nil {
+		t.Errorf("format %v, keep_alive %v, options %v", h.request.Format, h.request.KeepAlive, h.request.Options)
+	}
+}
+
+func TestOllamaNativeImageErrors(t *testing.T) {
+	h := newOllamaHost(t)
+	p := h.provider(OllamaConfig{})
+	for _, image := range []string{h.server.URL + "/missing.png", h.server.URL + "/api/tags", "file:///etc/passwd"} {
+		req := GenAIProviderRequest{Model: "m", Messages: []RequestMessage{{Role: "user", Content: "look", Image: []string{image}}}}
+		_, err := p.Generate(context.Background(), p.Init(), req)
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("image %s: error = %v, want an invalid request", image, err)
+		}
+		_, err = p.GenerateStream(context.Background(), p.Init(), req)
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("image %s: stream error = %v, want an invalid request", image, err)
+		}
+	}
+	for _, path := range h.paths {
+		if path == "/api/chat" {
+			t.Error("chat request sent without its image")
+		}
+	}
+}
+
+func TestOllamaNativeResponse(t *testing.T) {
+	h := newOllamaHost(t)
+	h.chat = `{"model":"qwen","message":{"role":"assistant","content":"","thinking":"hmm","tool_calls":[{"function":{"name":"search","arguments":{"q":"go"}}},{"function":{"name":"now"}}]},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":5}`
+	p := h.provider(OllamaConfig{})
+
+	resp, err := p.Generate(context.Background(), p.Init(), GenAIProviderRequest{Model: "qwen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	choice := resp.Choices[0]
+	wantCalls := []ToolCall{
+		{ID: "call_0", Type: "function", Function: ToolCallFunc{Name: "search", Arguments: `{"q":"go"}`}},
+		{ID: "call_1", Type: "function", Function: ToolCallFunc{Name: "now", Arguments: "{}"}},
+	}
+	if !reflect.DeepEqual(choice.Message.ToolCalls, wantCalls) || choice.Message.Thought != "hmm" {
+		t.Errorf("message = %+v", choice.Message)
+	}
+	if choice.FinishReason != "tool_calls" || resp.Usage.TotalTokens != 17 || resp.Model != "qwen" {
+		t.Errorf("finish reason %q, usage %+v, model %q", choice.FinishReason, resp.Usage, resp.Model)
+	}
+
+	h.chat = `{"error":"model crashed"}`
+	_, err = p.Generate(context.Background(), p.Init(), GenAIProviderRequest{Model: "qwen"})
+	var perr *ProviderError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrServer) || perr.Message != "model crashed" || perr.Provider != "gpu" {
+		t.Errorf("error = %v, want the server error of gpu", err)
+	}
+}
+
+func TestOllamaNativeStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantContent string
+		wantErr     error
+	}{
+		{
+			name: "complete",
+			body: `{"message":{"role":"assistant","content":"hel"},"done":false}` + "\n" +
+				`{"message":{"role":"assistant","content":"lo","tool_calls":[{"function":{"name":"f","arguments":{}}}]},"done":false}` + "\n" +
+				`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":3,"eval_count":2}` + "\n" +
+				`{"message":{"role":"assistant","content":"after done"},"done":false}` + "\n",
+			wantContent: "hello",
+		},
+		{
+			name:        "error line",
+			body:        `{"message":{"role":"assistant","content":"hel"},"done":false}` + "\n" + `{"error":"out of memory"}` + "\n",
+			wantContent: "hel",
+			wantErr:     ErrServer,
+		},
+		{
+			name:        "truncated",
+			body:        `{"message":{"role":"assistant","content":"hel"},"done":false}` + "\n" + `{"message":{"role":"assis`,
+			wantContent: "hel",
+			wantErr:     ErrMalformedResponse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newOllamaHost(t)
+			h.chat = tt.body
+			p := h.provider(OllamaConfig{})
+			chunks, err := p.GenerateStream(context.Background(), p.Init(), GenAIProviderRequest{Model: "m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !h.request.Stream {
+				t.Error("stream not requested")
+			}
+
+			var acc StreamAccumulator
+			var streamErr error
+			for _, chunk := range collect(t, chunks) {
+				if chunk.Err != nil {
+					streamErr = chunk.Err
+					continue
+				}
+				acc.Add(chunk)
+			}
+			resp := acc.Response()
+			if content := resp.Choices[0].Message.Content; content != tt.wantContent {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(streamErr, tt.wantErr) {
+					t.Errorf("stream error = %v, want %v", streamErr, tt.wantErr)
+				}
+				return
+			}
+			if streamErr != nil {
+				t.Fatal(streamErr)
+			}
+			if calls := resp.Choices[0].Message.ToolCalls; len(calls) != 1 || calls[0].ID != "call_0" {
+				t.Errorf("tool calls = %+v", calls)
+			}
+			if resp.Choices[0].FinishReason != "tool_calls" || resp.Usage.TotalTokens != 5 {
+				t.Errorf("finish reason %q, usage %+v", resp.Choices[0].FinishReason, resp.Usage)
+			}
+		})
+	}
+}
+
+func TestOllamaEnsureModels(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name         string
+		pull         *bool
+		pullBody     map[string]string
+		wantPulled   []string
+		wantProgress []string
+		wantErr      error
+	}{
+		{
+			name: "pulls missing models",
+			pullBody: map[string]string{"qwen:7b": `{"status":"pulling manifest"}` + "\n" +
+				`{"status":"downloading","total":1000,"completed":100}` + "\n" +
+				`{"status":"downloading","total":1000,"completed":150}` + "\n" +
+				`{"status":"downloading","total":1000,"completed":1000}` + "\n" +
+				`{"status":"success"}` + "\n"},
+			wantPulled: []string{"qwen:7b"},
+			wantProgress: []string{
+				"gpu: pulling qwen:7b",
+				"gpu: qwen:7b pulling manifest",
+				"gpu: qwen:7b downloading 10% of 1.0 kB",
+				"gpu: qwen:7b downloading 100% of 1.0 kB",
+				"gpu: qwen:7b success",
+			},
+		},
+		{
+			name:         "pulling disabled",
+			pull:         &disabled,
+			wantProgress: []string{"gpu: model qwen:7b is missing and pulling is disabled"},
+		},
+		{
+			name:         "pull fails",
+			pullBody:     map[string]string{"qwen:7b": `{"status":"pulling manifest"}` + "\n" + `{"error":"pull model manifest: file does not exist"}` + "\n"},
+			wantPulled:   []string{"qwen:7b"},
+			wantProgress: []string{"gpu: pulling qwen:7b", "gpu: qwen:7b pulling manifest"},
+			wantErr:      ErrModelUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newOllamaHost(t)
+			// llama3 is there under its implicit tag
+			h.tags = []string{"llama3:latest", "mistral:7b"}
+			h.pull = tt.pullBody
+			p := h.provider(OllamaConfig{Pull: tt.pull})
+
+			var progress []string
+			err := p.EnsureModels(context.Background(), []string{"llama3", "mistral:7b", "qwen:7b"}, func(line string) {
+				progress = append(progress, line)
+			})
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("EnsureModels error = %v, want %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(h.pulled) != fmt.Sprint(tt.wantPulled) {
+				t.Errorf("pulled %v, want %v", h.pulled, tt.wantPulled)
+			}
+			if fmt.Sprint(progress) != fmt.Sprint(tt.wantProgress) {
+				t.Errorf("progress = %q, want %q", progress, tt.wantProgress)
+			}
+		})
+	}
+}
+
+func TestOllamaEnsureModelsHostDown(t *testing.T) {
+	h := newOllamaHost(t)
+	p := h.provider(OllamaConfig{})
+	h.server.Close()
+	if err := p.EnsureModels(context.Background(), []string{"llama3"}, func(string) {}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("EnsureModels error = %v, want the host unavailable", err)
+	}
+}
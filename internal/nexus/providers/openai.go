@@ -0,0 +1,121 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Auth schemes of OpenAI-compatible endpoints.
+const (
+	AuthBearer = "bearer"
+	AuthAPIKey = "api-key"
+	AuthAzure  = "azure"
+	AuthNone   = "none"
+)
+
+// AuthConfig selects how the API key of an OpenAI-compatible endpoint is sent.
+type AuthConfig struct {
+	// Scheme is bearer (Authorization header), api-key (a custom header), azure (an
+	// api-key header plus the api-version query parameter) or none.
+	Scheme string `yaml:"scheme,omitempty"`
+	// Header names the header of the api-key and azure schemes, "api-key" by default.
+	Header string `yaml:"header,omitempty"`
+	// APIVersion is the api-version query parameter of the azure scheme.
+	APIVersion string `yaml:"api_version,omitempty"`
+}
+
+func (a AuthConfig) merge(override AuthConfig) AuthConfig {
+	if override.Scheme != "" {
+		a.Scheme = override.Scheme
+	}
+	if override.Header != "" {
+		a.Header = override.Header
+	}
+	if override.APIVersion != "" {
+		a.APIVersion = override.APIVersion
+	}
+	return a
+}
+
+func (a AuthConfig) validate() error {
+	switch a.Scheme {
+	case "", AuthBearer, AuthAPIKey, AuthNone:
+		return nil
+	case AuthAzure:
+		if a.APIVersion == "" {
+			return fmt.Errorf("auth scheme %q needs an api_version", a.Scheme)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown auth scheme %q (expected %s, %s, %s or %s)", a.Scheme, AuthBearer, AuthAPIKey, AuthAzure, AuthNone)
+}
+
+// OpenAIProvider talks to any endpoint implementing the OpenAI chat completions API,
+// such as vLLM, LM Studio, llama.cpp server, OpenRouter or Azure OpenAI. Everything
+// about the endpoint comes from the workspace config.
+type OpenAIProvider struct {
+	ProviderConfig
+}
+
+func init() {
+	Register("openai", ProviderConfig{
+		Host:      "https://api.openai.com",
+		APIKeyEnv: "OPENAI_API_KEY",
+		Path:      "/v1/chat/completions",
+		Auth:      AuthConfig{Scheme: AuthBearer},
+		// Backends without schema support are retried in JSON mode by the executors.
+		StructuredOutput: boolPtr(true),
+	}, func(cfg ProviderConfig) GenAIProvider { return OpenAIProvider{cfg} })
+}
+
+func (p OpenAIProvider) Init() *http.Client {
+	client := http.Client{Timeout: p.Timeout}
+	return &client
+}
+
+func (p OpenAIProvider) url(model string) string {
+	endpoint := p.Host + strings.ReplaceAll(p.Path, "{model}", url.PathEscape(model))
+	if p.Auth.Scheme == AuthAzure {
+		separator := "?"
+		if strings.Contains(endpoint, "?") {
+			separator = "&"
+		}
+		endpoint += separator + "api-version=" + url.QueryEscape(p.Auth.APIVersion)
+	}
+	return endpoint
+}
+
+func (p OpenAIProvider) headers() map[string]string {
+	headers := map[string]string{}
+	if key := p.APIKey(); key != "" {
+		switch p.Auth.Scheme {
+		case AuthBearer, "":
+			headers["Authorization"] = fmt.Sprintf("Bearer %s", key)
+		case AuthAPIKey, AuthAzure:
+			header := p.Auth.Header
+			if header == "" {
+				header = "api-key"
+			}
+			headers[header] = key
+		}
+	}
+	for key, value := range p.Headers {
+		headers[key] = value
+	}
+	return headers
+}
+
+func (p OpenAIProvider) Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+	return generateOpenAICompatible(ctx, client, p.ProviderConfig, p.url(req.Model), p.headers(), req)
+}
+
+func (p OpenAIProvider) GenerateStream(ctx context.Context, client *http.Client, req GenAIProviderRequest) (<-chan StreamChunk, error) {
+	return streamOpenAICompatible(ctx, client, p.ProviderConfig, p.url(req.Model), p.headers(), req)
+}
+
+func (p OpenAIProvider) Close(client *http.Client) {
+	client.CloseIdleConnections()
+}
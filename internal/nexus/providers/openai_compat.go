@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// postJSON sends body to url and returns the response once a 2xx status has been
+// confirmed. Transport failures and error statuses come back as *ProviderError.
+func postJSON(ctx context.Context, client *http.Client, provider string, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("%s: encoding request: %w", provider, err)
+	}
+
+	req_obj, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: creating request: %w", provider, err)
+	}
+	req_obj.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req_obj.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req_obj)
+	if err != nil {
+		slog.Error("Provider request failed", "provider", provider, "error", err)
+		return nil, newTransportError(provider, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		payload, _ := io.ReadAll(resp.Body)
+		perr := newHTTPError(provider, resp, payload)
+		slog.Error("Provider returned an error", "provider", provider, "status", resp.StatusCode, "error", perr)
+		return nil, perr
+	}
+	return resp, nil
+}
+
+// compatibleRequest drops the parameters of req that the endpoint of cfg does not
+// take. OpenAI rejects unknown parameters rather than ignoring them.
+func compatibleRequest(cfg ProviderConfig, req GenAIProviderRequest) GenAIProviderRequest {
+	if !cfg.Capabilities().ReasoningFormat {
+		req.ReasoningFormat = ""
+	}
+	return req
+}
+
+// generateOpenAICompatible runs a blocking chat completion against an
+// OpenAI-compatible /chat/completions endpoint.
+func generateOpenAICompatible(ctx context.Context, client *http.Client, cfg ProviderConfig, url string, headers map[string]string, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+	provider := cfg.Name
+	req = compatibleRequest(cfg, req)
+	req.Stream = false
+	req.StreamOptions = nil
+
+	resp, err := postJSON(ctx, client, provider, url, headers, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newTransportError(provider, err)
+	}
+
+	var result GenAIProviderResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		slog.Error("Error unmarshaling response", "provider", provider, "error", err)
+		return nil, newMalformedError(provider, err)
+	}
+	if len(result.Choices) == 0 {
+		return nil, newMalformedError(provider, fmt.Errorf("response has no choices"))
+	}
+	return &result, nil
+}
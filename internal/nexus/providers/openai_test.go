@@ -0,0 +1,249 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// seenRequest is what the mock OpenAI-compatible server received.
+type seenRequest struct {
+	path   string
+	query  string
+	header http.Header
+	body   GenAIProviderRequest
+	// fields holds the top level fields of the body as sent.
+	fields map[string]json.RawMessage
+}
+
+// openAIServer stands in for an OpenAI-compatible endpoint, replying with a fixed
+// completion, or a short stream when one is asked for.
+func openAIServer(t *testing.T) (*httptest.Server, *seenRequest) {
+	t.Helper()
+	seen := &seenRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen.path, seen.query, seen.header = r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Clone()
+		raw, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(raw, &seen.body); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		seen.fields = nil
+		json.Unmarshal(raw, &seen.fields)
+		if seen.body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, `data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"hel"}}]}`+"\n\n")
+			io.WriteString(w, `data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`+"\n\n")
+			io.WriteString(w, "data: [DONE]\n\n")
+			return
+		}
+		io.WriteString(w, `{"id":"c1","object":"chat.completion","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":2,"completion_tokens":1,"total_tokens":3}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, seen
+}
+
+func TestOpenAIProviderEndpoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     ProviderConfig
+		model      string
+		wantPath   string
+		wantQuery  string
+		wantHeader map[string]string
+		noHeader   []string
+	}{
+		{
+			name:       "bearer by default",
+			config:     ProviderConfig{},
+			model:      "gpt-4o",
+			wantPath:   "/v1/chat/completions",
+			wantHeader: map[string]string{"Authorization": "Bearer secret"},
+			noHeader:   []string{"Api-Key"},
+		},
+		{
+			name:       "custom path and headers",
+			config:     ProviderConfig{Path: "/api/v1/chat/completions", Headers: map[string]string{"HTTP-Referer": "https://yafai.dev"}},
+			model:      "meta-llama/llama-3.1-8b",
+			wantPath:   "/api/v1/chat/completions",
+			wantHeader: map[string]string{"Authorization": "Bearer secret", "HTTP-Referer": "https://yafai.dev"},
+		},
+		{
+			name:       "api-key header",
+			config:     ProviderConfig{Auth: AuthConfig{Scheme: AuthAPIKey}},
+			model:      "m",
+			wantPath:   "/v1/chat/completions",
+			wantHeader: map[string]string{"api-key": "secret"},
+			noHeader:   []string{"Authorization"},
+		},
+		{
+			name:       "named api-key header",
+			config:     ProviderConfig{Auth: AuthConfig{Scheme: AuthAPIKey, Header: "X-Api-Token"}},
+			model:      "m",
+			wantPath:   "/v1/chat/completions",
+			wantHeader: map[string]string{"X-Api-Token": "secret"},
+			noHeader:   []string{"Authorization", "Api-Key"},
+		},
+		{
+			name:       "azure deployment",
+			config:     ProviderConfig{Path: "/openai/deployments/{model}/chat/completions", Auth: AuthConfig{Scheme: AuthAzure, APIVersion: "2024-10-21"}},
+			model:      "gpt 4o",
+			wantPath:   "/openai/deployments/gpt%204o/chat/completions",
+			wantQuery:  "api-version=2024-10-21",
+			wantHeader: map[string]string{"api-key": "secret"},
+			noHeader:   []string{"Authorization"},
+		},
+		{
+			name:      "azure with a query in the path",
+			config:    ProviderConfig{Path: "/openai/deployments/{model}/chat/completions?trace=1", Auth: AuthConfig{Scheme: AuthAzure, APIVersion: "2024-10-21"}},
+			model:     "gpt-4o",
+			wantPath:  "/openai/deployments/gpt-4o/chat/completions",
+			wantQuery: "trace=1&api-version=2024-10-21",
+		},
+		{
+			name:     "no auth",
+			config:   ProviderConfig{Auth: AuthConfig{Scheme: AuthNone}},
+			model:    "local",
+			wantPath: "/v1/chat/completions",
+			noHeader: []string{"Authorization", "Api-Key"},
+		},
+	}
+	for _, tt := range tests {
+		for _, stream := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/stream=%v", tt.name, stream), func(t *testing.T) {
+				server, seen := openAIServer(t)
+				t.Setenv("TEST_OPENAI_KEY", "secret")
+				override := tt.config
+				override.Type, override.BaseURL, override.APIKeyEnv = "openai", server.URL+"/", "TEST_OPENAI_KEY"
+				cfg, factory, err := ResolveConfig("local", map[string]ProviderConfig{"local": override})
+				if err != nil {
+					t.Fatal(err)
+				}
+				p := factory(cfg)
+				client := p.Init()
+				req := GenAIProviderRequest{Model: tt.model, Messages: []RequestMessage{{Role: "user", Content: "hi"}}}
+
+				content := ""
+				if stream {
+					chunks, err := p.GenerateStream(context.Background(), client, req)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for chunk := range chunks {
+						if chunk.Err != nil {
+							t.Fatal(chunk.Err)
+						}
+						content += chunk.Content
+					}
+				} else {
+					resp, err := p.Generate(context.Background(), client, req)
+					if err != nil {
+						t.Fatal(err)
+					}
+					content = resp.Choices[0].Message.Content
+				}
+
+				if content != "hello" {
+					t.Errorf("content = %q, want hello", content)
+				}
+				if seen.path != tt.wantPath || seen.query != tt.wantQuery {
+					t.Errorf("url = %s?%s, want %s?%s", seen.path, seen.query, tt.wantPath, tt.wantQuery)
+				}
+				if seen.body.Model != tt.model || seen.body.Stream != stream {
+					t.Errorf("body model = %q stream = %v", seen.body.Model, seen.body.Stream)
+				}
+				for key, value := range tt.wantHeader {
+					if got := seen.header.Get(key); got != value {
+						t.Errorf("header %s = %q, want %q", key, got, value)
+					}
+				}
+				for _, key := range tt.noHeader {
+					if got := seen.header.Get(key); got != "" {
+						t.Errorf("header %s = %q, want none", key, got)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestOpenAIProviderAuthValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    AuthConfig
+		wantErr bool
+	}{
+		{"bearer", AuthConfig{Scheme: AuthBearer}, false},
+		{"azure without api version", AuthConfig{Scheme: AuthAzure}, true},
+		{"unknown scheme", AuthConfig{Scheme: "basic"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ResolveConfig("local", map[string]ProviderConfig{"local": {Type: "openai", Auth: tt.auth}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveConfig error = %v, want one: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenAICompatibleRequestBody(t *testing.T) {
+	// The planner asks for parsed reasoning and a schema, and has no tools
+	planner := GenAIProviderRequest{
+		Model:           "m",
+		Messages:        []RequestMessage{{Role: "system", Content: "plan"}, {Role: "user", Content: "hi"}},
+		ResponseFormat:  JSONSchemaFormat("plan", &struct{ Tasks []string }{}),
+		ReasoningFormat: "parsed",
+	}
+	opted := true
+	tests := []struct {
+		name          string
+		config        ProviderConfig
+		wantReasoning bool
+	}{
+		{"openai", ProviderConfig{Type: "openai"}, false},
+		{"openai opting in", ProviderConfig{Type: "openai", ReasoningFormat: &opted}, true},
+		{"ollama", ProviderConfig{Type: "ollama"}, false},
+		{"groq", ProviderConfig{Type: "groq"}, true},
+	}
+	for _, tt := range tests {
+		for _, stream := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/stream=%v", tt.name, stream), func(t *testing.T) {
+				server, seen := openAIServer(t)
+				override := tt.config
+				override.BaseURL = server.URL
+				if override.Type == "openai" {
+					override.Auth = AuthConfig{Scheme: AuthNone}
+				}
+				cfg, factory, err := ResolveConfig("local", map[string]ProviderConfig{"local": override})
+				if err != nil {
+					t.Fatal(err)
+				}
+				p := factory(cfg)
+				if stream {
+					chunks, err := p.GenerateStream(context.Background(), p.Init(), planner)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for range chunks {
+					}
+				} else if _, err := p.Generate(context.Background(), p.Init(), planner); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, sent := seen.fields["reasoning_format"]; sent != tt.wantReasoning {
+					t.Errorf("reasoning_format sent: %v, want %v", sent, tt.wantReasoning)
+				}
+				if tools, sent := seen.fields["tools"]; sent {
+					t.Errorf("tools sent without tools: %s", tools)
+				}
+				if format := seen.body.ResponseFormat; format == nil {
+					t.Error("response format dropped")
+				}
+			})
+		}
+	}
+}
@@ -2,27 +2,48 @@ package providers
 
 import (
 	"context"
-	"log/slog"
+	"encoding/json"
 	"net/http"
-	"os"
-	//"os"
 )
 
-func GetProvider(name string) GenAIProvider {
-	switch name {
-	case "groq":
-		return GroqProvider{Host: os.Getenv("GROQ_HOST")}
-	case "ollama":
-		return OllamaProvider{Host: os.Getenv("GROQ_HOST")}
-	default:
-		slog.Info("Unknown provider, falling back to groq")
-		return GroqProvider{Host: os.Getenv("GROQ_HOST")}
-	}
+// GetProvider builds a registered provider with its default configuration.
+func GetProvider(name string) (GenAIProvider, error) {
+	return NewProvider(name, nil)
 }
 
 type GenAIProvider interface {
 	Init() *http.Client
 	Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error)
-	//GenerateStream(ctx context.Context, client *http.Client, req GenAIProviderRequest) <-chan []byte @Todo
+	GenerateStream(ctx context.Context, client *http.Client, req GenAIProviderRequest) (<-chan StreamChunk, error)
 	Close(client *http.Client)
+	Capabilities() Capabilities
+}
+
+// ModelPuller is implemented by providers that can download models onto their host.
+type ModelPuller interface {
+	// EnsureModels pulls the models missing from the host, reporting progress as
+	// human readable lines through progress.
+	EnsureModels(ctx context.Context, models []string, progress func(string)) error
+}
+
+// Unwrap returns the provider implementation beneath any decorators such as retries,
+// so optional interfaces like ModelPuller can be detected.
+func Unwrap(provider GenAIProvider) GenAIProvider {
+	for {
+		wrapper, ok := provider.(interface{ Unwrap() GenAIProvider })
+		if !ok {
+			return provider
+		}
+		provider = wrapper.Unwrap()
+	}
+}
+
+// toolArguments returns the JSON encoded arguments of a tool call as a raw object, for
+// APIs that take them unencoded. Malformed arguments become an empty object.
+func toolArguments(arguments string) json.RawMessage {
+	var object map[string]interface{}
+	if json.Unmarshal([]byte(arguments), &object) != nil || object == nil {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
 }
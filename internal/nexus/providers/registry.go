@@ -0,0 +1,225 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProviderConfig holds the connection settings of a provider. Registered providers
+// supply defaults which the `providers:` section of a workspace config overrides.
+type ProviderConfig struct {
+	// Type selects the registered provider implementation. It defaults to the name the
+	// provider is configured under, so a workspace can declare aliases such as a
+	// second ollama host with `type: ollama`.
+	Type      string            `yaml:"type,omitempty"`
+	Host      string            `yaml:"host,omitempty"`
+	HostEnv   string            `yaml:"host_env,omitempty"`
+	APIKeyEnv string            `yaml:"api_key_env,omitempty"`
+	Timeout   time.Duration     `yaml:"timeout,omitempty"`
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	Retry     RetryConfig       `yaml:"retry,omitempty"`
+	Auth      AuthConfig        `yaml:"auth,omitempty"`
+	// BaseURL is an alias of Host for OpenAI-compatible endpoints.
+	BaseURL string `yaml:"base_url,omitempty"`
+	// Path is the chat completions path of the openai provider type. "{model}" is
+	// replaced with the requested model, e.g. for Azure OpenAI deployments.
+	Path string `yaml:"path,omitempty"`
+	// StructuredOutput overrides whether the provider is sent JSON schemas as
+	// `response_format: json_schema`. Leave unset to use the provider default.
+	StructuredOutput *bool `yaml:"structured_output,omitempty"`
+	// ReasoningFormat overrides whether the provider is sent the reasoning_format
+	// parameter, which only Groq accepts. Leave unset to use the provider default.
+	ReasoningFormat *bool `yaml:"reasoning_format,omitempty"`
+	// DefaultContextWindow is the context limit in tokens of the provider's models and
+	// ContextWindows that of single models. Unset limits are looked up by model name.
+	DefaultContextWindow int            `yaml:"context_window,omitempty"`
+	ContextWindows       map[string]int `yaml:"context_windows,omitempty"`
+	// Ollama holds the settings of the ollama provider type, written at the top level
+	// of the provider entry. Other types reject them.
+	Ollama OllamaConfig `yaml:",inline"`
+	// Name is the name the provider is configured under, used to label errors.
+	Name string `yaml:"-"`
+}
+
+// APIKey reads the credential from the configured environment variable.
+func (c ProviderConfig) APIKey() string {
+	if c.APIKeyEnv == "" {
+		return ""
+	}
+	return os.Getenv(c.APIKeyEnv)
+}
+
+// Capabilities reports the optional features enabled for the provider.
+func (c ProviderConfig) Capabilities() Capabilities {
+	return Capabilities{
+		JSONSchema:      c.StructuredOutput != nil && *c.StructuredOutput,
+		ReasoningFormat: c.ReasoningFormat != nil && *c.ReasoningFormat,
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// merge layers the non-empty fields of override on top of c.
+func (c ProviderConfig) merge(override ProviderConfig) ProviderConfig {
+	if override.Type != "" {
+		c.Type = override.Type
+	}
+	if override.Host != "" {
+		c.Host = override.Host
+	}
+	if override.BaseURL != "" {
+		c.Host = override.BaseURL
+	}
+	if override.Path != "" {
+		c.Path = override.Path
+	}
+	c.Auth = c.Auth.merge(override.Auth)
+	c.Ollama = c.Ollama.merge(override.Ollama)
+	if override.HostEnv != "" {
+		c.HostEnv = override.HostEnv
+	}
+	if override.APIKeyEnv != "" {
+		c.APIKeyEnv = override.APIKeyEnv
+	}
+	if override.Timeout != 0 {
+		c.Timeout = override.Timeout
+	}
+	if len(override.Headers) > 0 {
+		headers := make(map[string]string, len(c.Headers)+len(override.Headers))
+		for key, value := range c.Headers {
+			headers[key] = value
+		}
+		for key, value := range override.Headers {
+			headers[key] = value
+		}
+		c.Headers = headers
+	}
+	c.Retry = c.Retry.merge(override.Retry)
+	if override.StructuredOutput != nil {
+		c.StructuredOutput = override.StructuredOutput
+	}
+	if override.ReasoningFormat != nil {
+		c.ReasoningFormat = override.ReasoningFormat
+	}
+	if override.DefaultContextWindow != 0 {
+		c.DefaultContextWindow = override.DefaultContextWindow
+	}
+	if len(override.ContextWindows) > 0 {
+		windows := make(map[string]int, len(c.ContextWindows)+len(override.ContextWindows))
+		for model, tokens := range c.ContextWindows {
+			windows[model] = tokens
+		}
+		for model, tokens := range override.ContextWindows {
+			windows[model] = tokens
+		}
+		c.ContextWindows = windows
+	}
+	return c
+}
+
+// ProviderFactory builds a provider from its resolved configuration.
+type ProviderFactory func(cfg ProviderConfig) GenAIProvider
+
+type registeredProvider struct {
+	defaults ProviderConfig
+	factory  ProviderFactory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]registeredProvider{}
+)
+
+// Register makes a provider implementation available under kind. Providers call it
+// from init so that adding a backend does not require touching GetProvider.
+func Register(kind string, defaults ProviderConfig, factory ProviderFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[kind]; exists {
+		panic(fmt.Sprintf("providers: %q registered twice", kind))
+	}
+	defaults.Type = kind
+	registry[kind] = registeredProvider{defaults: defaults, factory: factory}
+}
+
+// Registered lists the registered provider kinds in alphabetical order.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	kinds := make([]string, 0, len(registry))
+	for kind := range registry {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// ResolveConfig returns the effective configuration for the provider called name,
+// combining the registered defaults with the workspace overrides in configs.
+func ResolveConfig(name string, configs map[string]ProviderConfig) (ProviderConfig, ProviderFactory, error) {
+	override := configs[name]
+	kind := override.Type
+	if kind == "" {
+		kind = name
+	}
+
+	registryMu.RLock()
+	entry, ok := registry[kind]
+	registryMu.RUnlock()
+	if !ok {
+		if kind != name {
+			return ProviderConfig{}, nil, fmt.Errorf("provider %q has unknown type %q (registered: %s)", name, kind, strings.Join(Registered(), ", "))
+		}
+		return ProviderConfig{}, nil, fmt.Errorf("unknown provider %q (registered: %s)", name, strings.Join(Registered(), ", "))
+	}
+
+	cfg := entry.defaults.merge(override)
+	cfg.Type = kind
+	cfg.Name = name
+	if err := cfg.Auth.validate(); err != nil {
+		return ProviderConfig{}, nil, fmt.Errorf("provider %q: %w", name, err)
+	}
+	if err := defaultRetry.merge(cfg.Retry).validate(); err != nil {
+		return ProviderConfig{}, nil, fmt.Errorf("provider %q: %w", name, err)
+	}
+	if kind != "ollama" && override.Ollama.set() {
+		return ProviderConfig{}, nil, fmt.Errorf("provider %q: api, options, keep_alive and pull only apply to providers of type ollama, not %q", name, kind)
+	}
+	if err := cfg.Ollama.validate(); err != nil {
+		return ProviderConfig{}, nil, fmt.Errorf("provider %q: %w", name, err)
+	}
+	if cfg.DefaultContextWindow < 0 {
+		return ProviderConfig{}, nil, fmt.Errorf("provider %q: context_window must be positive", name)
+	}
+	for model, tokens := range cfg.ContextWindows {
+		if tokens <= 0 {
+			return ProviderConfig{}, nil, fmt.Errorf("provider %q: context window of %q must be positive", name, model)
+		}
+	}
+	if override.Host == "" && override.BaseURL == "" && cfg.HostEnv != "" {
+		if host := os.Getenv(cfg.HostEnv); host != "" {
+			cfg.Host = host
+		}
+	}
+	cfg.Host = strings.TrimRight(cfg.Host, "/")
+	return cfg, entry.factory, nil
+}
+
+// NewProvider builds the provider called name using the workspace provider configs.
+// The provider is wrapped so transient failures are retried as configured in cfg.Retry,
+// and so its calls share one HTTP client.
+func NewProvider(name string, configs map[string]ProviderConfig) (GenAIProvider, error) {
+	cfg, factory, err := ResolveConfig(name, configs)
+	if err != nil {
+		return nil, err
+	}
+	return withSharedClient(withRetry(cfg, factory(cfg))), nil
+}
@@ -0,0 +1,62 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveConfigOllamaSettings(t *testing.T) {
+	pull := false
+	tests := []struct {
+		name    string
+		configs map[string]ProviderConfig
+		resolve string
+		wantErr string
+	}{
+		{
+			name:    "ollama settings on ollama",
+			configs: map[string]ProviderConfig{"ollama": {Ollama: OllamaConfig{API: OllamaAPINative, KeepAlive: "10m", Pull: &pull}}},
+			resolve: "ollama",
+		},
+		{
+			name:    "ollama settings on an ollama alias",
+			configs: map[string]ProviderConfig{"gpu": {Type: "ollama", Ollama: OllamaConfig{Options: map[string]interface{}{"num_ctx": 8192}}}},
+			resolve: "gpu",
+		},
+		{
+			name:    "options on groq",
+			configs: map[string]ProviderConfig{"groq": {Ollama: OllamaConfig{Options: map[string]interface{}{"num_ctx": 8192}}}},
+			resolve: "groq",
+			wantErr: `provider "groq": api, options, keep_alive and pull only apply to providers of type ollama, not "groq"`,
+		},
+		{
+			name:    "keep_alive on an openai alias",
+			configs: map[string]ProviderConfig{"azure": {Type: "openai", Ollama: OllamaConfig{KeepAlive: "5m"}}},
+			resolve: "azure",
+			wantErr: "only apply to providers of type ollama",
+		},
+		{
+			name:    "pull on anthropic",
+			configs: map[string]ProviderConfig{"anthropic": {Ollama: OllamaConfig{Pull: &pull}}},
+			resolve: "anthropic",
+			wantErr: "only apply to providers of type ollama",
+		},
+		{
+			name:    "unknown ollama api",
+			configs: map[string]ProviderConfig{"ollama": {Ollama: OllamaConfig{API: "grpc"}}},
+			resolve: "ollama",
+			wantErr: `unknown ollama api "grpc"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ResolveConfig(tt.resolve, tt.configs)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ResolveConfig: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("ResolveConfig error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryConfig controls how transient provider failures (rate limits, server errors and
+// unreachable hosts) are retried. Zero fields fall back to the defaults below; set
+// max_attempts to 1 to disable retries for a provider.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts,omitempty"`
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty"`
+	MaxBackoff     time.Duration `yaml:"max_backoff,omitempty"`
+	// MaxWait caps how long a provider requested wait (retry-after or
+	// x-ratelimit-reset-*) is honoured. Longer waits fail immediately instead.
+	MaxWait time.Duration `yaml:"max_wait,omitempty"`
+}
+
+var defaultRetry = RetryConfig{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     20 * time.Second,
+	MaxWait:        60 * time.Second,
+}
+
+func (c RetryConfig) merge(override RetryConfig) RetryConfig {
+	if override.MaxAttempts != 0 {
+		c.MaxAttempts = override.MaxAttempts
+	}
+	if override.InitialBackoff != 0 {
+		c.InitialBackoff = override.InitialBackoff
+	}
+	if override.MaxBackoff != 0 {
+		c.MaxBackoff = override.MaxBackoff
+	}
+	if override.MaxWait != 0 {
+		c.MaxWait = override.MaxWait
+	}
+	return c
+}
+
+// validate rejects settings that cannot be waited on, such as negative durations
+// written in a workspace config.
+func (c RetryConfig) validate() error {
+	switch {
+	case c.MaxAttempts < 0:
+		return fmt.Errorf("retry max_attempts must not be negative, got %d", c.MaxAttempts)
+	case c.InitialBackoff <= 0:
+		return fmt.Errorf("retry initial_backoff must be positive, got %s", c.InitialBackoff)
+	case c.MaxBackoff <= 0:
+		return fmt.Errorf("retry max_backoff must be positive, got %s", c.MaxBackoff)
+	case c.MaxBackoff < c.InitialBackoff:
+		return fmt.Errorf("retry max_backoff %s is shorter than initial_backoff %s", c.MaxBackoff, c.InitialBackoff)
+	case c.MaxWait <= 0:
+		return fmt.Errorf("retry max_wait must be positive, got %s", c.MaxWait)
+	}
+	return nil
+}
+
+// backoff returns the delay before the given retry (1 based): exponential growth
+// capped at MaxBackoff, with full jitter over the upper half of the interval.
+func (c RetryConfig) backoff(retry int) time.Duration {
+	delay := c.InitialBackoff
+	for i := 1; i < retry && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.MaxBackoff {
+		delay = c.MaxBackoff
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryProvider decorates a GenAIProvider with retries of transient failures.
+type retryProvider struct {
+	GenAIProvider
+	name  string
+	retry RetryConfig
+}
+
+// withRetry wraps provider so every call is retried according to cfg.Retry.
+func withRetry(cfg ProviderConfig, provider GenAIProvider) GenAIProvider {
+	retry := defaultRetry.merge(cfg.Retry)
+	if retry.MaxAttempts <= 1 {
+		return provider
+	}
+	return &retryProvider{GenAIProvider: provider, name: cfg.Name, retry: retry}
+}
+
+func (p *retryProvider) Unwrap() GenAIProvider {
+	return p.GenAIProvider
+}
+
+func (p *retryProvider) Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+	var resp *GenAIProviderResponse
+	err := p.do(ctx, func() error {
+		var err error
+		resp, err = p.GenAIProvider.Generate(ctx, client, req)
+		return err
+	})
+	return resp, err
+}
+
+// GenerateStream only retries opening the stream. Once chunks have been delivered a
+// failure is passed on, since the consumer may already have rendered partial output.
+func (p *retryProvider) GenerateStream(ctx context.Context, client *http.Client, req GenAIProviderRequest) (<-chan StreamChunk, error) {
+	var chunks <-chan StreamChunk
+	err := p.do(ctx, func() error {
+		var err error
+		chunks, err = p.GenAIProvider.GenerateStream(ctx, client, req)
+		return err
+	})
+	return chunks, err
+}
+
+func (p *retryProvider) do(ctx context.Context, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || !Retryable(err) || attempt >= p.retry.MaxAttempts {
+			return err
+		}
+
+		delay := p.retry.backoff(attempt)
+		if perr, ok := err.(*ProviderError); ok && perr.RetryAfter > 0 {
+			if perr.RetryAfter > p.retry.MaxWait {
+				slog.Warn("Provider asked to wait longer than allowed, giving up", "provider", p.name, "retry_after", perr.RetryAfter, "max_wait", p.retry.MaxWait)
+				return err
+			}
+			delay = perr.RetryAfter
+		}
+
+		slog.Warn("Retrying provider call", "provider", p.name, "attempt", attempt, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			// The caller gave up, which is no provider failure
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails its first calls with the errors in failures, then succeeds.
+// Its streams deliver chunks, then streamErr when set.
+type flakyProvider struct {
+	mu        sync.Mutex
+	failures  []error
+	calls     int
+	chunks    []StreamChunk
+	streamErr error
+}
+
+func (p *flakyProvider) next() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if len(p.failures) == 0 {
+		return nil
+	}
+	err := p.failures[0]
+	p.failures = p.failures[1:]
+	return err
+}
+
+func (p *flakyProvider) Init() *http.Client { return nil }
+
+func (p *flakyProvider) Generate(ctx context.Context, client *http.Client, req GenAIProviderRequest) (*GenAIProviderResponse, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	return &GenAIProviderResponse{ID: "ok"}, nil
+}
+
+func (p *flakyProvider) GenerateStream(ctx context.Context, client *http.Client, req GenAIProviderRequest) (<-chan StreamChunk, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	out := make(chan StreamChunk, len(p.chunks)+1)
+	for _, chunk := range p.chunks {
+		out <- chunk
+	}
+	if p.streamErr != nil {
+		out <- StreamChunk{Err: p.streamErr}
+	}
+	close(out)
+	return out, nil
+}
+
+func (p *flakyProvider) Close(client *http.Client) {}
+
+func (p *flakyProvider) Capabilities() Capabilities { return Capabilities{} }
+
+var fastRetry = RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxWait: time.Second}
+
+func serverError() error {
+	return &ProviderError{Provider: "test", Kind: ErrServer, StatusCode: 500}
+}
+
+func TestRetryGenerate(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  []error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "transient failures", failures: []error{serverError(), &ProviderError{Kind: ErrUnavailable}}, wantCalls: 3},
+		{name: "attempts run out", failures: []error{serverError(), serverError(), serverError(), serverError()}, wantErr: ErrServer, wantCalls: 3},
+		{name: "not retryable", failures: []error{&ProviderError{Kind: ErrAuthFailed, StatusCode: 401}}, wantErr: ErrAuthFailed, wantCalls: 1},
+		{name: "invalid request", failures: []error{&ProviderError{Kind: ErrInvalidRequest, StatusCode: 400}, serverError()}, wantErr: ErrInvalidRequest, wantCalls: 1},
+		{name: "rate limited", failures: []error{&ProviderError{Kind: ErrRateLimited, StatusCode: 429, RetryAfter: time.Millisecond}}, wantCalls: 2},
+		{name: "wait longer than allowed", failures: []error{&ProviderError{Kind: ErrRateLimited, StatusCode: 429, RetryAfter: time.Hour}}, wantErr: ErrRateLimited, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakyProvider{failures: tt.failures}
+			p := withRetry(ProviderConfig{Type: "test", Retry: fastRetry}, inner)
+			resp, err := p.Generate(context.Background(), nil, GenAIProviderRequest{})
+			if tt.wantErr == nil && (err != nil || resp == nil) {
+				t.Errorf("Generate = %v, %v", resp, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if inner.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", inner.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	inner := &flakyProvider{failures: []error{&ProviderError{Kind: ErrRateLimited, RetryAfter: 50 * time.Millisecond}}}
+	p := withRetry(ProviderConfig{Type: "test", Retry: fastRetry}, inner)
+	start := time.Now()
+	if _, err := p.Generate(context.Background(), nil, GenAIProviderRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("retried after %s, want the 50ms the provider asked for", waited)
+	}
+}
+
+func TestRetryCancelledDuringBackoff(t *testing.T) {
+	inner := &flakyProvider{failures: []error{&ProviderError{Kind: ErrRateLimited, RetryAfter: 500 * time.Millisecond}}}
+	p := withRetry(ProviderConfig{Type: "test", Retry: fastRetry}, inner)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := p.Generate(ctx, nil, GenAIProviderRequest{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want the context error", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("calls = %d, want 1", inner.calls)
+	}
+}
+
+func TestRetryStreamOnlyBeforeFirstChunk(t *testing.T) {
+	inner := &flakyProvider{
+		failures:  []error{serverError()},
+		chunks:    []StreamChunk{{Content: "partial"}},
+		streamErr: serverError(),
+	}
+	p := withRetry(ProviderConfig{Type: "test", Retry: fastRetry}, inner)
+	chunks, err := p.GenerateStream(context.Background(), nil, GenAIProviderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []StreamChunk
+	for chunk := range chunks {
+		got = append(got, chunk)
+	}
+	if len(got) != 2 || got[0].Content != "partial" || !errors.Is(got[1].Err, ErrServer) {
+		t.Errorf("chunks = %+v, want the partial output then the failure", got)
+	}
+	if inner.calls != 2 {
+		t.Errorf("calls = %d, want the stream opened twice, not reopened after output", inner.calls)
+	}
+}
+
+func TestRetryDisabled(t *testing.T) {
+	inner := &flakyProvider{}
+	if p := withRetry(ProviderConfig{Retry: RetryConfig{MaxAttempts: 1}}, inner); p != GenAIProvider(inner) {
+		t.Errorf("max_attempts 1 wrapped the provider: %T", p)
+	}
+}
+
+func TestRetryNamedAfterTheConfiguredProvider(t *testing.T) {
+	p, ok := withRetry(ProviderConfig{Type: "groq", Name: "groq-fast"}, &flakyProvider{}).(*retryProvider)
+	if !ok || p.name != "groq-fast" {
+		t.Errorf("retries labelled %+v, want groq-fast", p)
+	}
+}
+
+func TestRetryConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   RetryConfig
+		wantErr string
+	}{
+		{"defaults", RetryConfig{}, ""},
+		{"disabled", RetryConfig{MaxAttempts: 1}, ""},
+		{"custom", RetryConfig{InitialBackoff: time.Second, MaxBackoff: time.Minute, MaxWait: time.Minute}, ""},
+		{"negative attempts", RetryConfig{MaxAttempts: -1}, "max_attempts must not be negative"},
+		{"negative initial backoff", RetryConfig{InitialBackoff: -time.Second}, "initial_backoff must be positive"},
+		{"negative max backoff", RetryConfig{MaxBackoff: -time.Second}, "max_backoff must be positive"},
+		{"max below initial", RetryConfig{InitialBackoff: 2 * time.Second, MaxBackoff: time.Second}, "max_backoff 1s is shorter than initial_backoff 2s"},
+		{"max below the default initial", RetryConfig{MaxBackoff: 100 * time.Millisecond}, "shorter than initial_backoff"},
+		{"negative max wait", RetryConfig{MaxWait: -time.Second}, "max_wait must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ResolveConfig("groq", map[string]ProviderConfig{"groq": {Retry: tt.retry}})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ResolveConfig: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("ResolveConfig error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	c := RetryConfig{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if delay := c.backoff(tt.retry); delay < tt.max/2 || delay > tt.max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.retry, delay, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"fractional seconds", http.Header{"Retry-After": {"1.5"}}, 1500 * time.Millisecond},
+		{"date in the past", http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0},
+		{"reset duration", http.Header{"X-Ratelimit-Reset-Requests": {"2m59.56s"}}, 2*time.Minute + 59560*time.Millisecond},
+		{"reset seconds", http.Header{"X-Ratelimit-Reset-Tokens": {"7"}}, 7 * time.Second},
+		{
+			"exhausted bucket wins",
+			http.Header{
+				"X-Ratelimit-Reset-Requests":     {"30s"},
+				"X-Ratelimit-Remaining-Requests": {"5"},
+				"X-Ratelimit-Reset-Tokens":       {"4s"},
+				"X-Ratelimit-Remaining-Tokens":   {"0"},
+			},
+			4 * time.Second,
+		},
+		{"longest reset otherwise", http.Header{"X-Ratelimit-Reset-Requests": {"30s"}, "X-Ratelimit-Reset-Tokens": {"4s"}}, 30 * time.Second},
+		{"retry-after first", http.Header{"Retry-After": {"2"}, "X-Ratelimit-Reset-Tokens": {"9s"}}, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header); got != tt.want {
+				t.Errorf("retryAfter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterFutureDate(t *testing.T) {
+	at := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	got := retryAfter(http.Header{"Retry-After": {at}})
+	if got <= 8*time.Second || got > 10*time.Second {
+		t.Errorf("retryAfter(%s) = %s, want about 10s", at, got)
+	}
+}
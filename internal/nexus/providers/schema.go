@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SchemaFor derives a JSON schema from the Go type of v, following encoding/json
+// field names. Every struct field is required and extra properties are rejected, as
+// strict structured output demands. Fields may carry a `description:"..."` tag and
+// string fields an `enum:"a,b"` tag.
+func SchemaFor(v interface{}) map[string]interface{} {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+// JSONSchemaFormat wraps the schema of v in a `json_schema` response format.
+func JSONSchemaFormat(name string, v interface{}) *ResponseFormat {
+	return &ResponseFormat{Type: "json_schema", JSONSchema: &JSONSchema{Name: name, Schema: SchemaFor(v), Strict: true}}
+}
+
+func schemaForType(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Name
+			if tag, ok := field.Tag.Lookup("json"); ok {
+				tagName, _, _ := strings.Cut(tag, ",")
+				if tagName == "-" {
+					continue
+				}
+				if tagName != "" {
+					name = tagName
+				}
+			}
+
+			property := schemaForType(field.Type)
+			if description := field.Tag.Get("description"); description != "" {
+				property["description"] = description
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				property["enum"] = strings.Split(enum, ",")
+			}
+			properties[name] = property
+			required = append(required, name)
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	}
+	// interface{} and anything else accepts any JSON value
+	return map[string]interface{}{}
+}
@@ -0,0 +1,223 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// readSSE walks a text/event-stream body and calls fn for every complete event.
+// Multi-line data fields are joined with newlines as per the SSE spec.
+func readSSE(r io.Reader, fn func(event string, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	var event string
+	var data []string
+
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := fn(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// comment / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
+
+// readNDJSON walks a newline delimited JSON body and calls fn for every non-empty line.
+func readNDJSON(r io.Reader, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// sendChunk delivers a chunk unless the consumer has gone away.
+func sendChunk(ctx context.Context, out chan<- StreamChunk, chunk StreamChunk) bool {
+	select {
+	case out <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// streamOpenAICompatible posts req with stream enabled to an OpenAI-compatible
+// chat completions endpoint and converts the SSE chunks into StreamChunks.
+// The returned channel is closed once the stream ends; failures mid-stream are
+// delivered as a final chunk carrying Err.
+func streamOpenAICompatible(ctx context.Context, client *http.Client, cfg ProviderConfig, url string, headers map[string]string, req GenAIProviderRequest) (<-chan StreamChunk, error) {
+	provider := cfg.Name
+	req = compatibleRequest(cfg, req)
+	req.Stream = true
+	req.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	streamHeaders := map[string]string{"Accept": "text/event-stream"}
+	for key, value := range headers {
+		streamHeaders[key] = value
+	}
+	resp, err := postJSON(ctx, client, provider, url, streamHeaders, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		err := readSSE(resp.Body, func(event string, data string) error {
+			if data == "[DONE]" {
+				return io.EOF
+			}
+			var chunk OpenAIStreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return newMalformedError(provider, fmt.Errorf("decoding stream chunk: %w", err))
+			}
+
+			sc := StreamChunk{ID: chunk.ID, Model: chunk.Model}
+			if chunk.Usage != nil {
+				sc.Usage = chunk.Usage
+			} else if chunk.XGroq != nil && chunk.XGroq.Usage != nil {
+				sc.Usage = chunk.XGroq.Usage
+			}
+			for _, choice := range chunk.Choices {
+				sc.Content += choice.Delta.Content
+				sc.Thought += choice.Delta.Thought
+				sc.ToolCalls = append(sc.ToolCalls, choice.Delta.ToolCalls...)
+				if choice.FinishReason != "" {
+					sc.FinishReason = choice.FinishReason
+				}
+			}
+			if !sendChunk(ctx, out, sc) {
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil && err != io.EOF {
+			if _, ok := err.(*ProviderError); !ok && ctx.Err() == nil {
+				err = newTransportError(provider, err)
+			}
+			sendChunk(ctx, out, StreamChunk{Err: err})
+		}
+	}()
+
+	return out, nil
+}
+
+// StreamAccumulator assembles StreamChunks back into a complete response so callers
+// that render deltas can still work with a regular GenAIProviderResponse afterwards.
+// Tool call argument fragments are concatenated per tool call index.
+type StreamAccumulator struct {
+	id           string
+	model        string
+	content      strings.Builder
+	thought      strings.Builder
+	toolCalls    map[int]*ToolCall
+	finishReason string
+	usage        ResponseUsage
+}
+
+func (a *StreamAccumulator) Add(chunk StreamChunk) {
+	if chunk.ID != "" {
+		a.id = chunk.ID
+	}
+	if chunk.Model != "" {
+		a.model = chunk.Model
+	}
+	a.content.WriteString(chunk.Content)
+	a.thought.WriteString(chunk.Thought)
+
+	for _, delta := range chunk.ToolCalls {
+		if a.toolCalls == nil {
+			a.toolCalls = make(map[int]*ToolCall)
+		}
+		call, ok := a.toolCalls[delta.Index]
+		if !ok {
+			call = &ToolCall{Type: "function"}
+			a.toolCalls[delta.Index] = call
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		if delta.Function.Name != "" {
+			call.Function.Name = delta.Function.Name
+		}
+		call.Function.Arguments += delta.Function.Arguments
+	}
+
+	if chunk.FinishReason != "" {
+		a.finishReason = chunk.FinishReason
+	}
+	if chunk.Usage != nil {
+		a.usage = *chunk.Usage
+	}
+}
+
+func (a *StreamAccumulator) Response() *GenAIProviderResponse {
+	indexes := make([]int, 0, len(a.toolCalls))
+	for index := range a.toolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	var toolCalls []ToolCall
+	for _, index := range indexes {
+		toolCalls = append(toolCalls, *a.toolCalls[index])
+	}
+
+	return &GenAIProviderResponse{
+		ID:     a.id,
+		Object: "chat.completion",
+		Model:  a.model,
+		Choices: []ResponseChoice{{
+			Message: ResponseMessage{
+				Role:      "assistant",
+				Content:   a.content.String(),
+				Thought:   a.thought.String(),
+				ToolCalls: toolCalls,
+			},
+			FinishReason: a.finishReason,
+		}},
+		Usage: a.usage,
+	}
+}
@@ -0,0 +1,148 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sseEvent is an event as passed to the readSSE callback.
+type sseEvent struct {
+	event string
+	data  string
+}
+
+func TestReadSSE(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []sseEvent
+	}{
+		{"single event", "data: hello\n\n", []sseEvent{{"", "hello"}}},
+		{"named event", "event: ping\ndata: {}\n\n", []sseEvent{{"ping", "{}"}}},
+		{"multi-line data", "data: one\ndata: two\ndata:three\n\n", []sseEvent{{"", "one\ntwo\nthree"}}},
+		{"comments and keep-alives", ": keep-alive\n\n:\ndata: a\n: between\n\n: trailing\n\n", []sseEvent{{"", "a"}}},
+		{"blank lines between events", "\n\ndata: a\n\n\n\ndata: b\n\n", []sseEvent{{"", "a"}, {"", "b"}}},
+		{"event without data is dropped", "event: ping\n\ndata: a\n\n", []sseEvent{{"", "a"}}},
+		{"unknown fields ignored", "id: 7\nretry: 100\ndata: a\n\n", []sseEvent{{"", "a"}}},
+		{"done marker", "data: a\n\ndata: [DONE]\n\n", []sseEvent{{"", "a"}, {"", "[DONE]"}}},
+		{"last event unterminated", "data: a\n\ndata: b", []sseEvent{{"", "a"}, {"", "b"}}},
+		{"empty body", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []sseEvent
+			err := readSSE(strings.NewReader(tt.body), func(event string, data string) error {
+				got = append(got, sseEvent{event, data})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSSEStopsOnCallbackError(t *testing.T) {
+	calls := 0
+	err := readSSE(strings.NewReader("data: a\n\ndata: b\n\n"), func(event string, data string) error {
+		calls++
+		return io.EOF
+	})
+	if err != io.EOF || calls != 1 {
+		t.Errorf("err = %v after %d calls, want io.EOF after 1", err, calls)
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"lines", "{\"a\":1}\n{\"b\":2}\n", []string{`{"a":1}`, `{"b":2}`}},
+		{"blank and padded lines", "\n  {\"a\":1}  \n\r\n{\"b\":2}", []string{`{"a":1}`, `{"b":2}`}},
+		{"truncated last line", "{\"a\":1}\n{\"b\":", []string{`{"a":1}`, `{"b":`}},
+		{"empty body", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := readNDJSON(strings.NewReader(tt.body), func(line []byte) error {
+				got = append(got, string(line))
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// streamBody serves body as the event stream of an OpenAI-compatible endpoint and
+// returns the chunks read from it.
+func streamBody(t *testing.T, body string) []StreamChunk {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	chunks, err := streamOpenAICompatible(context.Background(), server.Client(), ProviderConfig{Name: "test"}, server.URL, nil, GenAIProviderRequest{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return collect(t, chunks)
+}
+
+func TestStreamOpenAICompatible(t *testing.T) {
+	chunks := streamBody(t, ": keep-alive\n\n"+
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"hel"}}]}`+"\n\n"+
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"f","arguments":"{\"a\""}}]}}]}`+"\n\n"+
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"lo","tool_calls":[{"index":0,"function":{"arguments":":1}"}}]},"finish_reason":"tool_calls"}]}`+"\n\n"+
+		`data: {"id":"c1","model":"m","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`+"\n\n"+
+		"data: [DONE]\n\n"+
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"after done"}}]}`+"\n\n")
+
+	var acc StreamAccumulator
+	for _, chunk := range chunks {
+		if chunk.Err != nil {
+			t.Fatal(chunk.Err)
+		}
+		acc.Add(chunk)
+	}
+	resp := acc.Response()
+	message := resp.Choices[0].Message
+	if message.Content != "hello" {
+		t.Errorf("content = %q, want hello, nothing after [DONE]", message.Content)
+	}
+	if len(message.ToolCalls) != 1 || message.ToolCalls[0].ID != "call_1" || message.ToolCalls[0].Function.Arguments != `{"a":1}` {
+		t.Errorf("tool calls = %+v", message.ToolCalls)
+	}
+	if resp.Choices[0].FinishReason != "tool_calls" || resp.Usage.TotalTokens != 5 {
+		t.Errorf("finish reason = %q, usage = %+v", resp.Choices[0].FinishReason, resp.Usage)
+	}
+}
+
+func TestStreamOpenAICompatibleTruncated(t *testing.T) {
+	chunks := streamBody(t, `data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"hel"}}]}`+"\n\n"+
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"del`)
+
+	if len(chunks) != 2 || chunks[0].Content != "hel" {
+		t.Fatalf("chunks = %+v, want the complete event and an error", chunks)
+	}
+	if err := chunks[1].Err; !errors.Is(err, ErrMalformedResponse) {
+		t.Errorf("error = %v, want a malformed response", err)
+	}
+}
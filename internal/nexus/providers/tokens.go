@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// defaultContextWindow is assumed for models with no configured or known limit.
+const defaultContextWindow = 8192
+
+// Token estimation constants: about four characters of English text per token, a few
+// tokens of framing per message and a flat cost per attached image.
+const (
+	charsPerToken    = 4
+	messageOverhead  = 4
+	imageTokenCost   = 1000
+	toolCallOverhead = 8
+)
+
+// knownContextWindows lists the context windows of common model families by name
+// prefix. Longer prefixes come first so they win over their family.
+var knownContextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3.3", 131072},
+	{"llama3", 8192},
+	{"qwen2.5", 32768},
+	{"qwen3", 40960},
+	{"mistral", 32768},
+	{"gemma2", 8192},
+	{"gemma3", 131072},
+	{"deepseek-r1", 131072},
+}
+
+// ContextWindow returns the context limit of model in tokens: its context_windows
+// entry, the provider's context_window, the num_ctx option of an ollama provider, the
+// window of a known model family, or a conservative default.
+func (c ProviderConfig) ContextWindow(model string) int {
+	if tokens := c.ContextWindows[model]; tokens > 0 {
+		return tokens
+	}
+	if c.DefaultContextWindow > 0 {
+		return c.DefaultContextWindow
+	}
+	if numCtx, ok := c.Ollama.Options["num_ctx"]; ok {
+		switch n := numCtx.(type) {
+		case int:
+			return n
+		case float64:
+			return int(n)
+		}
+	}
+	name := strings.ToLower(model)
+	if idx := strings.LastIndex(name, "/"); idx != -1 {
+		name = name[idx+1:]
+	}
+	for _, known := range knownContextWindows {
+		if strings.HasPrefix(name, known.prefix) {
+			return known.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// ContextWindow returns the context limit of model served by provider.
+func ContextWindow(provider GenAIProvider, model string) int {
+	if limited, ok := Unwrap(provider).(interface{ ContextWindow(string) int }); ok {
+		return limited.ContextWindow(model)
+	}
+	return defaultContextWindow
+}
+
+// EstimateTokens approximates the prompt tokens of messages and tools. It errs on the
+// high side for English text and needs no tokenizer, which differs per model anyway.
+func EstimateTokens(messages []RequestMessage, tools []LLMTool) int {
+	tokens := 0
+	for _, msg := range messages {
+		tokens += messageOverhead + textTokens(msg.Content) + len(msg.Image)*imageTokenCost
+		for _, call := range msg.ToolCalls {
+			tokens += toolCallOverhead + textTokens(call.Function.Name) + textTokens(call.Function.Arguments)
+		}
+	}
+	if len(tools) > 0 {
+		if schema, err := json.Marshal(tools); err == nil {
+			tokens += textTokens(string(schema))
+		}
+	}
+	return tokens
+}
+
+func textTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
@@ -1,14 +1,33 @@
 package providers
 
+import "encoding/json"
+
 //import "nexus/providers"
 
 type RequestMessage struct {
-	Role    string   `json:"role"`
-	Content string   `json:"content"`
-	Image   []string `json:"image,omitempty"`
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Image lists images attached to the message as data URLs (see ImageDataURL) or
+	// http(s) URLs. Each provider encodes them in its own wire format.
+	Image []string `json:"-"`
+	// ToolCalls are the calls requested by an assistant message, and ToolCallID the
+	// call a tool message answers.
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
 	//Tools   []providers.Tool `json:"tool,omitempty"`
 }
 
+// ContentPart is an element of the multi-part content of an OpenAI chat message.
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
 type LLMTool struct {
 	Type     string      `json:"type"`
 	Function LLMFunction `json:"function"`
@@ -71,13 +90,28 @@ type ResponseUsage struct {
 	TotalTime        float64 `json:"total_time"`
 }
 
+// GenerationConfig holds the sampling parameters of a request. Unset fields leave the
+// provider default in place. Providers that spell a parameter differently, or lack
+// it, map or drop it when building their request.
+type GenerationConfig struct {
+	Temperature      *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	TopP             *float64 `yaml:"top_p,omitempty" json:"top_p,omitempty"`
+	MaxTokens        *int     `yaml:"max_tokens,omitempty" json:"max_tokens,omitempty"`
+	Stop             []string `yaml:"stop,omitempty" json:"stop,omitempty"`
+	Seed             *int     `yaml:"seed,omitempty" json:"seed,omitempty"`
+	FrequencyPenalty *float64 `yaml:"frequency_penalty,omitempty" json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `yaml:"presence_penalty,omitempty" json:"presence_penalty,omitempty"`
+}
+
 type GenAIProviderRequest struct {
 	Model           string           `json:"model"`
 	Messages        []RequestMessage `json:"messages"`
 	ResponseFormat  interface{}      `json:"response_format,omitempty"`
 	ReasoningFormat string           `json:"reasoning_format,omitempty"`
 	Stream          bool             `json:"stream"`
-	Tools           []LLMTool        `json:"tools"`
+	StreamOptions   *StreamOptions   `json:"stream_options,omitempty"`
+	Tools           []LLMTool        `json:"tools,omitempty"`
+	GenerationConfig
 }
 
 type GenAIProviderResponse struct {
@@ -90,22 +124,206 @@ type GenAIProviderResponse struct {
 	SystemFingerprint string           `json:"system_fingerprint"`
 }
 
+// StreamOptions asks OpenAI-compatible APIs to report usage in the final stream chunk.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
 type ResponseFormat struct {
-	Type string `json:"type"`
-}
-
-// type GenAIProviderResponse struct {
-// 	Model     string    `json:"model"`
-// 	CreatedAt string    `json:"created_at"`
-// 	Response  string    `json:"response"`
-// 	Done      bool      `json:"done"`
-// 	Context   []int     `json:"context"`
-// 	TotalDuration int64 `json:"total_duration"`
-// 	LoadDuration int64 `json:"load_duration"`
-// 	PromptEvalCount int64 `json:"prompt_eval_count"`
-// 	PromptEvalDuration int64 `json:"prompt_eval_duration"`
-// 	EvalCount int64 `json:"eval_count"`
-// 	EvalDuration int64 `json:"eval_duration"`
-// }
-
-// TODO:Support Streaming for Ollama
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is the payload of a `json_schema` response format.
+type JSONSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict,omitempty"`
+}
+
+// Capabilities describes optional features of a provider.
+type Capabilities struct {
+	// JSONSchema is set when the provider accepts `response_format: json_schema`.
+	JSONSchema bool
+	// ReasoningFormat is set when the provider accepts the `reasoning_format` parameter.
+	ReasoningFormat bool
+}
+
+// Streaming types
+
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function ToolCallFunc `json:"function"`
+}
+
+type OpenAIStreamDelta struct {
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	Thought   string          `json:"reasoning,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+type OpenAIStreamChoice struct {
+	Index        int               `json:"index"`
+	Delta        OpenAIStreamDelta `json:"delta"`
+	FinishReason string            `json:"finish_reason"`
+}
+
+type OpenAIStreamChunk struct {
+	ID      string               `json:"id"`
+	Model   string               `json:"model"`
+	Choices []OpenAIStreamChoice `json:"choices"`
+	Usage   *ResponseUsage       `json:"usage,omitempty"`
+	XGroq   *struct {
+		Usage *ResponseUsage `json:"usage,omitempty"`
+	} `json:"x_groq,omitempty"`
+}
+
+// StreamChunk is a provider neutral increment of a streamed completion. Tool call
+// arguments arrive as fragments keyed by ToolCallDelta.Index; use StreamAccumulator
+// to reassemble them. A chunk carrying Err is always the last one on the channel.
+type StreamChunk struct {
+	ID           string
+	Model        string
+	Content      string
+	Thought      string
+	ToolCalls    []ToolCallDelta
+	FinishReason string
+	Usage        *ResponseUsage
+	Err          error
+}
+
+// Ollama native API wire types
+
+type OllamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type OllamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []OllamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type OllamaChatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []OllamaMessage        `json:"messages"`
+	Tools     []LLMTool              `json:"tools,omitempty"`
+	Format    interface{}            `json:"format,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive interface{}            `json:"keep_alive,omitempty"`
+	Stream    bool                   `json:"stream"`
+}
+
+// OllamaChatResponse is both the blocking reply of /api/chat and each line of its
+// stream; usage counts are only set once Done.
+type OllamaChatResponse struct {
+	Model           string        `json:"model"`
+	CreatedAt       string        `json:"created_at"`
+	Message         OllamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason,omitempty"`
+	TotalDuration   int64         `json:"total_duration,omitempty"`
+	PromptEvalCount int           `json:"prompt_eval_count,omitempty"`
+	EvalCount       int           `json:"eval_count,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+type OllamaModel struct {
+	Name  string `json:"name"`
+	Model string `json:"model"`
+}
+
+type OllamaTagsResponse struct {
+	Models []OllamaModel `json:"models"`
+}
+
+type OllamaPullRequest struct {
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+}
+
+type OllamaPullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Anthropic Messages API wire types
+
+type AnthropicContentBlock struct {
+	Type      string                 `json:"type"`
+	Text      string                 `json:"text,omitempty"`
+	ID        string                 `json:"id,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Input     json.RawMessage        `json:"input,omitempty"`
+	ToolUseID string                 `json:"tool_use_id,omitempty"`
+	Content   string                 `json:"content,omitempty"`
+	Source    map[string]interface{} `json:"source,omitempty"`
+}
+
+type AnthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []AnthropicContentBlock `json:"content"`
+}
+
+type AnthropicTool struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	InputSchema LLMFunctionParameters `json:"input_schema"`
+}
+
+type AnthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []AnthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Tools         []AnthropicTool    `json:"tools,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type AnthropicResponse struct {
+	ID         string                  `json:"id"`
+	Type       string                  `json:"type"`
+	Role       string                  `json:"role"`
+	Model      string                  `json:"model"`
+	Content    []AnthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      AnthropicUsage          `json:"usage"`
+}
+
+type AnthropicStreamEvent struct {
+	Type         string                 `json:"type"`
+	Index        int                    `json:"index"`
+	Message      *AnthropicResponse     `json:"message,omitempty"`
+	ContentBlock *AnthropicContentBlock `json:"content_block,omitempty"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *AnthropicUsage `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
@@ -0,0 +1,112 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileStore keeps each session as a JSON file in a directory.
+type FileStore struct {
+	Dir string
+}
+
+// NewFileStore returns a store in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating session directory %s: %w", dir, err)
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (f *FileStore) path(id string) string {
+	return filepath.Join(f.Dir, id+".json")
+}
+
+// Save writes the session to a temporary file first, so a crash never leaves a
+// truncated session behind.
+func (f *FileStore) Save(s *Session) error {
+	if err := ValidateID(s.ID); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding session %s: %w", s.ID, err)
+	}
+	tmp, err := os.CreateTemp(f.Dir, s.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("saving session %s: %w", s.ID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving session %s: %w", s.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving session %s: %w", s.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path(s.ID)); err != nil {
+		return fmt.Errorf("saving session %s: %w", s.ID, err)
+	}
+	return nil
+}
+
+func (f *FileStore) Load(id string) (*Session, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading session %s: %w", id, err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decoding session %s: %w", id, err)
+	}
+	return &s, nil
+}
+
+// List returns the stored sessions, most recently updated first. Unreadable files
+// are logged and skipped.
+func (f *FileStore) List() ([]Summary, error) {
+	entries, err := os.ReadDir(f.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+	var summaries []Summary
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		s, err := f.Load(id)
+		if err != nil {
+			slog.Warn("Skipping unreadable session", "file", entry.Name(), "error", err)
+			continue
+		}
+		summaries = append(summaries, s.Summarize())
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Updated.After(summaries[j].Updated)
+	})
+	return summaries, nil
+}
+
+func (f *FileStore) Delete(id string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+	err := os.Remove(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return err
+}
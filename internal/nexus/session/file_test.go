@@ -0,0 +1,179 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/providers"
+)
+
+func newStore(t *testing.T) *FileStore {
+	t.Helper()
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "sessions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// conversation is a session with a user request carrying an image, a tool call in
+// an agent history and a plan.
+func conversation(id string, updated time.Time) *Session {
+	image := "data:image/png;base64,iVBORw0KGgo="
+	return &Session{
+		ID:        id,
+		Workspace: "research",
+		Created:   updated.Add(-time.Hour),
+		Updated:   updated,
+		Orchestrator: []*executors.ChatRecord{
+			{From: "user", To: "orchestrator", Role: "user", Message: "What is in this picture?\nBe brief.", Image: []string{image}},
+			{From: "orchestrator", To: "user", Role: "assistant", Message: `{"action":"answer","answer":"a cat"}`},
+		},
+		Agents: map[string][]*executors.ChatRecord{
+			"looker": {
+				{From: "orchestrator", To: "agent", Role: "user", Message: "look", Image: []string{image}},
+				{From: "agent", To: "tool", Role: "assistant", ToolCalls: []providers.ToolCall{{ID: "call_0", Type: "function", Function: providers.ToolCallFunc{Name: "zoom", Arguments: "{}"}}}},
+				{From: "tool", To: "agent", Role: "tool", Message: "zoomed", ToolCallID: "call_0"},
+			},
+		},
+		Plan:          &executors.PlannerResponse{Request: "look", Response: []*executors.PlannerTask{{ID: "t1", Task: "look", Agent: "looker"}}, Image: []string{image}},
+		PlanConfirmed: true,
+		Traces:        []Trace{{Time: updated, Source: "looker", Kind: "tool", Message: "zooming"}},
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := newStore(t)
+	saved := conversation("s1", time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err := store.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.Load("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, saved) {
+		t.Errorf("loaded session = %+v, want %+v", loaded, saved)
+	}
+	if image := loaded.Orchestrator[0].Image; len(image) != 1 || image[0] != saved.Orchestrator[0].Image[0] {
+		t.Errorf("request image = %q, want it kept", image)
+	}
+	if len(loaded.Plan.Image) != 1 || len(loaded.Agents["looker"][0].Image) != 1 {
+		t.Error("plan or agent images not kept")
+	}
+
+	entries, err := os.ReadDir(store.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "s1.json" {
+		t.Errorf("session directory holds %v, want only s1.json", entries)
+	}
+}
+
+func TestFileStoreSaveOverwrites(t *testing.T) {
+	store := newStore(t)
+	s := conversation("s1", time.Now())
+	if err := store.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	s.Orchestrator = s.Orchestrator[:1]
+	s.Plan = nil
+	if err := store.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.Load("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Orchestrator) != 1 || loaded.Plan != nil {
+		t.Errorf("loaded session = %+v, want the second save", loaded)
+	}
+}
+
+func TestFileStoreList(t *testing.T) {
+	store := newStore(t)
+	now := time.Now()
+	for i, id := range []string{"old", "new", "middle"} {
+		updated := now.Add(-time.Hour * time.Duration([]int{3, 1, 2}[i]))
+		if err := store.Save(conversation(id, updated)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Corrupt sessions, other files and directories are left out
+	os.WriteFile(filepath.Join(store.Dir, "broken.json"), []byte(`{"id":"broken",`), 0600)
+	os.WriteFile(filepath.Join(store.Dir, "notes.txt"), []byte("notes"), 0600)
+	os.Mkdir(filepath.Join(store.Dir, "dir.json"), 0700)
+
+	summaries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, summary := range summaries {
+		ids = append(ids, summary.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"new", "middle", "old"}) {
+		t.Errorf("listed %v, want new, middle, old", ids)
+	}
+	if summary := summaries[0]; summary.Title != "What is in this picture?" || summary.Messages != 2 || summary.Workspace != "research" {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestFileStoreLoadErrors(t *testing.T) {
+	store := newStore(t)
+	os.WriteFile(filepath.Join(store.Dir, "broken.json"), []byte(`{"id":"broken",`), 0600)
+
+	if _, err := store.Load("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing session error = %v, want not found", err)
+	}
+	if _, err := store.Load("broken"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("corrupt session error = %v, want a decoding error", err)
+	}
+	if _, err := store.Load("../secrets"); err == nil {
+		t.Error("loaded a session outside the store")
+	}
+}
+
+func TestFileStoreDelete(t *testing.T) {
+	store := newStore(t)
+	if err := store.Save(conversation("s1", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("s1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("s1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted session still loads: %v", err)
+	}
+	if err := store.Delete("s1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second delete error = %v, want not found", err)
+	}
+}
+
+func TestSaveRejectsInvalidIDs(t *testing.T) {
+	store := newStore(t)
+	for _, id := range []string{"", "../up", "a/b", "a.json"} {
+		if err := store.Save(&Session{ID: id}); err == nil {
+			t.Errorf("saved session with ID %q", id)
+		}
+	}
+}
+
+func TestAddTraceKeepsTheLatest(t *testing.T) {
+	s := New("research")
+	for i := 0; i < maxTraces+10; i++ {
+		s.AddTrace("agent", "info", strconv.Itoa(i))
+	}
+	if len(s.Traces) != maxTraces || s.Traces[0].Message != "10" || s.Traces[maxTraces-1].Message != strconv.Itoa(maxTraces+9) {
+		t.Errorf("kept %d traces, %q to %q", len(s.Traces), s.Traces[0].Message, s.Traces[len(s.Traces)-1].Message)
+	}
+}
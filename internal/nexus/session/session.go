@@ -0,0 +1,75 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxTraces bounds the traces kept per session.
+const maxTraces = 500
+
+// maxTitleLength caps the title shown for a session in listings.
+const maxTitleLength = 60
+
+// ErrNotFound is returned for session IDs that are not stored.
+var ErrNotFound = errors.New("session not found")
+
+// Store persists sessions by ID.
+type Store interface {
+	Save(s *Session) error
+	Load(id string) (*Session, error)
+	List() ([]Summary, error)
+	Delete(id string) error
+}
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidateID rejects IDs that could not be used as a file name.
+func ValidateID(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid session id %q", id)
+	}
+	return nil
+}
+
+// NewID returns a session ID that sorts by creation time.
+func NewID() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// New starts an empty session of workspace.
+func New(workspace string) *Session {
+	now := time.Now()
+	return &Session{ID: NewID(), Workspace: workspace, Created: now, Updated: now}
+}
+
+// AddTrace records a message sent to the client, dropping the oldest past maxTraces.
+func (s *Session) AddTrace(source string, kind string, message string) {
+	s.Traces = append(s.Traces, Trace{Time: time.Now(), Source: source, Kind: kind, Message: message})
+	if len(s.Traces) > maxTraces {
+		s.Traces = s.Traces[len(s.Traces)-maxTraces:]
+	}
+}
+
+// Summarize describes the session for listings.
+func (s *Session) Summarize() Summary {
+	summary := Summary{ID: s.ID, Workspace: s.Workspace, Created: s.Created, Updated: s.Updated, Messages: len(s.Orchestrator)}
+	for _, record := range s.Orchestrator {
+		if record.From == "user" {
+			title, _, _ := strings.Cut(strings.TrimSpace(record.Message), "\n")
+			if len(title) > maxTitleLength {
+				title = title[:maxTitleLength] + "..."
+			}
+			summary.Title = title
+			break
+		}
+	}
+	return summary
+}
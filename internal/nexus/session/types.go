@@ -0,0 +1,44 @@
+package session
+
+import (
+	"time"
+
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/usage"
+)
+
+// Session is the persisted state of one conversation with a workspace.
+type Session struct {
+	ID        string    `json:"id"`
+	Workspace string    `json:"workspace"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+	// Orchestrator is the history of the orchestrator and Agents that of each agent.
+	Orchestrator  []*executors.ChatRecord            `json:"orchestrator,omitempty"`
+	Agents        map[string][]*executors.ChatRecord `json:"agents,omitempty"`
+	Plan          *executors.PlannerResponse         `json:"plan,omitempty"`
+	PlanConfirmed bool                               `json:"plan_confirmed,omitempty"`
+	// Usage is what the session spent on models, counted against the per session budget.
+	Usage usage.Totals `json:"usage"`
+	// Traces are the messages sent to the client, up to the last maxTraces.
+	Traces []Trace `json:"traces,omitempty"`
+}
+
+// Trace is one message sent to the client during the session.
+type Trace struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source,omitempty"`
+	Kind    string    `json:"kind,omitempty"`
+	Message string    `json:"message"`
+}
+
+// Summary describes a stored session for listings.
+type Summary struct {
+	ID        string
+	Workspace string
+	Created   time.Time
+	Updated   time.Time
+	// Messages counts the orchestrator history and Title is its first user message.
+	Messages int
+	Title    string
+}
@@ -0,0 +1,62 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBudgetExhausted is matched by every BudgetError.
+var ErrBudgetExhausted = errors.New("budget exhausted")
+
+// BudgetError reports which budget limit stopped a model call.
+type BudgetError struct {
+	Limit string
+	Used  float64
+	Max   float64
+	// Spend is set for currency limits, which are printed with decimals.
+	Spend bool
+}
+
+func (e *BudgetError) Error() string {
+	if e.Spend {
+		return fmt.Sprintf("Budget exhausted: the %s limit of %.4f was reached (%.4f spent).", e.Limit, e.Max, e.Used)
+	}
+	return fmt.Sprintf("Budget exhausted: the %s limit of %.0f tokens was reached (%.0f used).", e.Limit, e.Max, e.Used)
+}
+
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExhausted
+}
+
+// check compares the totals of a request, session and day with the budget.
+func (b Budget) check(request Totals, session Totals, day Totals) error {
+	tokenLimits := []struct {
+		limit string
+		used  int
+		max   int
+	}{
+		{"per request token", request.TotalTokens, b.MaxTokensPerRequest},
+		{"per session token", session.TotalTokens, b.MaxTokensPerSession},
+		{"daily token", day.TotalTokens, b.MaxTokensPerDay},
+	}
+	for _, l := range tokenLimits {
+		if l.max > 0 && l.used >= l.max {
+			return &BudgetError{Limit: l.limit, Used: float64(l.used), Max: float64(l.max)}
+		}
+	}
+
+	spendLimits := []struct {
+		limit string
+		used  float64
+		max   float64
+	}{
+		{"per session spend", session.Cost, b.MaxSpendPerSession},
+		{"daily spend", day.Cost, b.MaxSpendPerDay},
+	}
+	for _, l := range spendLimits {
+		if l.max > 0 && l.used >= l.max {
+			return &BudgetError{Limit: l.limit, Used: l.used, Max: l.max, Spend: true}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"yafai/internal/nexus/providers"
+)
+
+// Lookup returns the price of model, given as "provider/model". An entry for the
+// full name wins over one for the bare model name.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+	if idx := strings.Index(model, "/"); idx != -1 {
+		price, ok := t[model[idx+1:]]
+		return price, ok
+	}
+	return Price{}, false
+}
+
+// Cost prices the tokens of a single call.
+func (p Price) Cost(promptTokens int, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1e6
+}
+
+func (t *Totals) add(u providers.ResponseUsage, cost float64) {
+	t.Calls++
+	t.PromptTokens += u.PromptTokens
+	t.CompletionTokens += u.CompletionTokens
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	t.TotalTokens += total
+	t.Cost += cost
+}
+
+// String renders the totals as the one line summary shown in the TUI status pane.
+func (t Totals) String() string {
+	return fmt.Sprintf("%d tokens (%d in / %d out) in %d calls, cost %.4f", t.TotalTokens, t.PromptTokens, t.CompletionTokens, t.Calls, t.Cost)
+}
+
+type breakdown struct {
+	total   Totals
+	byAgent map[string]*Totals
+	byModel map[string]*Totals
+}
+
+func newBreakdown() *breakdown {
+	return &breakdown{byAgent: map[string]*Totals{}, byModel: map[string]*Totals{}}
+}
+
+func (b *breakdown) add(agent string, model string, u providers.ResponseUsage, cost float64) {
+	b.total.add(u, cost)
+	entry(b.byAgent, agent).add(u, cost)
+	entry(b.byModel, model).add(u, cost)
+}
+
+func entry(group map[string]*Totals, key string) *Totals {
+	totals, ok := group[key]
+	if !ok {
+		totals = &Totals{}
+		group[key] = totals
+	}
+	return totals
+}
+
+func (b *breakdown) report() Report {
+	report := Report{Total: b.total, ByAgent: map[string]Totals{}, ByModel: map[string]Totals{}}
+	for agent, totals := range b.byAgent {
+		report.ByAgent[agent] = *totals
+	}
+	for model, totals := range b.byModel {
+		report.ByModel[model] = *totals
+	}
+	return report
+}
+
+// Ledger accumulates token usage and cost for a workspace, broken down per connection,
+// per agent and per model. The per session budget is kept per conversation session, so
+// it carries over when a session is resumed. It is safe for concurrent use.
+type Ledger struct {
+	mu          sync.Mutex
+	prices      PriceTable
+	budget      Budget
+	workspace   *breakdown
+	connections map[string]*breakdown
+	// sessions holds the totals of each session and bound the session each connection
+	// is in. A connection not bound to a session is its own session.
+	sessions map[string]*Totals
+	bound    map[string]string
+	day      string
+	today    Totals
+}
+
+func NewLedger(prices PriceTable, budget Budget) *Ledger {
+	return &Ledger{prices: prices, budget: budget, workspace: newBreakdown(), connections: map[string]*breakdown{}, sessions: map[string]*Totals{}, bound: map[string]string{}}
+}
+
+// Bind puts connection in session, whose earlier usage is used unless the ledger
+// already counts it. The session the connection leaves is forgotten; its usage is
+// kept by the caller, see Session.
+func (l *Ledger) Bind(connection string, session string, used Totals) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous := l.sessionOf(connection)
+	if previous != session {
+		delete(l.sessions, previous)
+	}
+	l.bound[connection] = session
+	if _, ok := l.sessions[session]; !ok {
+		l.sessions[session] = &used
+	}
+}
+
+// Session returns the usage counted against the per session budget of session.
+func (l *Ledger) Session(session string) Totals {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if totals, ok := l.sessions[session]; ok {
+		return *totals
+	}
+	return Totals{}
+}
+
+// Close forgets connection and its session once the client has gone away.
+func (l *Ledger) Close(connection string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.sessions, l.sessionOf(connection))
+	delete(l.bound, connection)
+	delete(l.connections, connection)
+}
+
+// sessionOf returns the session connection is in.
+func (l *Ledger) sessionOf(connection string) string {
+	if session, ok := l.bound[connection]; ok {
+		return session
+	}
+	return connection
+}
+
+// sessionTotals returns the totals of the session connection is in.
+func (l *Ledger) sessionTotals(connection string) *Totals {
+	session := l.sessionOf(connection)
+	totals, ok := l.sessions[session]
+	if !ok {
+		totals = &Totals{}
+		l.sessions[session] = totals
+	}
+	return totals
+}
+
+// rollDay resets the daily totals once the local date has changed.
+func (l *Ledger) rollDay() {
+	if day := time.Now().Format(time.DateOnly); day != l.day {
+		l.day = day
+		l.today = Totals{}
+	}
+}
+
+// Record adds the usage of one model call made by agent on connection. A non-nil
+// request additionally collects the usage of the user request being answered.
+func (l *Ledger) Record(connection string, request *Totals, agent string, model string, u providers.ResponseUsage) {
+	price, _ := l.prices.Lookup(model)
+	cost := price.Cost(u.PromptTokens, u.CompletionTokens)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	conn, ok := l.connections[connection]
+	if !ok {
+		conn = newBreakdown()
+		l.connections[connection] = conn
+	}
+	conn.add(agent, model, u, cost)
+	l.sessionTotals(connection).add(u, cost)
+	l.workspace.add(agent, model, u, cost)
+	l.rollDay()
+	l.today.add(u, cost)
+	if request != nil {
+		request.add(u, cost)
+	}
+}
+
+// Check returns a *BudgetError when another model call on connection would exceed
+// the workspace budget.
+func (l *Ledger) Check(connection string, request *Totals) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var session, current Totals
+	if totals, ok := l.sessions[l.sessionOf(connection)]; ok {
+		session = *totals
+	}
+	if request != nil {
+		current = *request
+	}
+	l.rollDay()
+	return l.budget.check(current, session, l.today)
+}
+
+// Report returns the usage of connection, or of the whole workspace including the
+// per connection totals when connection is empty.
+func (l *Ledger) Report(connection string) Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if connection != "" {
+		if conn, ok := l.connections[connection]; ok {
+			return conn.report()
+		}
+		return newBreakdown().report()
+	}
+
+	report := l.workspace.report()
+	report.Connections = make(map[string]Totals, len(l.connections))
+	for id, conn := range l.connections {
+		report.Connections[id] = conn.total
+	}
+	return report
+}
+
+type contextKey struct{}
+
+type scope struct {
+	ledger     *Ledger
+	connection string
+	request    *Totals
+}
+
+// WithLedger returns a context whose model calls are recorded in ledger under connection.
+func WithLedger(ctx context.Context, ledger *Ledger, connection string) context.Context {
+	if ledger == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, scope{ledger: ledger, connection: connection})
+}
+
+// WithRequest starts collecting the usage of a new user request, so the per request
+// budget applies to every model call made under the returned context.
+func WithRequest(ctx context.Context) context.Context {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	if !ok {
+		return ctx
+	}
+	s.request = &Totals{}
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// Record adds the usage of a model call to the ledger carried by ctx, if any.
+func Record(ctx context.Context, agent string, model string, u providers.ResponseUsage) {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	if !ok {
+		return
+	}
+	s.ledger.Record(s.connection, s.request, agent, model, u)
+}
+
+// Check enforces the budget of the ledger carried by ctx before a model call. It
+// returns nil when ctx carries no ledger.
+func Check(ctx context.Context) error {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	if !ok {
+		return nil
+	}
+	return s.ledger.Check(s.connection, s.request)
+}
@@ -0,0 +1,101 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+
+	"yafai/internal/nexus/providers"
+)
+
+func call(tokens int) providers.ResponseUsage {
+	return providers.ResponseUsage{PromptTokens: tokens / 2, CompletionTokens: tokens - tokens/2, TotalTokens: tokens}
+}
+
+func TestLedgerSessionBudgetCarriesOver(t *testing.T) {
+	l := NewLedger(nil, Budget{MaxTokensPerSession: 100})
+
+	l.Bind("conn_1", "session_a", Totals{})
+	l.Record("conn_1", nil, "orchestrator", "groq/m", call(60))
+	used := l.Session("session_a")
+	if used.TotalTokens != 60 {
+		t.Fatalf("session usage = %+v, want 60 tokens", used)
+	}
+	l.Close("conn_1")
+
+	// The session resumed on a new connection, with the usage saved with it
+	l.Bind("conn_2", "session_a", used)
+	if err := l.Check("conn_2", nil); err != nil {
+		t.Fatalf("Check below the limit: %v", err)
+	}
+	l.Record("conn_2", nil, "orchestrator", "groq/m", call(40))
+	err := l.Check("conn_2", nil)
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != "per session token" || budgetErr.Used != 100 {
+		t.Fatalf("Check = %v, want the per session limit reached at 100 tokens", err)
+	}
+
+	// A new session on the same connection starts from nothing
+	l.Bind("conn_2", "session_b", Totals{})
+	if err := l.Check("conn_2", nil); err != nil {
+		t.Errorf("Check in a new session: %v", err)
+	}
+}
+
+func TestLedgerBindKeepsCountedUsage(t *testing.T) {
+	l := NewLedger(nil, Budget{})
+	l.Bind("conn_1", "session_a", Totals{TotalTokens: 10})
+	l.Record("conn_1", nil, "agent", "m", call(5))
+	// Usage saved before the last calls does not replace what the ledger counted
+	l.Bind("conn_1", "session_a", Totals{TotalTokens: 10})
+	if got := l.Session("session_a").TotalTokens; got != 15 {
+		t.Errorf("session tokens = %d, want 15", got)
+	}
+}
+
+func TestLedgerUnboundConnectionIsItsOwnSession(t *testing.T) {
+	l := NewLedger(nil, Budget{MaxTokensPerSession: 50})
+	l.Record("conn_1", nil, "agent", "m", call(50))
+	if err := l.Check("conn_1", nil); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Check = %v, want the session budget exhausted", err)
+	}
+	if err := l.Check("conn_2", nil); err != nil {
+		t.Errorf("Check on another connection: %v", err)
+	}
+}
+
+func TestLedgerClosePrunesConnection(t *testing.T) {
+	l := NewLedger(nil, Budget{})
+	l.Bind("conn_1", "session_a", Totals{})
+	l.Record("conn_1", nil, "agent", "m", call(10))
+	l.Record("conn_2", nil, "agent", "m", call(20))
+	l.Close("conn_1")
+
+	report := l.Report("")
+	if _, ok := report.Connections["conn_1"]; ok || len(report.Connections) != 1 {
+		t.Errorf("connections = %v, want only conn_2", report.Connections)
+	}
+	if report.Total.TotalTokens != 30 {
+		t.Errorf("workspace tokens = %d, want 30 kept after the close", report.Total.TotalTokens)
+	}
+	if len(l.sessions) != 1 || len(l.bound) != 0 {
+		t.Errorf("sessions = %v, bound = %v, want only the session of conn_2", l.sessions, l.bound)
+	}
+}
+
+func TestLedgerRequestBudget(t *testing.T) {
+	l := NewLedger(PriceTable{"m": {Input: 1e6, Output: 1e6}}, Budget{MaxTokensPerRequest: 30, MaxSpendPerDay: 100})
+	request := &Totals{}
+	l.Record("conn_1", request, "agent", "m", call(20))
+	if err := l.Check("conn_1", request); err != nil {
+		t.Fatalf("Check below the limit: %v", err)
+	}
+	l.Record("conn_1", request, "agent", "m", call(10))
+	var budgetErr *BudgetError
+	if err := l.Check("conn_1", request); !errors.As(err, &budgetErr) || budgetErr.Limit != "per request token" {
+		t.Fatalf("Check = %v, want the per request limit", err)
+	}
+	// Each token costs 1, so 30 of the daily 100 are spent
+	if err := l.Check("conn_1", &Totals{}); err != nil {
+		t.Errorf("Check for a new request: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package usage
+
+// Price is the cost of a model in currency units per million tokens.
+type Price struct {
+	Input  float64 `yaml:"input" json:"input"`
+	Output float64 `yaml:"output" json:"output"`
+}
+
+// PriceTable maps "provider/model", or just "model", to its price.
+type PriceTable map[string]Price
+
+// Totals adds up the usage of a group of model calls.
+type Totals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// Report is a snapshot of the usage of one connection, or of the whole workspace.
+type Report struct {
+	Total       Totals            `json:"total"`
+	ByAgent     map[string]Totals `json:"by_agent"`
+	ByModel     map[string]Totals `json:"by_model"`
+	Connections map[string]Totals `json:"connections,omitempty"`
+}
+
+// Budget caps what a workspace may spend on models. Zero fields are unlimited. A
+// request is one user message, including every model call made while answering it;
+// a session is one conversation, across the connections resuming it, or one client
+// connection when sessions are not stored; days follow the local calendar.
+type Budget struct {
+	MaxTokensPerRequest int     `yaml:"max_tokens_per_request,omitempty" json:"max_tokens_per_request,omitempty"`
+	MaxTokensPerSession int     `yaml:"max_tokens_per_session,omitempty" json:"max_tokens_per_session,omitempty"`
+	MaxTokensPerDay     int     `yaml:"max_tokens_per_day,omitempty" json:"max_tokens_per_day,omitempty"`
+	MaxSpendPerSession  float64 `yaml:"max_spend_per_session,omitempty" json:"max_spend_per_session,omitempty"`
+	MaxSpendPerDay      float64 `yaml:"max_spend_per_day,omitempty" json:"max_spend_per_day,omitempty"`
+}
@@ -0,0 +1,69 @@
+package workspace
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/session"
+)
+
+// Runtime is the conversation state of one connection: an orchestrator and team
+// cloned from the workspace config, so connections never share histories. The
+// workspace itself stays read-only once loaded.
+type Runtime struct {
+	Workspace    string
+	Orchestrator *executors.YafaiOrchestrator
+	mu           sync.Mutex
+}
+
+// NewRuntime returns fresh conversation state for a connection.
+func (w *Workspace) NewRuntime() *Runtime {
+	runtime := &Runtime{Workspace: w.Name}
+	if w.Orchestrator != nil {
+		runtime.Orchestrator = w.Orchestrator.Clone()
+	}
+	return runtime
+}
+
+// Snapshot copies the histories and plan of the runtime into s.
+func (r *Runtime) Snapshot(s *session.Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s.Updated = time.Now()
+	if r.Orchestrator == nil {
+		return
+	}
+	s.Orchestrator = append([]*executors.ChatRecord(nil), r.Orchestrator.History...)
+	s.Plan = r.Orchestrator.Plan
+	s.PlanConfirmed = r.Orchestrator.PlanConfirmed
+	s.Agents = make(map[string][]*executors.ChatRecord, len(r.Orchestrator.Team))
+	for name, agent := range r.Orchestrator.Team {
+		if len(agent.History) > 0 {
+			s.Agents[name] = append([]*executors.ChatRecord(nil), agent.History...)
+		}
+	}
+}
+
+// Restore replaces the histories and plan of the runtime with those of s. A new
+// session clears them.
+func (r *Runtime) Restore(s *session.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.Workspace != "" && s.Workspace != r.Workspace {
+		return fmt.Errorf("session %s belongs to workspace %q, not %q", s.ID, s.Workspace, r.Workspace)
+	}
+	if r.Orchestrator == nil {
+		return nil
+	}
+	r.Orchestrator.History = append([]*executors.ChatRecord(nil), s.Orchestrator...)
+	r.Orchestrator.Plan = s.Plan
+	r.Orchestrator.PlanConfirmed = s.PlanConfirmed
+	for name, agent := range r.Orchestrator.Team {
+		agent.History = append([]*executors.ChatRecord(nil), s.Agents[name]...)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package workspace
+
+import "sync"
+
+const (
+	// statusHistory is the number of recent messages replayed to new subscribers.
+	statusHistory = 20
+	// statusBuffer is the number of messages a subscriber may fall behind by.
+	statusBuffer = 64
+)
+
+// StatusHub fans workspace status messages, such as model pull progress, out to the
+// connected clients. Clients connecting late first receive the recent history.
+type StatusHub struct {
+	mu          sync.Mutex
+	history     []string
+	subscribers map[chan string]struct{}
+}
+
+func NewStatusHub() *StatusHub {
+	return &StatusHub{subscribers: map[chan string]struct{}{}}
+}
+
+// Publish sends message to every subscriber. Subscribers that are not keeping up
+// miss the message rather than blocking the publisher.
+func (h *StatusHub) Publish(message string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.history = append(h.history, message)
+	if len(h.history) > statusHistory {
+		h.history = h.history[len(h.history)-statusHistory:]
+	}
+	for subscriber := range h.subscribers {
+		select {
+		case subscriber <- message:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of status messages, starting with the recent history,
+// and a function that ends the subscription.
+func (h *StatusHub) Subscribe() (<-chan string, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscriber := make(chan string, statusBuffer)
+	for _, message := range h.history {
+		subscriber <- message
+	}
+	h.subscribers[subscriber] = struct{}{}
+
+	var once sync.Once
+	return subscriber, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers, subscriber)
+			close(subscriber)
+		})
+	}
+}
@@ -3,15 +3,24 @@ package workspace
 import (
 	"sync"
 	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/providers"
+	"yafai/internal/nexus/session"
+	"yafai/internal/nexus/usage"
 )
 
 type Workspace struct {
-	Name         string                       `json:"name" yaml:"name"`
-	Scope        string                       `json:"scope" yaml:"scope"`
-	Planner      *executors.YafaiPlanner      `json:"planner" yaml:"planner"`
-	Orchestrator *executors.YafaiOrchestrator `json:"orchestrator,omitempty" yaml:"orchestrator,omitempty"`
-	Integrations []string                     `json:"integrations,omitempty" yaml:"integrations,omitempty"`
-	VectorStore  string                       `json:"vector_store,omitempty" yaml:"vector_store,omitempty"`
-	Bridge       string                       `json:"bridge" yaml:"bridge"`
-	ListenerPool sync.WaitGroup               `json:"pool" yaml:"pool"`
+	Name         string                              `json:"name" yaml:"name"`
+	Scope        string                              `json:"scope" yaml:"scope"`
+	Planner      *executors.YafaiPlanner             `json:"planner" yaml:"planner"`
+	Orchestrator *executors.YafaiOrchestrator        `json:"orchestrator,omitempty" yaml:"orchestrator,omitempty"`
+	Providers    map[string]providers.ProviderConfig `json:"providers,omitempty" yaml:"providers,omitempty"`
+	Pricing      usage.PriceTable                    `json:"pricing,omitempty" yaml:"pricing,omitempty"`
+	Budget       usage.Budget                        `json:"budget,omitempty" yaml:"budget,omitempty"`
+	Usage        *usage.Ledger                       `json:"-" yaml:"-"`
+	Status       *StatusHub                          `json:"-" yaml:"-"`
+	Sessions     session.Store                       `json:"-" yaml:"-"`
+	Integrations []string                            `json:"integrations,omitempty" yaml:"integrations,omitempty"`
+	VectorStore  string                              `json:"vector_store,omitempty" yaml:"vector_store,omitempty"`
+	Bridge       string                              `json:"bridge" yaml:"bridge"`
+	ListenerPool sync.WaitGroup                      `json:"pool" yaml:"pool"`
 }
@@ -1,9 +1,89 @@
 package workspace
 
-import "log/slog"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"yafai/internal/nexus/executors"
+	"yafai/internal/nexus/providers"
+)
 
 func (w *Workspace) AttachVectorStore(vector_store string) (workspace *Workspace) {
 	w.VectorStore = vector_store
 	slog.Info("Attached Vector store!")
 	return w
 }
+
+// models lists every model the actors of the workspace generate with, fallbacks
+// included, keyed by provider name.
+func (w *Workspace) models() map[string][]executors.ModelRef {
+	byProvider := map[string][]executors.ModelRef{}
+	seen := map[string]bool{}
+	add := func(ref executors.ModelRef) {
+		if ref.Model == "" || ref.GenAIProvider == nil || seen[ref.String()] {
+			return
+		}
+		seen[ref.String()] = true
+		byProvider[ref.Provider] = append(byProvider[ref.Provider], ref)
+	}
+	addActor := func(primary executors.ModelRef, fallbacks []*executors.ModelRef) {
+		add(primary)
+		for _, fallback := range fallbacks {
+			if fallback != nil {
+				add(*fallback)
+			}
+		}
+	}
+
+	if w.Planner != nil {
+		addActor(executors.ModelRef{Provider: w.Planner.Provider, Model: w.Planner.Model, GenAIProvider: w.Planner.GenAIProvider}, w.Planner.Fallbacks)
+	}
+	if w.Orchestrator != nil {
+		addActor(executors.ModelRef{Provider: w.Orchestrator.Provider, Model: w.Orchestrator.Model, GenAIProvider: w.Orchestrator.GenAIProvider}, w.Orchestrator.Fallbacks)
+		for _, agent := range w.Orchestrator.Team {
+			addActor(executors.ModelRef{Provider: agent.Provider, Model: agent.Model, GenAIProvider: agent.GenAIProvider}, agent.Fallbacks)
+		}
+		if critic := w.Orchestrator.Critic; critic.Enabled() {
+			addActor(executors.ModelRef{Provider: critic.Provider, Model: critic.Model, GenAIProvider: critic.GenAIProvider}, critic.Fallbacks)
+		}
+	}
+	return byProvider
+}
+
+// PrepareModels makes sure the providers that can download models, such as Ollama,
+// hold every model the workspace references. Progress is published on the status
+// hub. Failures are reported but do not stop the workspace; calls to a missing model
+// fail with a model unavailable error instead.
+func (w *Workspace) PrepareModels(ctx context.Context) {
+	byProvider := w.models()
+	names := make([]string, 0, len(byProvider))
+	for name := range byProvider {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		refs := byProvider[name]
+		puller, ok := providers.Unwrap(refs[0].GenAIProvider).(providers.ModelPuller)
+		if !ok {
+			continue
+		}
+		models := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			models = append(models, ref.Model)
+		}
+		if err := puller.EnsureModels(ctx, models, w.publishStatus); err != nil {
+			slog.Error("Preparing models failed", "provider", name, "error", err)
+			w.publishStatus(fmt.Sprintf("%s: models not ready: %v", name, err))
+			continue
+		}
+		slog.Info("Models ready", "provider", name, "models", models)
+	}
+}
+
+func (w *Workspace) publishStatus(message string) {
+	if w.Status != nil {
+		w.Status.Publish(message)
+	}
+}